@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"notes-api/internal/app"
 	"notes-api/internal/config"
-	"notes-api/internal/storage"
+	"notes-api/internal/jwtkeys"
+	"notes-api/internal/tracing"
 	"notes-api/pkg/logger"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
@@ -18,18 +22,50 @@ func main() {
 }
 
 func run() error {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		return runMigrate(os.Args[2:])
+	}
+
 	cfg := config.MustLoad()
 	log := setupLogger()
-	storage, err := storage.New(cfg.StoragePath)
+	keys, err := jwtkeys.New(cfg.JWT, []byte(cfg.JwtSecret))
+	if err != nil {
+		log.Error("failed to load JWT keys", logger.Err(err))
+		return err
+	}
+
+	storage, err := app.NewStorage(cfg)
 	if err != nil {
 		log.Error("failed to init storage", logger.Err(err))
 		return err
 	}
+	defer func() {
+		if err := storage.Close(); err != nil {
+			log.Error("failed to close storage", logger.Err(err))
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		log.Error("failed to init tracing", logger.Err(err))
+		return err
+	}
+	defer func() {
+		// ctx may be done by now, so flushing spans gets a deadline of its own.
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
+		defer cancel()
+
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error("failed to flush spans", logger.Err(err))
+		}
+	}()
 
-	app := app.NewApp(cfg, storage, log, []byte(cfg.JwtSecret))
+	app := app.NewApp(cfg, storage, log, keys)
 
-	app.Start()
-	return nil
+	return app.Start(ctx)
 }
 
 func setupLogger() *slog.Logger {
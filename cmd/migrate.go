@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"notes-api/internal/config"
+	"notes-api/internal/migrate"
+	"notes-api/internal/storage/postgres"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+const migrateUsage = `usage: notes-api migrate <command> [arguments]
+
+commands:
+  status                  list migrations and whether they are applied
+  up [N]                  apply the next N pending migrations, or all of them
+  down [N]                revert the last N applied migrations (default 1)
+  create DIR NAME         add up and down files for a new migration to DIR
+
+status, up and down work on the database configured in CONFIG_PATH.`
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", migrateUsage)
+	}
+
+	command, args := args[0], args[1:]
+
+	if command == "create" {
+		if len(args) != 2 {
+			return fmt.Errorf("%s", migrateUsage)
+		}
+
+		up, down, err := migrate.Create(args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("created %s\ncreated %s\n", up, down)
+		return nil
+	}
+
+	steps := 0
+	if command == "down" {
+		steps = 1
+	}
+
+	if len(args) > 1 {
+		return fmt.Errorf("%s", migrateUsage)
+	}
+
+	if len(args) == 1 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return fmt.Errorf("number of migrations must be a positive integer")
+		}
+		steps = n
+	}
+
+	migrator, err := openMigrator(config.MustLoad())
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "status":
+		return printStatus(migrator)
+	case "up":
+		applied, err := migrator.Up(steps)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("database is up to date")
+		}
+		return err
+	case "down":
+		reverted, err := migrator.Down(steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Println("no migrations to revert")
+		}
+		return err
+	}
+
+	return fmt.Errorf("unknown migrate command %q\n%s", command, migrateUsage)
+}
+
+// openMigrator connects to the configured database without migrating it.
+func openMigrator(cfg *config.Config) (*migrate.Migrator, error) {
+	switch cfg.Storage.Driver {
+	case config.DriverSQLite:
+		return openSQLiteMigrator(cfg.StoragePath)
+	case config.DriverPostgres:
+		s, err := postgres.Open(cfg.Storage.DSN)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.Migrator()
+	case config.DriverMemory:
+		return nil, fmt.Errorf("the memory driver has no schema to migrate")
+	}
+
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+}
+
+func printStatus(migrator *migrate.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+
+	for _, s := range statuses {
+		status := "pending"
+		switch {
+		case s.Modified:
+			status = "modified"
+		case s.Applied:
+			status = "applied"
+		}
+
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, status, s.AppliedAt)
+	}
+
+	return w.Flush()
+}
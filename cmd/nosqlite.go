@@ -0,0 +1,12 @@
+//go:build !sqlite_fts5
+
+package main
+
+import (
+	"notes-api/internal/migrate"
+	"notes-api/internal/storage"
+)
+
+func openSQLiteMigrator(string) (*migrate.Migrator, error) {
+	return nil, storage.ErrNoSQLite
+}
@@ -0,0 +1,17 @@
+//go:build sqlite_fts5
+
+package main
+
+import (
+	"notes-api/internal/migrate"
+	"notes-api/internal/storage/sqlite"
+)
+
+func openSQLiteMigrator(path string) (*migrate.Migrator, error) {
+	s, err := sqlite.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Migrator()
+}
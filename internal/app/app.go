@@ -1,72 +1,250 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"notes-api/internal/config"
 	"notes-api/internal/handlers/auth"
+	"notes-api/internal/handlers/health"
 	"notes-api/internal/handlers/notes"
+	"notes-api/internal/handlers/tags"
+	"notes-api/internal/jwtkeys"
+	"notes-api/internal/mail"
+	"notes-api/internal/metrics"
 	"notes-api/internal/middleware"
-	"notes-api/internal/storage"
+	"notes-api/internal/models"
+	"notes-api/internal/openapi"
+	"notes-api/internal/password"
+	"notes-api/internal/problem"
+	"notes-api/internal/ratelimit"
+	"notes-api/internal/tracing"
 	"notes-api/pkg/logger"
+	"sync"
+	"sync/atomic"
 
 	"github.com/go-chi/chi/v5"
 	chiMW "github.com/go-chi/chi/v5/middleware"
 )
 
 type App struct {
-	config    *config.Config
-	storage   *storage.Storage
-	logger    *slog.Logger
-	jwtSecret []byte
+	config  *config.Config
+	storage Storage
+	logger  *slog.Logger
+	keys    *jwtkeys.KeySet
+	metrics *metrics.Metrics
+	spec    *openapi.Spec
+	limiter ratelimit.Store
+	hasher  *password.Hasher
+	mailer  mail.Mailer
+	// shuttingDown fails the readiness check once graceful shutdown begins.
+	shuttingDown atomic.Bool
 }
 
-func NewApp(config *config.Config, storage *storage.Storage, logger *slog.Logger, jwtSecret []byte) *App {
-	return &App{config: config, storage: storage, logger: logger, jwtSecret: jwtSecret}
+func NewApp(config *config.Config, storage Storage, logger *slog.Logger, keys *jwtkeys.KeySet) *App {
+	a := &App{config: config, storage: storage, logger: logger, keys: keys, metrics: metrics.New(), spec: openapi.MustLoad()}
+	a.limiter = newRateLimitStore(config.RateLimit.Store, storage)
+
+	// Passwords were hashed with bcrypt at cost 12 before argon2id.
+	a.hasher = password.New(password.Argon2id{
+		Memory:      config.PasswordHash.Memory,
+		Iterations:  config.PasswordHash.Iterations,
+		Parallelism: config.PasswordHash.Parallelism,
+	}, password.Bcrypt{Cost: 12})
+	a.mailer = mail.New(config.Mail, logger)
+	a.instrumentStorage()
+
+	return a
 }
 
 func (a *App) AddRoutes() http.Handler {
 	r := chi.NewRouter()
 
 	r.Use(chiMW.RequestID)
+	r.Use(tracing.Middleware)
+	r.Use(a.metrics.Middleware)
 	r.Use(middleware.LoggerMiddleware(a.logger))
+	r.Use(a.spec.Middleware(a.logger, a.config.Env == config.EnvDev))
+
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		problem.Write(w, r, problem.NotFound, "No route matches "+r.URL.Path)
+	})
+
+	r.Get("/healthz", health.HealthzHandler())
+	r.Get("/readyz", health.ReadyzHandler(a.logger, a.storage, a.shuttingDown.Load))
+	r.Get("/version", health.VersionHandler())
+
+	r.Get("/.well-known/jwks.json", a.keys.Handler())
+	r.Get("/openapi.json", a.spec.Handler())
+	r.Get("/docs", openapi.DocsHandler("/openapi.json"))
+
+	if a.config.HTTPServer.AdminAddress == "" {
+		r.Handle("/metrics", a.metrics.Handler())
+	}
+
+	ttl := auth.TokenTTL{
+		Access:       a.config.Auth.AccessTokenTTL,
+		Refresh:      a.config.Auth.RefreshTokenTTL,
+		MFAChallenge: a.config.Auth.MFAChallengeTTL,
+	}
+	lockout := auth.Lockout{
+		Threshold:   a.config.Auth.MaxFailedLogins,
+		Duration:    a.config.Auth.LockoutDuration,
+		MaxDuration: a.config.Auth.MaxLockoutDuration,
+	}
+	authLimit := ratelimit.Limit{Requests: a.config.RateLimit.AuthRequests, Period: a.config.RateLimit.AuthPeriod}
+	resets := auth.PasswordResets{TTL: a.config.Auth.PasswordResetTTL, URL: a.config.Mail.ResetURL}
+	apiLimit := ratelimit.Limit{Requests: a.config.RateLimit.APIRequests, Period: a.config.RateLimit.APIPeriod}
 
 	r.Route("/auth", func(r chi.Router) {
-		r.Post("/signup", auth.RegisterHandler(a.logger, a.storage))
-		r.Post("/signin", auth.LoginHandler(a.logger, a.storage, a.jwtSecret))
+		r.Use(middleware.RateLimitByIP(a.logger, a.limiter, authLimit, a.config.RateLimit.FailOpen, a.metrics))
+
+		r.Post("/signup", auth.RegisterHandler(a.logger, a.storage, a.hasher))
+		r.Post("/signin", auth.LoginHandler(a.logger, a.storage, a.hasher, a.keys, ttl, lockout))
+		r.Post("/refresh", auth.RefreshHandler(a.logger, a.storage, a.keys, ttl))
+		r.Post("/2fa/verify", auth.VerifyMFAHandler(a.logger, a.storage, a.keys, ttl, lockout))
+		r.Post("/password/forgot", auth.ForgotPasswordHandler(a.logger, a.storage, a.mailer, resets))
+		r.Post("/password/reset", auth.ResetPasswordHandler(a.logger, a.storage, a.hasher))
+
+		// Managing sessions and tokens needs a login session; personal access
+		// tokens cannot mint or revoke tokens.
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.JWTAuthMiddleware(a.keys, a.storage, a.metrics))
+
+			r.Post("/logout", auth.LogoutHandler(a.logger, a.storage))
+			r.Post("/logout-all", auth.LogoutAllHandler(a.logger, a.storage))
+			r.Post("/password", auth.ChangePasswordHandler(a.logger, a.storage, a.hasher, a.keys, ttl))
+			r.Post("/2fa/setup", auth.SetupTOTPHandler(a.logger, a.storage, a.config.Auth.TOTPIssuer))
+			r.Post("/2fa/confirm", auth.ConfirmTOTPHandler(a.logger, a.storage))
+			r.Post("/2fa/disable", auth.DisableTOTPHandler(a.logger, a.storage))
+			r.Get("/tokens", auth.PersonalTokensHandler(a.logger, a.storage))
+			r.Post("/tokens", auth.CreatePersonalTokenHandler(a.logger, a.storage))
+			r.Delete("/tokens/{id}", auth.DeletePersonalTokenHandler(a.logger, a.storage))
+		})
 	})
 
 	r.Group(func(r chi.Router) {
-		r.Use(middleware.JWTAuthMiddleware(a.jwtSecret))
+		r.Use(middleware.TokenAuthMiddleware(a.keys, a.storage, a.metrics))
+		r.Use(middleware.RateLimitByUser(a.logger, a.limiter, apiLimit, a.config.RateLimit.FailOpen, a.metrics))
 
 		r.Route("/notes", func(r chi.Router) {
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RequireScope(models.ScopeNotesRead))
 
-			r.Get("/", notes.NotesHandler(a.logger, a.storage))
-			r.Get("/{id}", notes.NoteHandler(a.logger, a.storage))
-			r.Post("/", notes.CreateNoteHandler(a.logger, a.storage))
-			r.Delete("/{id}", notes.DeleteNoteHandler(a.logger, a.storage))
-			r.Put("/{id}", notes.UpdateNoteHandler(a.logger, a.storage))
+				r.Get("/", notes.NotesHandler(a.logger, a.storage))
+				r.Get("/search", notes.SearchNotesHandler(a.logger, a.storage))
+				r.Get("/trash", notes.TrashHandler(a.logger, a.storage))
+				r.Get("/{id}", notes.NoteHandler(a.logger, a.storage))
+				r.Get("/{id}/diff", notes.DiffHandler(a.logger, a.storage))
+				r.Get("/{id}/revisions", notes.RevisionsHandler(a.logger, a.storage))
+				r.Get("/{id}/revisions/{rev}", notes.RevisionHandler(a.logger, a.storage))
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RequireScope(models.ScopeNotesWrite))
+
+				r.Post("/", notes.CreateNoteHandler(a.logger, a.storage))
+				r.Delete("/{id}", notes.DeleteNoteHandler(a.logger, a.storage))
+				r.Put("/{id}", notes.UpdateNoteHandler(a.logger, a.storage))
+				r.Patch("/{id}", notes.PatchNoteHandler(a.logger, a.storage))
+				r.Post("/{id}/restore", notes.RestoreNoteHandler(a.logger, a.storage))
+				r.Post("/{id}/revisions/{rev}/restore", notes.RestoreRevisionHandler(a.logger, a.storage))
+			})
+		})
+
+		r.Route("/tags", func(r chi.Router) {
+			r.With(middleware.RequireScope(models.ScopeTagsRead)).Get("/", tags.TagsHandler(a.logger, a.storage))
+
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RequireScope(models.ScopeTagsWrite))
+
+				r.Post("/merge", tags.MergeTagsHandler(a.logger, a.storage))
+				r.Post("/{name}/rename", tags.RenameTagHandler(a.logger, a.storage))
+			})
 		})
 	})
 
 	return r
 }
 
-func (a *App) Start() error {
-	srv := http.Server{
+// Start serves the API, and the metrics on the admin listener if one is
+// configured, and runs the background jobs until ctx is done. It then stops
+// accepting connections, gives requests in flight the configured drain
+// timeout to finish and waits for the background jobs to return.
+func (a *App) Start(ctx context.Context) error {
+	servers := []*http.Server{{
 		Addr:         a.config.HTTPServer.Address,
 		Handler:      a.AddRoutes(),
 		ReadTimeout:  a.config.HTTPServer.Timeout,
 		WriteTimeout: a.config.HTTPServer.Timeout,
 		IdleTimeout:  a.config.HTTPServer.IdleTimeout,
+	}}
+
+	if addr := a.config.HTTPServer.AdminAddress; addr != "" {
+		admin := chi.NewRouter()
+		admin.Handle("/metrics", a.metrics.Handler())
+
+		servers = append(servers, &http.Server{
+			Addr:         addr,
+			Handler:      admin,
+			ReadTimeout:  a.config.HTTPServer.Timeout,
+			WriteTimeout: a.config.HTTPServer.Timeout,
+			IdleTimeout:  a.config.HTTPServer.IdleTimeout,
+		})
 	}
 
-	a.logger.Info("starting server", slog.String("address", a.config.HTTPServer.Address))
+	jobsCtx, cancelJobs := context.WithCancel(ctx)
+
+	var jobs sync.WaitGroup
+	for _, job := range []func(context.Context){a.purgeTrash, a.pruneRevisions, a.purgeExpiredTokens, a.purgeRateLimits} {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			job(jobsCtx)
+		}()
+	}
+	defer func() {
+		cancelJobs()
+		jobs.Wait()
+	}()
+
+	serveErr := make(chan error, len(servers))
+	for _, srv := range servers {
+		a.logger.Info("starting server", slog.String("address", srv.Addr))
+
+		go func() {
+			serveErr <- srv.ListenAndServe()
+		}()
+	}
 
-	if err := srv.ListenAndServe(); err != nil {
+	var err error
+	select {
+	case err = <-serveErr:
 		a.logger.Error("failed to start server", logger.Err(err))
+	case <-ctx.Done():
+	}
+
+	a.shuttingDown.Store(true)
+
+	a.logger.Info("shutting down server", slog.Duration("timeout", a.config.HTTPServer.ShutdownTimeout))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.config.HTTPServer.ShutdownTimeout)
+	defer cancel()
+
+	for _, srv := range servers {
+		if shutdownErr := srv.Shutdown(shutdownCtx); shutdownErr != nil {
+			a.logger.Error("failed to drain connections", slog.String("address", srv.Addr), logger.Err(shutdownErr))
+			srv.Close()
+			err = errors.Join(err, shutdownErr)
+		}
+	}
+
+	if err != nil {
 		return err
 	}
 
+	a.logger.Info("server stopped")
+
 	return nil
 }
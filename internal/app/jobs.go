@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"notes-api/pkg/logger"
+	"time"
+)
+
+// purgeTrash permanently removes notes that have been in the trash for longer
+// than the configured retention, once per purge interval, until ctx is done.
+func (a *App) purgeTrash(ctx context.Context) {
+	log := a.logger.With(slog.String("component", "app/trash"))
+
+	retention, interval := a.config.Trash.Retention, a.config.Trash.PurgeInterval
+	if retention <= 0 || interval <= 0 {
+		log.Info("trash purge disabled")
+		return
+	}
+
+	runPeriodically(ctx, interval, func() {
+		purged, err := a.storage.PurgeTrash(ctx, time.Now().Add(-retention))
+		if err != nil && ctx.Err() == nil {
+			log.Error("failed to purge trash", logger.Err(err))
+		} else if purged > 0 {
+			log.Info("purged trashed notes", slog.Int64("count", purged))
+		}
+	})
+}
+
+// pruneRevisions enforces the configured note history retention once per
+// prune interval, until ctx is done.
+func (a *App) pruneRevisions(ctx context.Context) {
+	log := a.logger.With(slog.String("component", "app/revisions"))
+
+	cfg := a.config.Revisions
+	if (cfg.MaxPerNote <= 0 && cfg.MaxAge <= 0) || cfg.PruneInterval <= 0 {
+		log.Info("revision pruning disabled")
+		return
+	}
+
+	runPeriodically(ctx, cfg.PruneInterval, func() {
+		var cutoff time.Time
+		if cfg.MaxAge > 0 {
+			cutoff = time.Now().Add(-cfg.MaxAge)
+		}
+
+		pruned, err := a.storage.PruneRevisions(ctx, cfg.MaxPerNote, cutoff)
+		if err != nil && ctx.Err() == nil {
+			log.Error("failed to prune revisions", logger.Err(err))
+		} else if pruned > 0 {
+			log.Info("pruned note revisions", slog.Int64("count", pruned))
+		}
+	})
+}
+
+// purgeExpiredTokens forgets refresh tokens and revoked access tokens once
+// they have expired, once per cleanup interval, until ctx is done.
+func (a *App) purgeExpiredTokens(ctx context.Context) {
+	log := a.logger.With(slog.String("component", "app/tokens"))
+
+	interval := a.config.Auth.TokenCleanupInterval
+	if interval <= 0 {
+		log.Info("token cleanup disabled")
+		return
+	}
+
+	runPeriodically(ctx, interval, func() {
+		purged, err := a.storage.PurgeExpiredTokens(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			log.Error("failed to purge expired tokens", logger.Err(err))
+		} else if purged > 0 {
+			log.Info("purged expired tokens", slog.Int64("count", purged))
+		}
+	})
+}
+
+// purgeRateLimits forgets the rate limit buckets that have filled up again,
+// once per cleanup interval, until ctx is done.
+func (a *App) purgeRateLimits(ctx context.Context) {
+	log := a.logger.With(slog.String("component", "app/ratelimit"))
+
+	interval := a.config.RateLimit.CleanupInterval
+	if interval <= 0 {
+		log.Info("rate limit cleanup disabled")
+		return
+	}
+
+	runPeriodically(ctx, interval, func() {
+		purged, err := a.limiter.Purge(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			log.Error("failed to purge rate limits", logger.Err(err))
+		} else if purged > 0 {
+			log.Debug("purged rate limits", slog.Int64("count", purged))
+		}
+	})
+}
+
+// runPeriodically calls fn right away and then once every interval until ctx
+// is done.
+func runPeriodically(ctx context.Context, interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fn()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package app
+
+import (
+	"database/sql"
+	"log/slog"
+	"notes-api/internal/storage"
+	"notes-api/pkg/logger"
+)
+
+// instrumentStorage reports storage operation durations and, for database
+// backends, connection pool statistics to the metrics of a. Backends that
+// support neither are left alone.
+func (a *App) instrumentStorage() {
+	if s, ok := a.storage.(interface{ SetObserver(storage.Observer) }); ok {
+		s.SetObserver(a.metrics)
+	}
+
+	if s, ok := a.storage.(interface{ DB() *sql.DB }); ok {
+		if err := a.metrics.RegisterDB(a.config.Storage.Driver, s.DB()); err != nil {
+			a.logger.Warn("failed to export connection pool stats", slog.String("driver", a.config.Storage.Driver), logger.Err(err))
+		}
+	}
+}
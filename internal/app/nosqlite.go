@@ -0,0 +1,19 @@
+//go:build !sqlite_fts5
+
+package app
+
+import (
+	"notes-api/internal/ratelimit"
+	"notes-api/internal/storage"
+	"time"
+)
+
+func newSQLite(string, time.Duration) (Storage, error) {
+	return nil, storage.ErrNoSQLite
+}
+
+// sqliteRateLimits is never reached: without the sqlite driver there is no
+// SQLite database to keep the buckets in.
+func sqliteRateLimits(Storage) ratelimit.Store {
+	panic(storage.ErrNoSQLite)
+}
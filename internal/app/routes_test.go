@@ -0,0 +1,56 @@
+package app_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notes-api/internal/app"
+	"notes-api/internal/config"
+	"notes-api/internal/jwtkeys"
+	"notes-api/internal/openapi"
+	"notes-api/internal/storage/memory"
+)
+
+// undocumented are the routes that are not part of the API itself.
+var undocumented = []string{"/metrics", "/openapi.json", "/docs"}
+
+// TestRoutesMatchSpec fails when a route is added to or removed from
+// AddRoutes without the OpenAPI document following.
+func TestRoutesMatchSpec(t *testing.T) {
+	keys, err := jwtkeys.New(config.JWT{}, []byte("secret"))
+	require.NoError(t, err)
+
+	a := app.NewApp(&config.Config{}, memory.New(), slog.New(slog.NewTextHandler(io.Discard, nil)), keys)
+
+	var routes []openapi.Route
+	err = chi.Walk(a.AddRoutes().(chi.Routes), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		// Routes mounted at "/" of a subrouter are served with and without
+		// the trailing slash; the document lists them without.
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+
+		if !slices.Contains(undocumented, route) {
+			routes = append(routes, openapi.Route{Method: method, Path: route})
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	documented := openapi.MustLoad().Routes()
+
+	for _, route := range routes {
+		assert.Contains(t, documented, route, "route is not in the OpenAPI document")
+	}
+	for _, route := range documented {
+		assert.Contains(t, routes, route, "the OpenAPI document lists a route that AddRoutes does not serve")
+	}
+}
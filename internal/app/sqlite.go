@@ -0,0 +1,26 @@
+//go:build sqlite_fts5
+
+package app
+
+import (
+	"fmt"
+	"notes-api/internal/config"
+	"notes-api/internal/ratelimit"
+	"notes-api/internal/storage/sqlite"
+	"time"
+)
+
+var _ Storage = (*sqlite.Storage)(nil)
+
+func newSQLite(path string, queryTimeout time.Duration) (Storage, error) {
+	return sqlite.New(path, queryTimeout)
+}
+
+func sqliteRateLimits(s Storage) ratelimit.Store {
+	db, ok := s.(*sqlite.Storage)
+	if !ok {
+		panic(fmt.Sprintf("rate limit store %q needs the sqlite storage driver, not %T", config.RateLimitSQLite, s))
+	}
+
+	return db.RateLimits()
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"notes-api/internal/config"
+	"notes-api/internal/handlers/auth"
+	"notes-api/internal/handlers/health"
+	"notes-api/internal/handlers/notes"
+	"notes-api/internal/handlers/tags"
+	"notes-api/internal/middleware"
+	"notes-api/internal/ratelimit"
+	"notes-api/internal/storage/memory"
+	"notes-api/internal/storage/postgres"
+	"time"
+)
+
+// Storage is everything the handlers, middleware and background jobs need
+// from a storage backend.
+type Storage interface {
+	auth.UserCreator
+	auth.UserProvider
+	auth.TokenRotator
+	auth.TokenRevoker
+	auth.PersonalTokenCreator
+	auth.PersonalTokensProvider
+	auth.PersonalTokenDeleter
+	auth.PasswordChanger
+	auth.PasswordResetRequester
+	auth.PasswordResetter
+	auth.MFAVerifier
+	auth.TOTPEnroller
+
+	middleware.TokenAuthenticator
+
+	notes.NotesProvider
+	notes.NoteSearcher
+	notes.NoteProvider
+	notes.NoteCreator
+	notes.NoteUpdater
+	notes.NotePatcher
+	notes.NoteDeleter
+	notes.NoteRestorer
+	notes.RevisionsProvider
+	notes.RevisionProvider
+	notes.RevisionRestorer
+	notes.RevisionDiffer
+
+	tags.TagsProvider
+	tags.TagRenamer
+	tags.TagMerger
+
+	health.ReadinessChecker
+
+	PurgeTrash(ctx context.Context, cutoff time.Time) (int64, error)
+	PruneRevisions(ctx context.Context, maxPerNote int, cutoff time.Time) (int64, error)
+	PurgeExpiredTokens(ctx context.Context, now time.Time) (int64, error)
+
+	// Close flushes pending writes and releases the backend. The storage
+	// must not be used afterwards.
+	Close() error
+}
+
+var (
+	_ Storage = (*postgres.Storage)(nil)
+	_ Storage = (*memory.Storage)(nil)
+)
+
+// NewStorage opens the backend selected in cfg and migrates its schema.
+func NewStorage(cfg *config.Config) (Storage, error) {
+	switch cfg.Storage.Driver {
+	case config.DriverSQLite:
+		return newSQLite(cfg.StoragePath, cfg.Storage.QueryTimeout)
+	case config.DriverPostgres:
+		return postgres.New(cfg.Storage.DSN, cfg.Storage.QueryTimeout)
+	case config.DriverMemory:
+		return memory.New(), nil
+	}
+
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+}
+
+// newRateLimitStore returns where the rate limiter keeps its buckets. The
+// sqlite store shares the database of the storage, which config.MustLoad
+// checks is a SQLite one.
+func newRateLimitStore(store string, s Storage) ratelimit.Store {
+	if store != config.RateLimitSQLite {
+		return ratelimit.NewMemory()
+	}
+
+	return sqliteRateLimits(s)
+}
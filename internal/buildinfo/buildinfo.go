@@ -0,0 +1,61 @@
+// Package buildinfo describes the running binary. Release builds stamp the
+// variables below with -ldflags, for example
+//
+//	go build -ldflags "-X notes-api/internal/buildinfo.Version=v1.2.0" ./cmd
+//
+// Anything left unset is filled in from the module and VCS information the
+// Go toolchain embeds.
+package buildinfo
+
+import "runtime/debug"
+
+var (
+	Version   string
+	Commit    string
+	BuildTime string
+)
+
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	// Modified is set when the binary was built from a checkout with
+	// uncommitted changes.
+	Modified bool `json:"modified,omitempty"`
+}
+
+// Get returns the build metadata of the running binary.
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.GoVersion = bi.GoVersion
+
+		if info.Version == "" && bi.Main.Version != "" {
+			info.Version = bi.Main.Version
+		}
+
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				// The commit time is the closest the toolchain records.
+				if info.BuildTime == "" {
+					info.BuildTime = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "(devel)"
+	}
+
+	return info
+}
@@ -2,22 +2,203 @@ package config
 
 import (
 	"log"
+	"net/mail"
+	"net/url"
 	"os"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory"
+)
+
+const (
+	EnvDev  = "dev"
+	EnvProd = "prod"
+)
+
+const (
+	RateLimitMemory = "memory"
+	RateLimitSQLite = "sqlite"
+)
+
+const (
+	MailNone = "none"
+	MailLog  = "log"
+	MailDir  = "dir"
+	MailSMTP = "smtp"
+)
+
+const (
+	JWTRS256 = "RS256"
+	JWTES256 = "ES256"
+	JWTEdDSA = "EdDSA"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Config is read from the YAML file at CONFIG_PATH. Env is "dev" or "prod";
+// in dev, responses are checked against the OpenAPI document.
 type Config struct {
-	StoragePath string `yaml:"storage_path" env-required:"true"`
-	HTTPServer  `yaml:"http_server"`
-	JwtSecret   string `yaml:"jwt_secret" env-required:"true"`
+	Env          string `yaml:"env" env-default:"prod"`
+	StoragePath  string `yaml:"storage_path"`
+	Storage      `yaml:"storage"`
+	HTTPServer   `yaml:"http_server"`
+	JwtSecret    string `yaml:"jwt_secret"`
+	JWT          `yaml:"jwt"`
+	Auth         `yaml:"auth"`
+	RateLimit    `yaml:"rate_limit"`
+	PasswordHash `yaml:"password_hash"`
+	Mail         `yaml:"mail"`
+	Trash        `yaml:"trash"`
+	Revisions    `yaml:"revisions"`
+	Tracing      `yaml:"tracing"`
 }
 
+// Storage selects the storage backend. SQLite keeps its data in the file at
+// StoragePath; PostgreSQL connects with DSN and lets several replicas of the
+// API share one database. The memory driver keeps everything in process
+// memory until the API stops, for tests and demos. QueryTimeout bounds a
+// single storage call; zero disables the limit.
+type Storage struct {
+	Driver       string        `yaml:"driver" env-default:"sqlite"`
+	DSN          string        `yaml:"dsn"`
+	QueryTimeout time.Duration `yaml:"query_timeout" env-default:"3s"`
+}
+
+// HTTPServer configures the listener. On shutdown, requests in flight get
+// ShutdownTimeout to finish before their connections are closed. Metrics are
+// served on /metrics of the API itself unless AdminAddress names a separate
+// listener for them.
 type HTTPServer struct {
-	Address     string        `yaml:"address" env-default:"localhost:8080"`
-	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
-	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	Address         string        `yaml:"address" env-default:"localhost:8080"`
+	AdminAddress    string        `yaml:"admin_address"`
+	Timeout         time.Duration `yaml:"timeout" env-default:"4s"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"10s"`
+}
+
+// JWT selects how access tokens are signed. Without Keys, they are signed
+// with HS256 and JwtSecret, and only this API can verify them. Keys lists
+// asymmetric keys by ID, each with the algorithm it is used with and a PEM
+// file: a private key to sign with, or a public key to only verify tokens a
+// retired key signed. SigningKey names the key that signs new tokens; the
+// others are still accepted, so a key can be rotated by listing the new key
+// first, switching SigningKey to it once every verifier has picked it up
+// from the JWKS, and dropping the old one once its tokens have expired.
+//
+// Tokens carry Issuer and Audience, and tokens without them are refused.
+// Leeway allows for clocks that are off when checking expiry and nbf.
+type JWT struct {
+	Issuer     string        `yaml:"issuer" env-default:"notes-api"`
+	Audience   string        `yaml:"audience" env-default:"notes-api"`
+	Leeway     time.Duration `yaml:"leeway" env-default:"30s"`
+	SigningKey string        `yaml:"signing_key"`
+	Keys       []JWTKey      `yaml:"keys"`
+}
+
+// JWTKey is one key of JWT.Keys. Algorithm is "RS256", "ES256" or "EdDSA";
+// exactly one of PrivateKeyFile and PublicKeyFile is set.
+type JWTKey struct {
+	ID             string `yaml:"id"`
+	Algorithm      string `yaml:"algorithm"`
+	PrivateKeyFile string `yaml:"private_key_file"`
+	PublicKeyFile  string `yaml:"public_key_file"`
+}
+
+// Auth sets how long issued tokens stay valid. Access tokens are short-lived
+// and renewed with a refresh token, which rotates on every use.
+//
+// After MaxFailedLogins failed sign-ins in a row, an account is locked for
+// LockoutDuration, which doubles with every further failure up to
+// MaxLockoutDuration. Zero MaxFailedLogins disables the lockout. Password
+// reset tokens are valid for PasswordResetTTL.
+//
+// Users with two-factor sign-in have MFAChallengeTTL to give a code after
+// their password. Authenticator apps list their accounts under TOTPIssuer.
+type Auth struct {
+	AccessTokenTTL       time.Duration `yaml:"access_token_ttl" env-default:"15m"`
+	RefreshTokenTTL      time.Duration `yaml:"refresh_token_ttl" env-default:"720h"`
+	TokenCleanupInterval time.Duration `yaml:"token_cleanup_interval" env-default:"1h"`
+	MaxFailedLogins      int           `yaml:"max_failed_logins" env-default:"5"`
+	LockoutDuration      time.Duration `yaml:"lockout_duration" env-default:"1m"`
+	MaxLockoutDuration   time.Duration `yaml:"max_lockout_duration" env-default:"1h"`
+	PasswordResetTTL     time.Duration `yaml:"password_reset_ttl" env-default:"1h"`
+	MFAChallengeTTL      time.Duration `yaml:"mfa_challenge_ttl" env-default:"5m"`
+	TOTPIssuer           string        `yaml:"totp_issuer" env-default:"Notes API"`
+}
+
+// RateLimit allows every client IP AuthRequests requests to /auth per
+// AuthPeriod, and every user APIRequests requests to the notes and tags
+// per APIPeriod. Zero requests disable a limit. Store is "memory" to keep
+// the counts in each process, or "sqlite" to share them through the
+// database of the sqlite driver between processes. Should the store fail,
+// requests pass if FailOpen, and are rejected with 503 otherwise.
+type RateLimit struct {
+	Store           string        `yaml:"store" env-default:"memory"`
+	FailOpen        bool          `yaml:"fail_open" env-default:"true"`
+	AuthRequests    int           `yaml:"auth_requests" env-default:"20"`
+	AuthPeriod      time.Duration `yaml:"auth_period" env-default:"1m"`
+	APIRequests     int           `yaml:"api_requests" env-default:"600"`
+	APIPeriod       time.Duration `yaml:"api_period" env-default:"1m"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval" env-default:"10m"`
+}
+
+// PasswordHash sets the argon2id parameters new password hashes are made
+// with; Memory is in KiB. Hashes made with other parameters, or with bcrypt,
+// are replaced when their users next sign in.
+type PasswordHash struct {
+	Memory      uint32 `yaml:"memory" env-default:"65536"`
+	Iterations  uint32 `yaml:"iterations" env-default:"3"`
+	Parallelism uint8  `yaml:"parallelism" env-default:"4"`
+}
+
+// Mail selects how mail reaches users. Transport "none" disables mail, and
+// with it password resets; "log" writes messages to the log and "dir" each
+// to a file in Dir, for local development; "smtp" sends them through the
+// server at SMTPHost:SMTPPort. Reset messages link to ResetURL with the
+// token in its token query parameter, or carry the bare token if it is
+// empty.
+type Mail struct {
+	Transport    string `yaml:"transport" env-default:"none"`
+	From         string `yaml:"from" env-default:"notes-api@localhost"`
+	Dir          string `yaml:"dir"`
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port" env-default:"587"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+	ResetURL     string `yaml:"reset_url"`
+}
+
+type Trash struct {
+	Retention     time.Duration `yaml:"retention" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// Revisions limits how much note history is kept. Zero disables a limit.
+type Revisions struct {
+	MaxPerNote    int           `yaml:"max_per_note" env-default:"50"`
+	MaxAge        time.Duration `yaml:"max_age" env-default:"0"`
+	PruneInterval time.Duration `yaml:"prune_interval" env-default:"1h"`
+}
+
+// Tracing selects where spans go: "none" drops them, "stdout" prints them as
+// JSON lines and "otlp" sends them to the OTLP/HTTP collector at Endpoint.
+// SampleRatio applies to traces that do not arrive with a sampling decision
+// of their own.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" env-default:"none"`
+	Endpoint    string  `yaml:"endpoint" env-default:"http://localhost:4318"`
+	ServiceName string  `yaml:"service_name" env-default:"notes-api"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
 }
 
 func MustLoad() *Config {
@@ -36,5 +217,73 @@ func MustLoad() *Config {
 		log.Fatalf("cannot read config: %s", err)
 	}
 
+	switch cfg.Env {
+	case EnvDev, EnvProd:
+	default:
+		log.Fatalf("unknown env: %s", cfg.Env)
+	}
+
+	switch cfg.Storage.Driver {
+	case DriverSQLite:
+		if cfg.StoragePath == "" {
+			log.Fatal("storage_path is required for the sqlite driver")
+		}
+	case DriverPostgres:
+		if cfg.Storage.DSN == "" {
+			log.Fatal("storage.dsn is required for the postgres driver")
+		}
+	case DriverMemory:
+	default:
+		log.Fatalf("unknown storage driver: %s", cfg.Storage.Driver)
+	}
+
+	if len(cfg.JWT.Keys) == 0 {
+		if cfg.JwtSecret == "" {
+			log.Fatal("jwt_secret is required unless jwt.keys are configured")
+		}
+	} else if cfg.JWT.SigningKey == "" {
+		log.Fatal("jwt.signing_key is required with jwt.keys")
+	}
+
+	switch cfg.RateLimit.Store {
+	case RateLimitMemory:
+	case RateLimitSQLite:
+		if cfg.Storage.Driver != DriverSQLite {
+			log.Fatal("the sqlite rate limit store needs the sqlite storage driver")
+		}
+	default:
+		log.Fatalf("unknown rate limit store: %s", cfg.RateLimit.Store)
+	}
+
+	switch cfg.Mail.Transport {
+	case MailNone, MailLog:
+	case MailDir:
+		if cfg.Mail.Dir == "" {
+			log.Fatal("mail.dir is required for the dir mail transport")
+		}
+	case MailSMTP:
+		if cfg.Mail.SMTPHost == "" {
+			log.Fatal("mail.smtp_host is required for the smtp mail transport")
+		}
+	default:
+		log.Fatalf("unknown mail transport: %s", cfg.Mail.Transport)
+	}
+
+	if _, err := mail.ParseAddress(cfg.Mail.From); cfg.Mail.Transport != MailNone && err != nil {
+		log.Fatalf("invalid mail.from address: %s", err)
+	}
+
+	if cfg.Mail.ResetURL != "" {
+		if u, err := url.Parse(cfg.Mail.ResetURL); err != nil || !u.IsAbs() {
+			log.Fatalf("mail.reset_url must be an absolute URL: %s", cfg.Mail.ResetURL)
+		}
+	}
+
+	switch cfg.Tracing.Exporter {
+	case ExporterNone, ExporterStdout, ExporterOTLP:
+	default:
+		log.Fatalf("unknown tracing exporter: %s", cfg.Tracing.Exporter)
+	}
+
 	return &cfg
 }
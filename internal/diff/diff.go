@@ -0,0 +1,337 @@
+// Package diff produces line-based unified diffs.
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultContext is the number of unchanged lines shown around each
+	// change, the same default as diff -u.
+	DefaultContext = 3
+
+	// MaxLines bounds how many lines of the two texts, not counting the
+	// ones they start and end with in common, are compared. Comparing takes
+	// time proportional to their number times the number of edits.
+	MaxLines = 10000
+)
+
+// ErrTooLarge is returned for texts that differ in more than MaxLines lines.
+var ErrTooLarge = errors.New("diff: texts differ in too many lines")
+
+type kind int
+
+const (
+	equal kind = iota
+	insert
+	remove
+)
+
+type edit struct {
+	kind kind
+	line string
+}
+
+// Unified returns the unified diff turning a into b, labelled with fromName
+// and toName. It returns an empty string when a and b are equal.
+func Unified(fromName, toName, a, b string, context int) (string, error) {
+	aLines, bLines := splitLines(a), splitLines(b)
+	if changedLines(aLines, bLines) > MaxLines {
+		return "", ErrTooLarge
+	}
+
+	edits := myers(aLines, bLines)
+
+	var sb strings.Builder
+	for _, h := range hunks(edits, context) {
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+		}
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", h.fromRange(), h.toRange())
+		for _, e := range edits[h.start:h.end] {
+			switch e.kind {
+			case equal:
+				sb.WriteString(" ")
+			case insert:
+				sb.WriteString("+")
+			case remove:
+				sb.WriteString("-")
+			}
+			sb.WriteString(e.line)
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// changedLines returns the number of lines of a and b left after dropping
+// the ones they start and end with in common.
+func changedLines(a, b []string) int {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-suffix-1] == b[len(b)-suffix-1] {
+		suffix++
+	}
+
+	return len(a) + len(b) - 2*(prefix+suffix)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// myers computes a shortest edit script with the linear space refinement of
+// Myers' O(ND) algorithm: it finds the middle snake of an optimal path,
+// splits there and recurses on both halves, so memory stays proportional
+// to N+M however far apart a and b are.
+func myers(a, b []string) []edit {
+	// Comparing small integers is cheaper than comparing lines.
+	ids := make(map[string]int)
+	intern := func(lines []string) []int {
+		out := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			out[i] = id
+		}
+
+		return out
+	}
+
+	size := len(a) + len(b) + 4
+	d := &differ{
+		a:  a,
+		b:  b,
+		ai: intern(a),
+		bi: intern(b),
+		vf: make([]int, size),
+		vb: make([]int, size),
+	}
+	d.compare(0, len(a), 0, len(b))
+
+	return d.edits
+}
+
+type differ struct {
+	a, b   []string
+	ai, bi []int
+	// vf and vb hold the furthest reaching paths of the forward and the
+	// backward search. They are sized for the whole input and reused by
+	// every subproblem.
+	vf, vb []int
+	edits  []edit
+}
+
+// compare appends the edits turning a[aLo:aHi] into b[bLo:bHi].
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.ai[aLo] == d.bi[bLo] {
+		d.edits = append(d.edits, edit{kind: equal, line: d.a[aLo]})
+		aLo++
+		bLo++
+	}
+
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.ai[aHi-suffix-1] == d.bi[bHi-suffix-1] {
+		suffix++
+	}
+	aHi -= suffix
+	bHi -= suffix
+
+	switch {
+	case aLo == aHi:
+		for _, line := range d.b[bLo:bHi] {
+			d.edits = append(d.edits, edit{kind: insert, line: line})
+		}
+	case bLo == bHi:
+		for _, line := range d.a[aLo:aHi] {
+			d.edits = append(d.edits, edit{kind: remove, line: line})
+		}
+	default:
+		x, y := d.split(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x, bLo, y)
+		d.compare(x, aHi, y, bHi)
+	}
+
+	for i := range suffix {
+		d.edits = append(d.edits, edit{kind: equal, line: d.a[aHi+i]})
+	}
+}
+
+// split returns a point of an optimal path through a[aLo:aHi] and
+// b[bLo:bHi] other than its ends, found where the forward search from the
+// start meets the backward search from the end. Both ranges must be
+// non-empty and differ in their first and last lines.
+func (d *differ) split(aLo, aHi, bLo, bHi int) (int, int) {
+	n, m := aHi-aLo, bHi-bLo
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	vf, vb := d.vf[:2*offset+1], d.vb[:2*offset+1]
+	for i := range vf {
+		vf[i], vb[i] = -1, -1
+	}
+	vf[offset+1], vb[offset+1] = 0, 0
+
+	// The searches meet on a forward diagonal when delta is odd, and on a
+	// backward one when it is even. Diagonals whose paths have run off the
+	// edit graph are skipped from then on.
+	delta := n - m
+	odd := delta%2 != 0
+	var fStart, fEnd, bStart, bEnd int
+
+	for step := 0; step <= maxD; step++ {
+		for k := -step + fStart; k <= step-fEnd; k += 2 {
+			var x int
+			if k == -step || (k != step && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && d.ai[aLo+x] == d.bi[bLo+y] {
+				x++
+				y++
+			}
+			vf[offset+k] = x
+
+			switch kb := offset + delta - k; {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd && kb >= 0 && kb < len(vb) && vb[kb] != -1 && x >= n-vb[kb]:
+				return aLo + x, bLo + y
+			}
+		}
+
+		for k := -step + bStart; k <= step-bEnd; k += 2 {
+			var x int
+			if k == -step || (k != step && vb[offset+k-1] < vb[offset+k+1]) {
+				x = vb[offset+k+1]
+			} else {
+				x = vb[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && d.ai[aHi-x-1] == d.bi[bHi-y-1] {
+				x++
+				y++
+			}
+			vb[offset+k] = x
+
+			switch kf := offset + delta - k; {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd && kf >= 0 && kf < len(vf) && vf[kf] != -1 && vf[kf] >= n-x:
+				xf := vf[kf]
+
+				return aLo + xf, bLo + xf - (kf - offset)
+			}
+		}
+	}
+
+	// Together the searches cover every edit within maxD steps each.
+	panic("diff: no middle snake")
+}
+
+type hunk struct {
+	start, end int
+	fromStart  int
+	fromCount  int
+	toStart    int
+	toCount    int
+}
+
+func (h hunk) fromRange() string {
+	return formatRange(h.fromStart, h.fromCount)
+}
+
+func (h hunk) toRange() string {
+	return formatRange(h.toStart, h.toCount)
+}
+
+func formatRange(start, count int) string {
+	// An empty range points at the line before it, as in GNU diff.
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// hunks groups changes that are at most 2*context unchanged lines apart.
+func hunks(edits []edit, context int) []hunk {
+	var result []hunk
+
+	i := 0
+	for i < len(edits) {
+		for i < len(edits) && edits[i].kind == equal {
+			i++
+		}
+		if i == len(edits) {
+			break
+		}
+
+		j := i
+		for j < len(edits) {
+			if edits[j].kind != equal {
+				j++
+				continue
+			}
+
+			run := j
+			for run < len(edits) && edits[run].kind == equal {
+				run++
+			}
+
+			if run == len(edits) || run-j > 2*context {
+				break
+			}
+			j = run
+		}
+
+		h := hunk{start: max(i-context, 0), end: min(j+context, len(edits))}
+		for _, e := range edits[:h.start] {
+			if e.kind != insert {
+				h.fromStart++
+			}
+			if e.kind != remove {
+				h.toStart++
+			}
+		}
+		for _, e := range edits[h.start:h.end] {
+			if e.kind != insert {
+				h.fromCount++
+			}
+			if e.kind != remove {
+				h.toCount++
+			}
+		}
+
+		result = append(result, h)
+		i = h.end
+	}
+
+	return result
+}
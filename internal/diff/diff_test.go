@@ -0,0 +1,159 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnified(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{name: "both empty", a: "", b: "", want: ""},
+		{name: "identical", a: "one\ntwo\nthree\n", b: "one\ntwo\nthree\n", want: ""},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "one\ntwo\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+one\n+two\n",
+		},
+		{
+			name: "to empty",
+			a:    "one\ntwo\n",
+			b:    "",
+			want: "--- a\n+++ b\n@@ -1,2 +0,0 @@\n-one\n-two\n",
+		},
+		{
+			name: "insert only",
+			a:    "one\ntwo\nthree\n",
+			b:    "one\ntwo\nnew\nthree\n",
+			want: "--- a\n+++ b\n@@ -2,2 +2,3 @@\n two\n+new\n three\n",
+		},
+		{
+			name: "delete only",
+			a:    "one\ntwo\nthree\n",
+			b:    "one\nthree\n",
+			want: "--- a\n+++ b\n@@ -1,3 +1,2 @@\n one\n-two\n three\n",
+		},
+		{
+			name: "replace",
+			a:    "one\ntwo\nthree\n",
+			b:    "one\n2\nthree\n",
+			want: "--- a\n+++ b\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			b:    "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			want: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n-1\n+one\n 2\n@@ -9,2 +9,2 @@\n 9\n-10\n+ten\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Unified("a", "b", tt.a, tt.b, 1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// The edit scripts of random texts turn one into the other and are as short
+// as the longest common subsequence allows.
+func TestMyersShortest(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	random := func() []string {
+		lines := make([]string, rng.IntN(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.IntN(4)))
+		}
+		return lines
+	}
+
+	for range 2000 {
+		a, b := random(), random()
+		edits := myers(a, b)
+
+		var from, to []string
+		changes := 0
+		for _, e := range edits {
+			if e.kind != insert {
+				from = append(from, e.line)
+			}
+			if e.kind != remove {
+				to = append(to, e.line)
+			}
+			if e.kind != equal {
+				changes++
+			}
+		}
+
+		require.Equal(t, strings.Join(a, ""), strings.Join(from, ""))
+		require.Equal(t, strings.Join(b, ""), strings.Join(to, ""))
+		require.Equal(t, len(a)+len(b)-2*lcs(a, b), changes, "%v %v", a, b)
+	}
+}
+
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
+
+func lines(n int, format string) string {
+	var sb strings.Builder
+	for i := range n {
+		fmt.Fprintf(&sb, format+"\n", i)
+	}
+
+	return sb.String()
+}
+
+// Texts that differ entirely take memory in proportion to their size, not
+// to its square.
+func TestUnifiedLargeInput(t *testing.T) {
+	a, b := lines(MaxLines/2, "old line %d"), lines(MaxLines/2, "new line %d")
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	got, err := Unified("a", "b", a, b, DefaultContext)
+	require.NoError(t, err)
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	assert.Equal(t, MaxLines+3, strings.Count(got, "\n"))
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(16<<20))
+	assert.Less(t, elapsed, 10*time.Second)
+}
+
+func TestUnifiedTooLarge(t *testing.T) {
+	_, err := Unified("a", "b", lines(MaxLines/2+1, "old line %d"), lines(MaxLines/2, "new line %d"), DefaultContext)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	// Lines the texts start and end with in common do not count.
+	common := lines(MaxLines, "line %d")
+	got, err := Unified("a", "b", common+"old\n"+common, common+"new\n"+common, DefaultContext)
+	require.NoError(t, err)
+	assert.Contains(t, got, "-old\n+new\n")
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"notes-api/internal/utils"
+)
+
+const (
+	testUserID = 7
+	testJTI    = "test-jti"
+)
+
+var (
+	discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	testTTL = TokenTTL{Access: 15 * time.Minute, Refresh: 24 * time.Hour, MFAChallenge: 5 * time.Minute}
+
+	testTokenExpiry = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// serve routes a request to handler, mounted at pattern, as the test user
+// signed in with the access token testJTI, and returns the response.
+func serve(pattern string, handler http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	router.Method(method, pattern, handler)
+
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	ctx := context.WithValue(r.Context(), utils.UserIDKey, strconv.Itoa(testUserID))
+	ctx = context.WithValue(ctx, utils.TokenIDKey, testJTI)
+	ctx = context.WithValue(ctx, utils.TokenExpiresAtKey, testTokenExpiry)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, r.WithContext(ctx))
+
+	return rec
+}
+
+// problemCode returns the code of the problem document in rec.
+func problemCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body), rec.Body.String())
+
+	return body.Code
+}
+
+// testSigner returns a signer that signs every access token as
+// "access-token".
+func testSigner(t *testing.T) *MockTokenSigner {
+	signer := NewMockTokenSigner(t)
+	signer.EXPECT().Sign(mock.Anything).Return("access-token", nil).Maybe()
+
+	return signer
+}
+
+// decodeTokens returns the token pair in rec.
+func decodeTokens(t *testing.T, rec *httptest.ResponseRecorder) tokenResponse {
+	t.Helper()
+
+	var tokens tokenResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tokens), rec.Body.String())
+
+	return tokens
+}
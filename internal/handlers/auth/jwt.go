@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"strconv"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -8,13 +10,39 @@ import (
 	"time"
 )
 
-func GenerateJWT(jwtSecret []byte, userID int64) (string, error) {
+// TokenSigner signs access tokens, adding the claims that say who issued
+// them and for whom.
+type TokenSigner interface {
+	Sign(claims jwt.MapClaims) (string, error)
+}
+
+// GenerateJWT issues an access token for userID that is valid from now until
+// ttl has passed. Each token carries a unique jti so it can be revoked before
+// then.
+func GenerateJWT(signer TokenSigner, userID int64, ttl time.Duration) (string, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"sub": strconv.FormatInt(userID, 10),
-		"exp": jwt.NewNumericDate(time.Now().Add(time.Hour * 24)),
+		"jti": jti,
+		"iat": jwt.NewNumericDate(now),
+		"nbf": jwt.NewNumericDate(now),
+		"exp": jwt.NewNumericDate(now.Add(ttl)),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return signer.Sign(claims)
+}
+
+// randomToken returns n random bytes, hex encoded.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
 
-	return token.SignedString(jwtSecret)
+	return hex.EncodeToString(b), nil
 }
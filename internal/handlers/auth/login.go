@@ -1,28 +1,119 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
 	"notes-api/internal/models"
-
-	"golang.org/x/crypto/bcrypt"
+	"notes-api/internal/problem"
+	"notes-api/internal/ratelimit"
+	store "notes-api/internal/storage"
+	"notes-api/internal/utils"
 
 	"log/slog"
 
 	"notes-api/pkg/logger"
 )
 
+// RefreshTokenCreator stores the first refresh token of a new token family.
+type RefreshTokenCreator interface {
+	CreateRefreshToken(ctx context.Context, userID int64, familyID, tokenHash string, expiresAt time.Time) error
+}
+
+// FailedLoginRecorder keeps count of failed sign-ins for the lockout.
+type FailedLoginRecorder interface {
+	RecordFailedLogin(ctx context.Context, userID int64) (int, error)
+	LockUser(ctx context.Context, userID int64, until time.Time) error
+	ResetFailedLogins(ctx context.Context, userID int64) error
+}
+
 type UserProvider interface {
-	User(username string) (*models.User, error)
+	RefreshTokenCreator
+	FailedLoginRecorder
+	User(ctx context.Context, username string) (*models.User, error)
+	UpdatePassword(ctx context.Context, userID int64, hash string) error
+	CreateMFAChallenge(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error
+}
+
+// PasswordHasher hashes new passwords and verifies existing hashes. Verify
+// reports when a hash is outdated and should be replaced.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash, password string) (rehash bool, err error)
+}
+
+// Lockout locks an account after Threshold failed sign-ins in a row, for
+// Duration at first and twice as long with every further failure, up to
+// MaxDuration. Zero Threshold disables it.
+type Lockout struct {
+	Threshold   int
+	Duration    time.Duration
+	MaxDuration time.Duration
+}
+
+// record counts a failed sign-in of userID and locks the account once there
+// have been Threshold of them in a row.
+func (l Lockout) record(ctx context.Context, log *slog.Logger, storage FailedLoginRecorder, userID int64, now time.Time) error {
+	if l.Threshold <= 0 {
+		return nil
+	}
+
+	failures, err := storage.RecordFailedLogin(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if until, locked := l.until(failures, now); locked {
+		if err := storage.LockUser(ctx, userID, until); err != nil {
+			return err
+		}
+
+		log.Warn("locked user after failed sign-ins", slog.Int64("user_id", userID), slog.Int("failures", failures), slog.Time("until", until))
+	}
+
+	return nil
 }
 
-func LoginHandler(log *slog.Logger, storage UserProvider, jwtSecret []byte) http.HandlerFunc {
-	type response struct {
-		Token string `json:"token"`
+// until returns when an account that has failed to sign in failures times in
+// a row is unlocked again, or false if it is not locked.
+func (l Lockout) until(failures int, now time.Time) (time.Time, bool) {
+	if l.Threshold <= 0 || l.Duration <= 0 || failures < l.Threshold {
+		return time.Time{}, false
 	}
 
+	d := l.Duration
+	for i := l.Threshold; i < failures && d < math.MaxInt64/2; i++ {
+		d *= 2
+	}
+	if l.MaxDuration > 0 {
+		d = min(d, l.MaxDuration)
+	}
+
+	return now.Add(d), true
+}
+
+// LoginHandler exchanges a username and password for an access token and a
+// refresh token that starts a new token family. Users with two-factor
+// sign-ins get a challenge instead, which VerifyMFAHandler exchanges for the
+// tokens given a code. Failed attempts count towards locking the account;
+// while it is locked, even the right password is refused. A password hash
+// made with an outdated scheme or parameters is replaced with a current one,
+// now that the password is at hand.
+func LoginHandler(log *slog.Logger, storage UserProvider, hasher PasswordHasher, signer TokenSigner, ttl TokenTTL, lockout Lockout) http.HandlerFunc {
+	// Passwords of unknown users are verified against this hash, so that
+	// signing in as one takes as long as with a wrong password and the
+	// response time does not give away which usernames exist.
+	dummyHash := sync.OnceValues(func() (string, error) {
+		return hasher.Hash("not the password of anyone")
+	})
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req models.User
 
@@ -32,8 +123,7 @@ func LoginHandler(log *slog.Logger, storage UserProvider, jwtSecret []byte) http
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			log.Error("failed to decode request body", logger.Err(err))
 
-			w.WriteHeader(http.StatusBadRequest)
-			encoder.Encode(map[string]string{"InvalidRequest": "Failed to decode request body"})
+			problem.Write(w, r, problem.InvalidRequest, "Failed to decode request body")
 
 			return
 		}
@@ -41,48 +131,159 @@ func LoginHandler(log *slog.Logger, storage UserProvider, jwtSecret []byte) http
 		if errs := req.Validate(); len(errs) > 0 {
 			log.Error("validation error", logger.Err(fmt.Errorf("invalid user data: %v", errs)))
 
-			w.WriteHeader(http.StatusBadRequest)
-			encoder.Encode(map[string]string{"ValidationError": fmt.Sprintf("Invalid user data: %v", errs)})
+			problem.WriteValidation(w, r, "Invalid user data", errs)
 
 			return
 		}
 
-		user, err := storage.User(req.Username)
+		user, err := storage.User(r.Context(), req.Username)
 		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			if errors.Is(err, store.ErrUserNotFound) {
+				log.Warn("sign-in as unknown user", slog.String("username", req.Username))
+
+				if hash, err := dummyHash(); err != nil {
+					log.Error("failed to hash dummy password", logger.Err(err))
+				} else {
+					hasher.Verify(hash, req.Password)
+				}
+
+				problem.Write(w, r, problem.Unauthorized, "Invalid username or password")
+				return
+			}
+
 			log.Error("failed to retrieve user", logger.Err(err))
 
-			w.WriteHeader(http.StatusInternalServerError)
-			encoder.Encode(map[string]string{"StorageError": "Failed to retrieve user"})
+			problem.Write(w, r, problem.Internal, "Failed to retrieve user")
+			return
+		}
+
+		now := time.Now()
+		if now.Before(user.LockedUntil) {
+			log.Warn("sign-in to locked account", slog.Int64("user_id", user.ID))
+
+			w.Header().Set("Retry-After", strconv.Itoa(ratelimit.Seconds(user.LockedUntil.Sub(now))))
+			problem.Write(w, r, problem.AccountLocked, "Too many failed sign-ins, retry later")
 			return
 		}
 
-		if err := verifyPassword(user.Password, req.Password); err != nil {
+		rehash, err := hasher.Verify(user.Password, req.Password)
+		if err != nil {
 			log.Error("authentication failed", logger.Err(err))
 
-			w.WriteHeader(http.StatusUnauthorized)
-			encoder.Encode(map[string]string{"AuthenticationError": "Invalid username or password"})
+			if err := lockout.record(r.Context(), log, storage, user.ID, now); err != nil {
+				if problem.WriteContextError(w, r, err) {
+					return
+				}
+
+				log.Error("failed to record failed sign-in", logger.Err(err))
+
+				problem.Write(w, r, problem.Internal, "Failed to record failed sign-in")
+				return
+			}
+
+			problem.Write(w, r, problem.Unauthorized, "Invalid username or password")
+			return
+		}
+
+		if rehash {
+			rehashPassword(r.Context(), log, storage, hasher, user.ID, req.Password)
+		}
+
+		// The failed sign-ins are only forgiven once the second factor is
+		// given too, or else guessing codes would never lock the account.
+		if user.TOTPEnabled {
+			challenge, err := startChallenge(r.Context(), storage, user.ID, ttl)
+			if err != nil {
+				if problem.WriteContextError(w, r, err) {
+					return
+				}
+
+				log.Error("failed to start sign-in challenge", logger.Err(err))
+
+				problem.Write(w, r, problem.Internal, "Failed to start sign-in challenge")
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			encoder.Encode(challenge)
 			return
 		}
 
-		token, err := GenerateJWT(jwtSecret, user.ID)
+		if user.FailedLogins > 0 {
+			if err := storage.ResetFailedLogins(r.Context(), user.ID); err != nil {
+				if problem.WriteContextError(w, r, err) {
+					return
+				}
+
+				log.Error("failed to reset failed sign-ins", logger.Err(err))
+
+				problem.Write(w, r, problem.Internal, "Failed to reset failed sign-ins")
+				return
+			}
+		}
+
+		tokens, err := startSession(r.Context(), storage, signer, user.ID, ttl)
 		if err != nil {
-			log.Error("failed to generate JWT token", logger.Err(err))
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
 
-			w.WriteHeader(http.StatusInternalServerError)
-			encoder.Encode(map[string]string{"TokenError": "Failed to generate JWT token"})
+			log.Error("failed to start session", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to start session")
 			return
 		}
 
 		w.WriteHeader(http.StatusOK)
-		encoder.Encode(response{Token: token})
+		encoder.Encode(tokens)
 	}
 
 }
 
-func verifyPassword(hashedPassword, password string) error {
-	if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)); err != nil {
-		return fmt.Errorf("error verifying password: %w", err)
+// rehashPassword stores a current hash of password for userID. The sign-in
+// goes ahead if that fails; the old hash still verifies and is replaced at
+// the next one.
+func rehashPassword(ctx context.Context, log *slog.Logger, storage UserProvider, hasher PasswordHasher, userID int64, password string) {
+	hash, err := hasher.Hash(password)
+	if err != nil {
+		log.Error("failed to rehash password", logger.Err(err))
+		return
 	}
 
-	return nil
+	if err := storage.UpdatePassword(ctx, userID, hash); err != nil {
+		log.Error("failed to store rehashed password", logger.Err(err))
+		return
+	}
+
+	log.Info("rehashed outdated password hash", slog.Int64("user_id", userID))
+}
+
+// startSession issues an access token for userID and a refresh token that
+// starts a new token family.
+func startSession(ctx context.Context, storage RefreshTokenCreator, signer TokenSigner, userID int64, ttl TokenTTL) (tokenResponse, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return tokenResponse{}, fmt.Errorf("failed to generate token family: %w", err)
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return tokenResponse{}, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	err = storage.CreateRefreshToken(ctx, userID, familyID, utils.HashToken(refreshToken), time.Now().Add(ttl.Refresh))
+	if err != nil {
+		return tokenResponse{}, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	token, err := GenerateJWT(signer, userID, ttl.Access)
+	if err != nil {
+		return tokenResponse{}, fmt.Errorf("failed to generate JWT token: %w", err)
+	}
+
+	return newTokenResponse(token, refreshToken, ttl), nil
 }
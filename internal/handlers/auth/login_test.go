@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"notes-api/internal/models"
+	"notes-api/internal/password"
+	store "notes-api/internal/storage"
+)
+
+func TestLoginHandler(t *testing.T) {
+	const body = `{"username":"alice","password":"password"}`
+	lockout := Lockout{Threshold: 3, Duration: time.Minute}
+	user := func(failedLogins int, totpEnabled bool) *models.User {
+		return &models.User{ID: testUserID, Username: "alice", Password: "hash", FailedLogins: failedLogins, TOTPEnabled: totpEnabled}
+	}
+
+	for _, tt := range []struct {
+		name    string
+		expect  func(storage *MockUserProvider, hasher *MockPasswordHasher)
+		code    int
+		problem string
+	}{
+		{
+			name: "signs in",
+			expect: func(storage *MockUserProvider, hasher *MockPasswordHasher) {
+				storage.EXPECT().User(mock.Anything, "alice").Return(user(0, false), nil).Once()
+				hasher.EXPECT().Verify("hash", "password").Return(false, nil).Once()
+				storage.EXPECT().CreateRefreshToken(mock.Anything, int64(testUserID), mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "rehashes outdated hash",
+			expect: func(storage *MockUserProvider, hasher *MockPasswordHasher) {
+				storage.EXPECT().User(mock.Anything, "alice").Return(user(1, false), nil).Once()
+				hasher.EXPECT().Verify("hash", "password").Return(true, nil).Once()
+				hasher.EXPECT().Hash("password").Return("new-hash", nil).Once()
+				storage.EXPECT().UpdatePassword(mock.Anything, int64(testUserID), "new-hash").Return(nil).Once()
+				storage.EXPECT().ResetFailedLogins(mock.Anything, int64(testUserID)).Return(nil).Once()
+				storage.EXPECT().CreateRefreshToken(mock.Anything, int64(testUserID), mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "wrong password",
+			expect: func(storage *MockUserProvider, hasher *MockPasswordHasher) {
+				storage.EXPECT().User(mock.Anything, "alice").Return(user(0, false), nil).Once()
+				hasher.EXPECT().Verify("hash", "password").Return(false, password.ErrMismatch).Once()
+				storage.EXPECT().RecordFailedLogin(mock.Anything, int64(testUserID)).Return(1, nil).Once()
+			},
+			code:    http.StatusUnauthorized,
+			problem: "unauthorized",
+		},
+		{
+			name: "wrong password locks account",
+			expect: func(storage *MockUserProvider, hasher *MockPasswordHasher) {
+				storage.EXPECT().User(mock.Anything, "alice").Return(user(2, false), nil).Once()
+				hasher.EXPECT().Verify("hash", "password").Return(false, password.ErrMismatch).Once()
+				storage.EXPECT().RecordFailedLogin(mock.Anything, int64(testUserID)).Return(3, nil).Once()
+				storage.EXPECT().LockUser(mock.Anything, int64(testUserID), mock.Anything).Return(nil).Once()
+			},
+			code:    http.StatusUnauthorized,
+			problem: "unauthorized",
+		},
+		{
+			name: "locked account",
+			expect: func(storage *MockUserProvider, hasher *MockPasswordHasher) {
+				locked := user(3, false)
+				locked.LockedUntil = time.Now().Add(time.Minute)
+				storage.EXPECT().User(mock.Anything, "alice").Return(locked, nil).Once()
+			},
+			code:    http.StatusTooManyRequests,
+			problem: "account_locked",
+		},
+		// Unknown users cost a hash verification too, so that response times
+		// do not tell them apart from known ones.
+		{
+			name: "unknown user",
+			expect: func(storage *MockUserProvider, hasher *MockPasswordHasher) {
+				storage.EXPECT().User(mock.Anything, "alice").Return(nil, store.ErrUserNotFound).Once()
+				hasher.EXPECT().Hash(mock.Anything).Return("dummy-hash", nil).Once()
+				hasher.EXPECT().Verify("dummy-hash", "password").Return(false, password.ErrMismatch).Once()
+			},
+			code:    http.StatusUnauthorized,
+			problem: "unauthorized",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, hasher := NewMockUserProvider(t), NewMockPasswordHasher(t)
+			tt.expect(storage, hasher)
+
+			rec := serve("/auth/signin", LoginHandler(discard, storage, hasher, testSigner(t), testTTL, lockout), http.MethodPost, "/auth/signin", body)
+			assert.Equal(t, tt.code, rec.Code)
+
+			if tt.problem != "" {
+				assert.Equal(t, tt.problem, problemCode(t, rec))
+				return
+			}
+
+			tokens := decodeTokens(t, rec)
+			assert.Equal(t, "access-token", tokens.Token)
+		})
+	}
+}
+
+func TestLoginHandlerChallenge(t *testing.T) {
+	storage, hasher := NewMockUserProvider(t), NewMockPasswordHasher(t)
+	storage.EXPECT().User(mock.Anything, "alice").Return(&models.User{ID: testUserID, Password: "hash", FailedLogins: 2, TOTPEnabled: true}, nil).Once()
+	hasher.EXPECT().Verify("hash", "password").Return(false, nil).Once()
+	storage.EXPECT().CreateMFAChallenge(mock.Anything, int64(testUserID), mock.Anything, mock.Anything).Return(nil).Once()
+
+	rec := serve("/auth/signin", LoginHandler(discard, storage, hasher, testSigner(t), testTTL, Lockout{}), http.MethodPost, "/auth/signin", `{"username":"alice","password":"password"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var challenge mfaChallengeResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &challenge))
+	assert.True(t, challenge.MFARequired)
+	assert.NotEmpty(t, challenge.MFAToken)
+}
+
+// The dummy hash is made once, not for every sign-in as an unknown user.
+func TestLoginHandlerHashesDummyOnce(t *testing.T) {
+	storage, hasher := NewMockUserProvider(t), NewMockPasswordHasher(t)
+	storage.EXPECT().User(mock.Anything, mock.Anything).Return(nil, store.ErrUserNotFound).Times(3)
+	hasher.EXPECT().Hash(mock.Anything).Return("dummy-hash", nil).Once()
+	hasher.EXPECT().Verify("dummy-hash", mock.Anything).Return(false, password.ErrMismatch).Times(3)
+
+	handler := LoginHandler(discard, storage, hasher, testSigner(t), testTTL, Lockout{})
+	for range 3 {
+		rec := serve("/auth/signin", handler, http.MethodPost, "/auth/signin", `{"username":"nobody","password":"password"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+}
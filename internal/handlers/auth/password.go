@@ -0,0 +1,354 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"notes-api/internal/mail"
+	"notes-api/internal/models"
+	"notes-api/internal/password"
+	"notes-api/internal/problem"
+	store "notes-api/internal/storage"
+	"notes-api/internal/utils"
+	"notes-api/pkg/logger"
+)
+
+type PasswordChanger interface {
+	RefreshTokenCreator
+	UserByID(ctx context.Context, id int64) (*models.User, error)
+	SetPassword(ctx context.Context, userID int64, hash string) error
+}
+
+// ChangePasswordHandler replaces the password of the signed-in user, who
+// must give the current one. Every refresh token of the user is revoked, so
+// other sessions end when their access tokens expire; this one continues
+// with the new token pair in the response.
+func ChangePasswordHandler(log *slog.Logger, storage PasswordChanger, hasher PasswordHasher, signer TokenSigner, ttl TokenTTL) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+
+		userID, ok := r.Context().Value(utils.UserIDKey).(string)
+		if !ok {
+			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
+
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
+			return
+		}
+
+		userIDInt, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			log.Error("error when converting user ID to int", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
+			return
+		}
+
+		var req models.PasswordChange
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request body", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidRequest, "Failed to decode request body")
+			return
+		}
+
+		if errs := req.Validate(); len(errs) > 0 {
+			log.Error("validation error", logger.Err(fmt.Errorf("invalid password change: %v", errs)))
+
+			problem.WriteValidation(w, r, "Invalid password change", errs)
+			return
+		}
+
+		user, err := storage.UserByID(r.Context(), userIDInt)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			if errors.Is(err, store.ErrUserNotFound) {
+				log.Warn("password change for deleted user", slog.Int64("user_id", userIDInt))
+
+				problem.Write(w, r, problem.Unauthorized, "User not found")
+				return
+			}
+
+			log.Error("failed to retrieve user", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to retrieve user")
+			return
+		}
+
+		if _, err := hasher.Verify(user.Password, req.CurrentPassword); err != nil {
+			if !errors.Is(err, password.ErrMismatch) {
+				log.Error("failed to verify password", logger.Err(err))
+
+				problem.Write(w, r, problem.Internal, "Failed to verify password")
+				return
+			}
+
+			log.Warn("password change with wrong current password", slog.Int64("user_id", user.ID))
+
+			problem.WriteValidation(w, r, "Invalid password change", map[string]string{
+				"current_password": "Current password is incorrect",
+			})
+			return
+		}
+
+		hash, err := hasher.Hash(req.NewPassword)
+		if err != nil {
+			log.Error("failed to hash password", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to hash password")
+			return
+		}
+
+		if err := storage.SetPassword(r.Context(), user.ID, hash); err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			log.Error("failed to set password", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to set password")
+			return
+		}
+
+		log.Info("password changed", slog.Int64("user_id", user.ID))
+
+		tokens, err := startSession(r.Context(), storage, signer, user.ID, ttl)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			log.Error("failed to start session", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to start session")
+			return
+		}
+
+		encoder.Encode(tokens)
+	}
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg mail.Message) error
+}
+
+type PasswordResetRequester interface {
+	User(ctx context.Context, username string) (*models.User, error)
+	CreatePasswordResetToken(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error
+}
+
+// PasswordResets configures the reset messages: tokens are valid for TTL,
+// and the message links to URL with the token in its token query
+// parameter, or carries the bare token if URL is empty.
+type PasswordResets struct {
+	TTL time.Duration
+	URL string
+}
+
+func (p PasswordResets) message(user *models.User, token string) (mail.Message, error) {
+	instructions := "To choose a new one, send this token to POST /auth/password/reset:\n\n" + token
+
+	if p.URL != "" {
+		u, err := url.Parse(p.URL)
+		if err != nil {
+			return mail.Message{}, fmt.Errorf("invalid reset URL: %w", err)
+		}
+
+		q := u.Query()
+		q.Set("token", token)
+		u.RawQuery = q.Encode()
+
+		instructions = "To choose a new one, open this link:\n\n" + u.String()
+	}
+
+	body := fmt.Sprintf(`Someone asked to reset the password of %s.
+
+%s
+
+It works once, within %s. If you did not ask for this, ignore this message;
+your password stays as it is.
+`, user.Username, instructions, humanDuration(p.TTL))
+
+	return mail.Message{To: user.Email, Subject: "Reset your password", Body: body}, nil
+}
+
+// humanDuration spells out d in the largest unit that divides it.
+func humanDuration(d time.Duration) string {
+	n, unit := int64(d/time.Second), "second"
+	switch {
+	case d%time.Hour == 0:
+		n, unit = int64(d/time.Hour), "hour"
+	case d%time.Minute == 0:
+		n, unit = int64(d/time.Minute), "minute"
+	}
+
+	if n != 1 {
+		unit += "s"
+	}
+
+	return fmt.Sprintf("%d %s", n, unit)
+}
+
+// ForgotPasswordHandler mails a password reset token to the user with the
+// given username. It answers 202 whether or not the user exists and has an
+// email address, so it cannot be used to find out either. It answers 501
+// if mail is disabled.
+func ForgotPasswordHandler(log *slog.Logger, storage PasswordResetRequester, mailer Mailer, resets PasswordResets) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if mailer == nil {
+			problem.Write(w, r, problem.MailUnavailable, "Password resets need mail delivery, which is disabled")
+			return
+		}
+
+		var req models.PasswordForgot
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request body", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidRequest, "Failed to decode request body")
+			return
+		}
+
+		if errs := req.Validate(); len(errs) > 0 {
+			log.Error("validation error", logger.Err(fmt.Errorf("invalid password reset request: %v", errs)))
+
+			problem.WriteValidation(w, r, "Invalid password reset request", errs)
+			return
+		}
+
+		user, err := storage.User(r.Context(), req.Username)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			if !errors.Is(err, store.ErrUserNotFound) {
+				log.Error("failed to retrieve user", logger.Err(err))
+
+				problem.Write(w, r, problem.Internal, "Failed to retrieve user")
+				return
+			}
+
+			log.Warn("password reset for unknown user", slog.String("username", req.Username))
+
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		if user.Email == "" {
+			log.Warn("password reset for user without email", slog.Int64("user_id", user.ID))
+
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		token, err := randomToken(32)
+		if err != nil {
+			log.Error("failed to generate reset token", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to generate reset token")
+			return
+		}
+
+		msg, err := resets.message(user, token)
+		if err != nil {
+			log.Error("failed to compose reset message", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to compose reset message")
+			return
+		}
+
+		err = storage.CreatePasswordResetToken(r.Context(), user.ID, utils.HashToken(token), time.Now().Add(resets.TTL))
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			log.Error("failed to store reset token", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to store reset token")
+			return
+		}
+
+		if err := mailer.Send(r.Context(), msg); err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			log.Error("failed to send reset message", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to send reset message")
+			return
+		}
+
+		log.Info("password reset requested", slog.Int64("user_id", user.ID))
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+type PasswordResetter interface {
+	ResetPassword(ctx context.Context, tokenHash, hash string) (int64, error)
+}
+
+// ResetPasswordHandler sets a new password with a token mailed by
+// ForgotPasswordHandler. The token and every other one the user was sent
+// are spent, and every refresh token of the user is revoked.
+func ResetPasswordHandler(log *slog.Logger, storage PasswordResetter, hasher PasswordHasher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.PasswordReset
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request body", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidRequest, "Failed to decode request body")
+			return
+		}
+
+		if errs := req.Validate(); len(errs) > 0 {
+			log.Error("validation error", logger.Err(fmt.Errorf("invalid password reset: %v", errs)))
+
+			problem.WriteValidation(w, r, "Invalid password reset", errs)
+			return
+		}
+
+		hash, err := hasher.Hash(req.NewPassword)
+		if err != nil {
+			log.Error("failed to hash password", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to hash password")
+			return
+		}
+
+		userID, err := storage.ResetPassword(r.Context(), utils.HashToken(req.Token), hash)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			if errors.Is(err, store.ErrResetTokenNotFound) {
+				log.Warn("invalid password reset token", logger.Err(err))
+
+				problem.Write(w, r, problem.InvalidResetToken, "Reset token is invalid, expired or already used")
+				return
+			}
+
+			log.Error("failed to reset password", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to reset password")
+			return
+		}
+
+		log.Info("password reset", slog.Int64("user_id", userID))
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
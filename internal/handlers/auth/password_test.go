@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"notes-api/internal/mail"
+	"notes-api/internal/models"
+	"notes-api/internal/password"
+	store "notes-api/internal/storage"
+	"notes-api/internal/utils"
+)
+
+func TestChangePasswordHandler(t *testing.T) {
+	const body = `{"current_password":"old-password","new_password":"new-password"}`
+	user := &models.User{ID: testUserID, Username: "alice", Password: "old-hash"}
+
+	for _, tt := range []struct {
+		name    string
+		body    string
+		expect  func(storage *MockPasswordChanger, hasher *MockPasswordHasher)
+		code    int
+		problem string
+	}{
+		{
+			name: "changes",
+			body: body,
+			expect: func(storage *MockPasswordChanger, hasher *MockPasswordHasher) {
+				storage.EXPECT().UserByID(mock.Anything, int64(testUserID)).Return(user, nil).Once()
+				hasher.EXPECT().Verify("old-hash", "old-password").Return(false, nil).Once()
+				hasher.EXPECT().Hash("new-password").Return("new-hash", nil).Once()
+				storage.EXPECT().SetPassword(mock.Anything, int64(testUserID), "new-hash").Return(nil).Once()
+				storage.EXPECT().CreateRefreshToken(mock.Anything, int64(testUserID), mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "wrong current password",
+			body: body,
+			expect: func(storage *MockPasswordChanger, hasher *MockPasswordHasher) {
+				storage.EXPECT().UserByID(mock.Anything, int64(testUserID)).Return(user, nil).Once()
+				hasher.EXPECT().Verify("old-hash", "old-password").Return(false, password.ErrMismatch).Once()
+			},
+			code:    http.StatusBadRequest,
+			problem: "validation_failed",
+		},
+		{
+			name: "unverifiable hash",
+			body: body,
+			expect: func(storage *MockPasswordChanger, hasher *MockPasswordHasher) {
+				storage.EXPECT().UserByID(mock.Anything, int64(testUserID)).Return(user, nil).Once()
+				hasher.EXPECT().Verify("old-hash", "old-password").Return(false, password.ErrUnknownScheme).Once()
+			},
+			code:    http.StatusInternalServerError,
+			problem: "internal_error",
+		},
+		{
+			name: "deleted user",
+			body: body,
+			expect: func(storage *MockPasswordChanger, hasher *MockPasswordHasher) {
+				storage.EXPECT().UserByID(mock.Anything, int64(testUserID)).Return(nil, store.ErrUserNotFound).Once()
+			},
+			code:    http.StatusUnauthorized,
+			problem: "unauthorized",
+		},
+		{
+			name:    "short new password",
+			body:    `{"current_password":"old-password","new_password":"new"}`,
+			expect:  func(*MockPasswordChanger, *MockPasswordHasher) {},
+			code:    http.StatusBadRequest,
+			problem: "validation_failed",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, hasher := NewMockPasswordChanger(t), NewMockPasswordHasher(t)
+			tt.expect(storage, hasher)
+
+			rec := serve("/auth/password", ChangePasswordHandler(discard, storage, hasher, testSigner(t), testTTL), http.MethodPost, "/auth/password", tt.body)
+			assert.Equal(t, tt.code, rec.Code)
+
+			if tt.problem != "" {
+				assert.Equal(t, tt.problem, problemCode(t, rec))
+				return
+			}
+
+			tokens := decodeTokens(t, rec)
+			assert.Equal(t, "access-token", tokens.Token)
+			assert.NotEmpty(t, tokens.RefreshToken)
+		})
+	}
+}
+
+var resetLinkToken = regexp.MustCompile(`https://notes\.example/reset\?token=([0-9a-f]+)`)
+
+func TestForgotPasswordHandler(t *testing.T) {
+	resets := PasswordResets{TTL: time.Hour, URL: "https://notes.example/reset"}
+
+	for _, tt := range []struct {
+		name    string
+		user    *models.User
+		err     error
+		code    int
+		problem string
+	}{
+		{name: "mails a token", user: &models.User{ID: testUserID, Username: "alice", Email: "alice@example.com"}, code: http.StatusAccepted},
+		// Neither tells whether the user exists or has an address.
+		{name: "unknown user", err: store.ErrUserNotFound, code: http.StatusAccepted},
+		{name: "no address", user: &models.User{ID: testUserID, Username: "alice"}, code: http.StatusAccepted},
+		{name: "storage failure", err: errors.New("disk on fire"), code: http.StatusInternalServerError, problem: "internal_error"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, mailer := NewMockPasswordResetRequester(t), NewMockMailer(t)
+			storage.EXPECT().User(mock.Anything, "alice").Return(tt.user, tt.err).Once()
+
+			var storedHash string
+			var sent mail.Message
+			if tt.user != nil && tt.user.Email != "" {
+				storage.EXPECT().CreatePasswordResetToken(mock.Anything, int64(testUserID), mock.Anything, mock.Anything).
+					RunAndReturn(func(_ context.Context, _ int64, tokenHash string, expiresAt time.Time) error {
+						storedHash = tokenHash
+						assert.WithinDuration(t, time.Now().Add(resets.TTL), expiresAt, time.Minute)
+						return nil
+					}).Once()
+				mailer.EXPECT().Send(mock.Anything, mock.Anything).
+					Run(func(_ context.Context, msg mail.Message) { sent = msg }).
+					Return(nil).Once()
+			}
+
+			rec := serve("/auth/password/forgot", ForgotPasswordHandler(discard, storage, mailer, resets), http.MethodPost, "/auth/password/forgot", `{"username":"alice"}`)
+			assert.Equal(t, tt.code, rec.Code)
+
+			if tt.problem != "" {
+				assert.Equal(t, tt.problem, problemCode(t, rec))
+			}
+
+			if storedHash != "" {
+				assert.Equal(t, tt.user.Email, sent.To)
+				assert.Contains(t, sent.Body, "1 hour")
+
+				link := resetLinkToken.FindStringSubmatch(sent.Body)
+				require.NotNil(t, link, sent.Body)
+				assert.Equal(t, utils.HashToken(link[1]), storedHash, "the token mailed must be the one stored")
+			}
+		})
+	}
+}
+
+func TestForgotPasswordHandlerWithoutMail(t *testing.T) {
+	rec := serve("/auth/password/forgot", ForgotPasswordHandler(discard, NewMockPasswordResetRequester(t), nil, PasswordResets{}), http.MethodPost, "/auth/password/forgot", `{"username":"alice"}`)
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+	assert.Equal(t, "mail_unavailable", problemCode(t, rec))
+}
+
+func TestResetPasswordHandler(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		body    string
+		err     error
+		code    int
+		problem string
+	}{
+		{name: "resets", body: `{"token":"reset-token","new_password":"new-password"}`, code: http.StatusNoContent},
+		{name: "spent token", body: `{"token":"reset-token","new_password":"new-password"}`, err: store.ErrResetTokenNotFound, code: http.StatusBadRequest, problem: "invalid_reset_token"},
+		{name: "short password", body: `{"token":"reset-token","new_password":"new"}`, code: http.StatusBadRequest, problem: "validation_failed"},
+		{name: "no token", body: `{"new_password":"new-password"}`, code: http.StatusBadRequest, problem: "validation_failed"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, hasher := NewMockPasswordResetter(t), NewMockPasswordHasher(t)
+			if tt.problem != "validation_failed" {
+				hasher.EXPECT().Hash("new-password").Return("new-hash", nil).Once()
+				storage.EXPECT().ResetPassword(mock.Anything, utils.HashToken("reset-token"), "new-hash").Return(testUserID, tt.err).Once()
+			}
+
+			rec := serve("/auth/password/reset", ResetPasswordHandler(discard, storage, hasher), http.MethodPost, "/auth/password/reset", tt.body)
+			assert.Equal(t, tt.code, rec.Code)
+			if tt.problem != "" {
+				assert.Equal(t, tt.problem, problemCode(t, rec))
+			}
+		})
+	}
+}
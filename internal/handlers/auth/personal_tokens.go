@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"notes-api/internal/models"
+	"notes-api/internal/problem"
+	store "notes-api/internal/storage"
+	"notes-api/internal/utils"
+	"notes-api/pkg/logger"
+)
+
+type PersonalTokenCreator interface {
+	CreatePersonalAccessToken(ctx context.Context, userID int64, name string, scopes []string, tokenHash string, expiresAt time.Time) (*models.PersonalAccessToken, error)
+}
+
+// CreatePersonalTokenHandler issues a named personal access token with the
+// requested scopes. The token itself is only ever shown in this response.
+func CreatePersonalTokenHandler(log *slog.Logger, storage PersonalTokenCreator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+
+		userID, ok := r.Context().Value(utils.UserIDKey).(string)
+		if !ok {
+			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
+
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
+			return
+		}
+
+		userIDInt, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			log.Error("error when converting user ID to int", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
+			return
+		}
+
+		req := models.PersonalAccessTokenRequest{ExpiresInDays: models.DefaultTokenLifetimeDays}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request body", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidRequest, "Failed to decode request body")
+			return
+		}
+
+		req.Name = strings.TrimSpace(req.Name)
+		slices.Sort(req.Scopes)
+		req.Scopes = slices.Compact(req.Scopes)
+
+		if errs := req.Validate(); len(errs) > 0 {
+			log.Error("validation error", logger.Err(fmt.Errorf("invalid token data: %v", errs)))
+
+			problem.WriteValidation(w, r, "Invalid token data", errs)
+			return
+		}
+
+		secret, err := randomToken(32)
+		if err != nil {
+			log.Error("failed to generate personal access token", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to generate personal access token")
+			return
+		}
+		raw := models.PersonalAccessTokenPrefix + secret
+
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+
+		token, err := storage.CreatePersonalAccessToken(r.Context(), userIDInt, req.Name, req.Scopes, utils.HashToken(raw), expiresAt)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			if errors.Is(err, store.ErrTokenAlreadyExists) {
+				log.Warn("personal access token already exists", slog.String("name", req.Name))
+
+				problem.Write(w, r, problem.TokenExists, "A token with this name already exists")
+				return
+			}
+
+			log.Error("failed to create personal access token", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to create personal access token")
+			return
+		}
+
+		token.Token = raw
+
+		w.WriteHeader(http.StatusCreated)
+		encoder.Encode(token)
+	}
+}
+
+type PersonalTokensProvider interface {
+	PersonalAccessTokens(ctx context.Context, userID int64) ([]models.PersonalAccessToken, error)
+}
+
+func PersonalTokensHandler(log *slog.Logger, storage PersonalTokensProvider) http.HandlerFunc {
+	type response struct {
+		Tokens []models.PersonalAccessToken `json:"tokens"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+
+		userID, ok := r.Context().Value(utils.UserIDKey).(string)
+		if !ok {
+			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
+
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
+			return
+		}
+
+		userIDInt, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			log.Error("error when converting user ID to int", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
+			return
+		}
+
+		tokens, err := storage.PersonalAccessTokens(r.Context(), userIDInt)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			log.Error("failed to retrieve personal access tokens", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to retrieve personal access tokens")
+			return
+		}
+
+		encoder.Encode(response{Tokens: tokens})
+	}
+}
+
+type PersonalTokenDeleter interface {
+	DeletePersonalAccessToken(ctx context.Context, userID, id int64) error
+}
+
+// DeletePersonalTokenHandler revokes a personal access token right away.
+func DeletePersonalTokenHandler(log *slog.Logger, storage PersonalTokenDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			log.Error("error when converting id to int", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidID, "ID must be an integer")
+			return
+		}
+
+		userID, ok := r.Context().Value(utils.UserIDKey).(string)
+		if !ok {
+			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
+
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
+			return
+		}
+
+		userIDInt, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			log.Error("error when converting user ID to int", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
+			return
+		}
+
+		if err := storage.DeletePersonalAccessToken(r.Context(), userIDInt, id); err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			if errors.Is(err, store.ErrTokenNotFound) {
+				log.Warn("personal access token not found", logger.Err(err))
+
+				problem.Write(w, r, problem.NotFound, "Token not found")
+				return
+			}
+
+			log.Error("failed to delete personal access token", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to delete personal access token")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"notes-api/internal/models"
+	store "notes-api/internal/storage"
+	"notes-api/internal/utils"
+)
+
+func TestCreatePersonalTokenHandler(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		body    string
+		scopes  []string
+		days    int
+		err     error
+		code    int
+		problem string
+	}{
+		{
+			name:   "creates",
+			body:   `{"name":" ci ","scopes":["notes:write","notes:read","notes:read"]}`,
+			scopes: []string{"notes:read", "notes:write"},
+			days:   models.DefaultTokenLifetimeDays,
+			code:   http.StatusCreated,
+		},
+		{
+			name:   "custom expiry",
+			body:   `{"name":"ci","scopes":["tags:read"],"expires_in_days":7}`,
+			scopes: []string{"tags:read"},
+			days:   7,
+			code:   http.StatusCreated,
+		},
+		{
+			name:    "name taken",
+			body:    `{"name":"ci","scopes":["tags:read"]}`,
+			scopes:  []string{"tags:read"},
+			days:    models.DefaultTokenLifetimeDays,
+			err:     store.ErrTokenAlreadyExists,
+			code:    http.StatusConflict,
+			problem: "token_exists",
+		},
+		{name: "unknown scope", body: `{"name":"ci","scopes":["admin"]}`, code: http.StatusBadRequest, problem: "validation_failed"},
+		{name: "no scopes", body: `{"name":"ci","scopes":[]}`, code: http.StatusBadRequest, problem: "validation_failed"},
+		{name: "no name", body: `{"name":" ","scopes":["tags:read"]}`, code: http.StatusBadRequest, problem: "validation_failed"},
+		{name: "expiry too long", body: `{"name":"ci","scopes":["tags:read"],"expires_in_days":366}`, code: http.StatusBadRequest, problem: "validation_failed"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewMockPersonalTokenCreator(t)
+
+			var storedHash string
+			if tt.scopes != nil {
+				storage.EXPECT().CreatePersonalAccessToken(mock.Anything, int64(testUserID), "ci", tt.scopes, mock.Anything, mock.Anything).
+					RunAndReturn(func(_ context.Context, userID int64, name string, scopes []string, tokenHash string, expiresAt time.Time) (*models.PersonalAccessToken, error) {
+						storedHash = tokenHash
+						assert.WithinDuration(t, time.Now().AddDate(0, 0, tt.days), expiresAt, time.Minute)
+						if tt.err != nil {
+							return nil, tt.err
+						}
+						return &models.PersonalAccessToken{ID: 1, Name: name, Scopes: scopes}, nil
+					}).Once()
+			}
+
+			rec := serve("/auth/tokens", CreatePersonalTokenHandler(discard, storage), http.MethodPost, "/auth/tokens", tt.body)
+			assert.Equal(t, tt.code, rec.Code)
+
+			if tt.problem != "" {
+				assert.Equal(t, tt.problem, problemCode(t, rec))
+				return
+			}
+
+			var token models.PersonalAccessToken
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &token))
+			assert.Equal(t, "ci", token.Name)
+			assert.Equal(t, tt.scopes, token.Scopes)
+			assert.True(t, strings.HasPrefix(token.Token, models.PersonalAccessTokenPrefix), token.Token)
+			assert.Equal(t, utils.HashToken(token.Token), storedHash, "the token handed out must be the one stored")
+		})
+	}
+}
+
+func TestPersonalTokensHandler(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		tokens []models.PersonalAccessToken
+		err    error
+		code   int
+		body   string
+	}{
+		{
+			name:   "lists",
+			tokens: []models.PersonalAccessToken{{ID: 1, Name: "ci", Scopes: []string{"notes:read"}, CreatedAt: "2026-01-01", ExpiresAt: "2026-02-01"}},
+			code:   http.StatusOK,
+			body:   `{"tokens":[{"id":1,"name":"ci","scopes":["notes:read"],"created_at":"2026-01-01","expires_at":"2026-02-01","last_used_at":null}]}`,
+		},
+		{name: "none", tokens: []models.PersonalAccessToken{}, code: http.StatusOK, body: `{"tokens":[]}`},
+		{name: "storage failure", err: errors.New("disk on fire"), code: http.StatusInternalServerError},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewMockPersonalTokensProvider(t)
+			storage.EXPECT().PersonalAccessTokens(mock.Anything, int64(testUserID)).Return(tt.tokens, tt.err).Once()
+
+			rec := serve("/auth/tokens", PersonalTokensHandler(discard, storage), http.MethodGet, "/auth/tokens", "")
+			assert.Equal(t, tt.code, rec.Code)
+			if tt.body != "" {
+				assert.JSONEq(t, tt.body, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestDeletePersonalTokenHandler(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		target  string
+		err     error
+		code    int
+		problem string
+	}{
+		{name: "revokes", target: "/auth/tokens/3", code: http.StatusNoContent},
+		{name: "unknown", target: "/auth/tokens/3", err: store.ErrTokenNotFound, code: http.StatusNotFound, problem: "not_found"},
+		{name: "invalid id", target: "/auth/tokens/three", code: http.StatusBadRequest, problem: "invalid_id"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewMockPersonalTokenDeleter(t)
+			if tt.problem != "invalid_id" {
+				storage.EXPECT().DeletePersonalAccessToken(mock.Anything, int64(testUserID), int64(3)).Return(tt.err).Once()
+			}
+
+			rec := serve("/auth/tokens/{id}", DeletePersonalTokenHandler(discard, storage), http.MethodDelete, tt.target, "")
+			assert.Equal(t, tt.code, rec.Code)
+			if tt.problem != "" {
+				assert.Equal(t, tt.problem, problemCode(t, rec))
+			}
+		})
+	}
+}
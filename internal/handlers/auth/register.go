@@ -1,26 +1,27 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
 
 	"notes-api/internal/models"
+	"notes-api/internal/problem"
 	"notes-api/pkg/logger"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
 type UserCreator interface {
-	CreateUser(username, password string) (int64, error)
-	UserExists(username string) (bool, error)
+	CreateUser(ctx context.Context, username, password, email string) (int64, error)
+	UserExists(ctx context.Context, username string) (bool, error)
 }
 
-func RegisterHandler(log *slog.Logger, storage UserCreator) http.HandlerFunc {
+func RegisterHandler(log *slog.Logger, storage UserCreator, hasher PasswordHasher) http.HandlerFunc {
 	type response struct {
 		ID       int64  `json:"id"`
 		Username string `json:"username"`
+		Email    string `json:"email,omitempty"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -32,8 +33,7 @@ func RegisterHandler(log *slog.Logger, storage UserCreator) http.HandlerFunc {
 		if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
 			log.Error("failed to decode request body", logger.Err(err))
 
-			w.WriteHeader(http.StatusBadRequest)
-			encoder.Encode(map[string]string{"InvalidRequest": "Failed to decode request body"})
+			problem.Write(w, r, problem.InvalidRequest, "Failed to decode request body")
 
 			return
 		}
@@ -41,18 +41,20 @@ func RegisterHandler(log *slog.Logger, storage UserCreator) http.HandlerFunc {
 		if errs := user.Validate(); len(errs) > 0 {
 			log.Error("Validation error", logger.Err(fmt.Errorf("invalid user data: %v", errs)))
 
-			w.WriteHeader(http.StatusBadRequest)
-			encoder.Encode(map[string]string{"ValidationError": fmt.Sprintf("Invalid user data: %v", errs)})
+			problem.WriteValidation(w, r, "Invalid user data", errs)
 
 			return
 		}
 
-		exists, err := storage.UserExists(user.Username)
+		exists, err := storage.UserExists(r.Context(), user.Username)
 		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
 			log.Error("failed to check if user exists", logger.Err(err))
 
-			w.WriteHeader(http.StatusInternalServerError)
-			encoder.Encode(map[string]string{"StorageError": "Failed to check if user exists"})
+			problem.Write(w, r, problem.Internal, "Failed to check if user exists")
 
 			return
 		}
@@ -60,42 +62,34 @@ func RegisterHandler(log *slog.Logger, storage UserCreator) http.HandlerFunc {
 		if exists {
 			log.Warn("user already exists", slog.String("username", user.Username))
 
-			w.WriteHeader(http.StatusConflict)
-			encoder.Encode(map[string]string{"UserExists": "User already exists"})
+			problem.Write(w, r, problem.UserExists, "User already exists")
 
 			return
 		}
 
-		hashedPassword, err := hashPassword(user.Password)
+		hashedPassword, err := hasher.Hash(user.Password)
 		if err != nil {
 			log.Error("failed to hash password", logger.Err(err))
 
-			w.WriteHeader(http.StatusInternalServerError)
-			encoder.Encode(map[string]string{"HashingError": "Failed to hash password"})
+			problem.Write(w, r, problem.Internal, "Failed to hash password")
 
 			return
 		}
 
-		id, err := storage.CreateUser(user.Username, hashedPassword)
+		id, err := storage.CreateUser(r.Context(), user.Username, hashedPassword, user.Email)
 		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
 			log.Error("failed to create user", logger.Err(err))
 
-			w.WriteHeader(http.StatusInternalServerError)
-			encoder.Encode(map[string]string{"StorageError": "Failed to create user"})
+			problem.Write(w, r, problem.Internal, "Failed to create user")
 
 			return
 		}
 
 		w.WriteHeader(http.StatusCreated)
-		encoder.Encode(response{ID: id, Username: user.Username})
+		encoder.Encode(response{ID: id, Username: user.Username, Email: user.Email})
 	}
 }
-
-func hashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 12)
-	if err != nil {
-		return "", fmt.Errorf("error hashing password: %w", err)
-	}
-
-	return string(hashedPassword), nil
-}
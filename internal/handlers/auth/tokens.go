@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"notes-api/internal/problem"
+	store "notes-api/internal/storage"
+	"notes-api/internal/utils"
+	"notes-api/pkg/logger"
+)
+
+// TokenTTL sets how long issued access and refresh tokens stay valid, and
+// how long a sign-in waits for its second factor.
+type TokenTTL struct {
+	Access       time.Duration
+	Refresh      time.Duration
+	MFAChallenge time.Duration
+}
+
+type tokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func newTokenResponse(token, refreshToken string, ttl TokenTTL) tokenResponse {
+	return tokenResponse{Token: token, RefreshToken: refreshToken, ExpiresIn: int(ttl.Access.Seconds())}
+}
+
+type TokenRotator interface {
+	RotateRefreshToken(ctx context.Context, tokenHash, newHash string, expiresAt time.Time) (int64, error)
+}
+
+// RefreshHandler trades a refresh token for a new access token and a new
+// refresh token. Each refresh token can be used once; reusing one revokes
+// every token descended from the same login.
+func RefreshHandler(log *slog.Logger, storage TokenRotator, signer TokenSigner, ttl TokenTTL) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+
+		var req refreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request body", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidRequest, "Failed to decode request body")
+			return
+		}
+
+		if req.RefreshToken == "" {
+			log.Warn("refresh without refresh token")
+
+			problem.Write(w, r, problem.InvalidRequest, "refresh_token is required")
+			return
+		}
+
+		refreshToken, err := randomToken(32)
+		if err != nil {
+			log.Error("failed to generate refresh token", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to generate refresh token")
+			return
+		}
+
+		userID, err := storage.RotateRefreshToken(r.Context(), utils.HashToken(req.RefreshToken), utils.HashToken(refreshToken), time.Now().Add(ttl.Refresh))
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			switch {
+			case errors.Is(err, store.ErrRefreshTokenReused):
+				log.Warn("refresh token reused, token family revoked", logger.Err(err))
+
+				problem.Write(w, r, problem.Unauthorized, "Refresh token has already been used")
+			case errors.Is(err, store.ErrRefreshTokenNotFound):
+				log.Warn("invalid refresh token", logger.Err(err))
+
+				problem.Write(w, r, problem.Unauthorized, "Invalid refresh token")
+			default:
+				log.Error("failed to rotate refresh token", logger.Err(err))
+
+				problem.Write(w, r, problem.Internal, "Failed to rotate refresh token")
+			}
+			return
+		}
+
+		token, err := GenerateJWT(signer, userID, ttl.Access)
+		if err != nil {
+			log.Error("failed to generate JWT token", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to generate JWT token")
+			return
+		}
+
+		encoder.Encode(newTokenResponse(token, refreshToken, ttl))
+	}
+}
+
+type TokenRevoker interface {
+	RevokeRefreshToken(ctx context.Context, userID int64, tokenHash string) error
+	RevokeUserRefreshTokens(ctx context.Context, userID int64) error
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+}
+
+// LogoutHandler revokes the access token of the request and, when one is
+// given, the session of a refresh token.
+func LogoutHandler(log *slog.Logger, storage TokenRevoker) http.HandlerFunc {
+	return logout(log, storage, false)
+}
+
+// LogoutAllHandler revokes every refresh token of the user along with the
+// access token of the request. Other access tokens already issued stay valid
+// until they expire, which the short access token lifetime bounds.
+func LogoutAllHandler(log *slog.Logger, storage TokenRevoker) http.HandlerFunc {
+	return logout(log, storage, true)
+}
+
+func logout(log *slog.Logger, storage TokenRevoker, all bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		userID, ok := r.Context().Value(utils.UserIDKey).(string)
+		if !ok {
+			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
+
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
+			return
+		}
+
+		userIDInt, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			log.Error("error when converting user ID to int", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
+			return
+		}
+
+		jti, _ := r.Context().Value(utils.TokenIDKey).(string)
+		expiresAt, _ := r.Context().Value(utils.TokenExpiresAtKey).(time.Time)
+
+		var req refreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			log.Error("failed to decode request body", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidRequest, "Failed to decode request body")
+			return
+		}
+
+		if all {
+			err = storage.RevokeUserRefreshTokens(r.Context(), userIDInt)
+		} else if req.RefreshToken != "" {
+			err = storage.RevokeRefreshToken(r.Context(), userIDInt, utils.HashToken(req.RefreshToken))
+		}
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			log.Error("failed to revoke refresh tokens", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to revoke refresh tokens")
+			return
+		}
+
+		if jti != "" {
+			if err := storage.RevokeAccessToken(r.Context(), jti, expiresAt); err != nil {
+				if problem.WriteContextError(w, r, err) {
+					return
+				}
+
+				log.Error("failed to revoke access token", logger.Err(err))
+
+				problem.Write(w, r, problem.Internal, "Failed to revoke access token")
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	store "notes-api/internal/storage"
+	"notes-api/internal/utils"
+)
+
+func TestRefreshHandler(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		body    string
+		err     error
+		code    int
+		problem string
+	}{
+		{name: "rotates", body: `{"refresh_token":"old"}`, code: http.StatusOK},
+		// The storage revokes the whole token family on reuse; the client
+		// only learns that the token is no good.
+		{name: "reused", body: `{"refresh_token":"old"}`, err: store.ErrRefreshTokenReused, code: http.StatusUnauthorized, problem: "unauthorized"},
+		{name: "unknown", body: `{"refresh_token":"old"}`, err: store.ErrRefreshTokenNotFound, code: http.StatusUnauthorized, problem: "unauthorized"},
+		{name: "storage failure", body: `{"refresh_token":"old"}`, err: errors.New("disk on fire"), code: http.StatusInternalServerError, problem: "internal_error"},
+		{name: "missing token", body: `{}`, code: http.StatusBadRequest, problem: "invalid_request"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewMockTokenRotator(t)
+
+			var newHash string
+			if tt.body != `{}` {
+				storage.EXPECT().RotateRefreshToken(mock.Anything, utils.HashToken("old"), mock.Anything, mock.Anything).
+					RunAndReturn(func(_ context.Context, _, hash string, expiresAt time.Time) (int64, error) {
+						newHash = hash
+						assert.WithinDuration(t, time.Now().Add(testTTL.Refresh), expiresAt, time.Minute)
+						return testUserID, tt.err
+					}).Once()
+			}
+
+			rec := serve("/auth/refresh", RefreshHandler(discard, storage, testSigner(t), testTTL), http.MethodPost, "/auth/refresh", tt.body)
+			assert.Equal(t, tt.code, rec.Code)
+
+			if tt.problem != "" {
+				assert.Equal(t, tt.problem, problemCode(t, rec))
+				return
+			}
+
+			tokens := decodeTokens(t, rec)
+			assert.Equal(t, "access-token", tokens.Token)
+			assert.NotEqual(t, "old", tokens.RefreshToken)
+			assert.Equal(t, utils.HashToken(tokens.RefreshToken), newHash, "the token handed out must be the one stored")
+			assert.Equal(t, int(testTTL.Access.Seconds()), tokens.ExpiresIn)
+		})
+	}
+}
+
+func TestLogoutHandler(t *testing.T) {
+	failure := errors.New("disk on fire")
+
+	for _, tt := range []struct {
+		name    string
+		all     bool
+		body    string
+		expect  func(storage *MockTokenRevoker)
+		code    int
+		problem string
+	}{
+		{
+			name: "access token only",
+			expect: func(storage *MockTokenRevoker) {
+				storage.EXPECT().RevokeAccessToken(mock.Anything, testJTI, testTokenExpiry).Return(nil).Once()
+			},
+			code: http.StatusNoContent,
+		},
+		{
+			name: "with refresh token",
+			body: `{"refresh_token":"refresh"}`,
+			expect: func(storage *MockTokenRevoker) {
+				storage.EXPECT().RevokeRefreshToken(mock.Anything, int64(testUserID), utils.HashToken("refresh")).Return(nil).Once()
+				storage.EXPECT().RevokeAccessToken(mock.Anything, testJTI, testTokenExpiry).Return(nil).Once()
+			},
+			code: http.StatusNoContent,
+		},
+		{
+			name: "everywhere",
+			all:  true,
+			expect: func(storage *MockTokenRevoker) {
+				storage.EXPECT().RevokeUserRefreshTokens(mock.Anything, int64(testUserID)).Return(nil).Once()
+				storage.EXPECT().RevokeAccessToken(mock.Anything, testJTI, testTokenExpiry).Return(nil).Once()
+			},
+			code: http.StatusNoContent,
+		},
+		{
+			name: "refresh token revocation fails",
+			body: `{"refresh_token":"refresh"}`,
+			expect: func(storage *MockTokenRevoker) {
+				storage.EXPECT().RevokeRefreshToken(mock.Anything, int64(testUserID), utils.HashToken("refresh")).Return(failure).Once()
+			},
+			code:    http.StatusInternalServerError,
+			problem: "internal_error",
+		},
+		{
+			name: "access token revocation fails",
+			expect: func(storage *MockTokenRevoker) {
+				storage.EXPECT().RevokeAccessToken(mock.Anything, testJTI, testTokenExpiry).Return(failure).Once()
+			},
+			code:    http.StatusInternalServerError,
+			problem: "internal_error",
+		},
+		{
+			name:    "malformed body",
+			body:    `{`,
+			expect:  func(*MockTokenRevoker) {},
+			code:    http.StatusBadRequest,
+			problem: "invalid_request",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewMockTokenRevoker(t)
+			tt.expect(storage)
+
+			handler := LogoutHandler(discard, storage)
+			if tt.all {
+				handler = LogoutAllHandler(discard, storage)
+			}
+
+			rec := serve("/auth/logout", handler, http.MethodPost, "/auth/logout", tt.body)
+			assert.Equal(t, tt.code, rec.Code)
+			if tt.problem != "" {
+				assert.Equal(t, tt.problem, problemCode(t, rec))
+			}
+		})
+	}
+}
@@ -0,0 +1,567 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"notes-api/internal/models"
+	"notes-api/internal/problem"
+	"notes-api/internal/ratelimit"
+	store "notes-api/internal/storage"
+	"notes-api/internal/totp"
+	"notes-api/internal/utils"
+	"notes-api/pkg/logger"
+)
+
+const (
+	recoveryCodeCount = 10
+
+	// maxMFAAttempts is how many wrong codes a sign-in challenge takes
+	// before it is spent, on top of the lockout they count towards.
+	maxMFAAttempts = 5
+)
+
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+type mfaChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// MFAChallengeCreator stores the challenges of sign-ins that wait for a
+// second factor.
+type MFAChallengeCreator interface {
+	CreateMFAChallenge(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error
+}
+
+// startChallenge issues the token of a sign-in of userID that waits for a
+// second factor.
+func startChallenge(ctx context.Context, storage MFAChallengeCreator, userID int64, ttl TokenTTL) (mfaChallengeResponse, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return mfaChallengeResponse{}, fmt.Errorf("failed to generate challenge token: %w", err)
+	}
+
+	err = storage.CreateMFAChallenge(ctx, userID, utils.HashToken(token), time.Now().Add(ttl.MFAChallenge))
+	if err != nil {
+		return mfaChallengeResponse{}, fmt.Errorf("failed to store challenge: %w", err)
+	}
+
+	return mfaChallengeResponse{MFARequired: true, MFAToken: token, ExpiresIn: int(ttl.MFAChallenge.Seconds())}, nil
+}
+
+// SecondFactorChecker spends the codes users give as a second factor, so
+// that none is accepted twice.
+type SecondFactorChecker interface {
+	UseTOTPStep(ctx context.Context, userID int64, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error
+}
+
+// checkSecondFactor reports whether code is a current code of the
+// authenticator app of user or, if recovery is set, one of the user's
+// recovery codes, and spends it if so.
+func checkSecondFactor(ctx context.Context, storage SecondFactorChecker, user *models.User, code string, now time.Time, recovery bool) (bool, error) {
+	if user.TOTPSecret == "" {
+		return false, nil
+	}
+
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits && strings.Trim(code, "0123456789") == "" {
+		step, ok := totp.Validate(user.TOTPSecret, code, now)
+		if !ok {
+			return false, nil
+		}
+
+		return storage.UseTOTPStep(ctx, user.ID, step)
+	}
+
+	if !recovery {
+		return false, nil
+	}
+
+	err := storage.UseRecoveryCode(ctx, user.ID, utils.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		if errors.Is(err, store.ErrRecoveryCodeNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// newRecoveryCodes returns recoveryCodeCount recovery codes of 50 random
+// bits each, formatted like abcde-fghij, and their hashes.
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	for range recoveryCodeCount {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := recoveryCodeEncoding.EncodeToString(b)[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, utils.HashToken(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode undoes what people do to recovery codes when they
+// copy them: change the case and drop or add separators.
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+}
+
+type MFAVerifier interface {
+	RefreshTokenCreator
+	FailedLoginRecorder
+	SecondFactorChecker
+	UserByIDProvider
+	MFAChallenge(ctx context.Context, tokenHash string) (*models.MFAChallenge, error)
+	RecordMFAChallengeFailure(ctx context.Context, tokenHash string) (int, error)
+	DeleteMFAChallenge(ctx context.Context, tokenHash string) error
+}
+
+// VerifyMFAHandler finishes a sign-in that LoginHandler answered with a
+// challenge: given its token and a code from the authenticator app or a
+// recovery code, it issues the access and refresh tokens. Wrong codes count
+// towards locking the account, like wrong passwords, and a challenge is
+// spent after maxMFAAttempts of them.
+func VerifyMFAHandler(log *slog.Logger, storage MFAVerifier, signer TokenSigner, ttl TokenTTL, lockout Lockout) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+
+		var req models.MFAVerify
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request body", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidRequest, "Failed to decode request body")
+			return
+		}
+
+		if errs := req.Validate(); len(errs) > 0 {
+			log.Error("validation error", logger.Err(fmt.Errorf("invalid MFA verification: %v", errs)))
+
+			problem.WriteValidation(w, r, "Invalid MFA verification", errs)
+			return
+		}
+
+		tokenHash := utils.HashToken(req.MFAToken)
+
+		challenge, err := storage.MFAChallenge(r.Context(), tokenHash)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			if errors.Is(err, store.ErrMFAChallengeNotFound) {
+				log.Warn("invalid MFA token", logger.Err(err))
+
+				problem.Write(w, r, problem.Unauthorized, "Invalid or expired MFA token")
+				return
+			}
+
+			log.Error("failed to retrieve challenge", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to retrieve challenge")
+			return
+		}
+
+		user, err := storage.UserByID(r.Context(), challenge.UserID)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			log.Error("failed to retrieve user", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to retrieve user")
+			return
+		}
+
+		now := time.Now()
+		if now.Before(user.LockedUntil) {
+			log.Warn("sign-in to locked account", slog.Int64("user_id", user.ID))
+
+			w.Header().Set("Retry-After", strconv.Itoa(ratelimit.Seconds(user.LockedUntil.Sub(now))))
+			problem.Write(w, r, problem.AccountLocked, "Too many failed sign-ins, retry later")
+			return
+		}
+
+		ok, err := checkSecondFactor(r.Context(), storage, user, req.Code, now, true)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			log.Error("failed to check code", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to check code")
+			return
+		}
+
+		if !ok {
+			log.Warn("wrong second factor", slog.Int64("user_id", user.ID))
+
+			err := lockout.record(r.Context(), log, storage, user.ID, now)
+			if err == nil {
+				err = failChallenge(r.Context(), storage, tokenHash)
+			}
+			if err != nil {
+				if problem.WriteContextError(w, r, err) {
+					return
+				}
+
+				log.Error("failed to record failed sign-in", logger.Err(err))
+
+				problem.Write(w, r, problem.Internal, "Failed to record failed sign-in")
+				return
+			}
+
+			problem.Write(w, r, problem.Unauthorized, "Invalid authentication code")
+			return
+		}
+
+		// Only one of concurrent verifications of a challenge gets to spend
+		// it.
+		if err := storage.DeleteMFAChallenge(r.Context(), tokenHash); err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			if errors.Is(err, store.ErrMFAChallengeNotFound) {
+				log.Warn("MFA token spent concurrently", slog.Int64("user_id", user.ID))
+
+				problem.Write(w, r, problem.Unauthorized, "Invalid or expired MFA token")
+				return
+			}
+
+			log.Error("failed to spend challenge", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to spend challenge")
+			return
+		}
+
+		if user.FailedLogins > 0 {
+			if err := storage.ResetFailedLogins(r.Context(), user.ID); err != nil {
+				if problem.WriteContextError(w, r, err) {
+					return
+				}
+
+				log.Error("failed to reset failed sign-ins", logger.Err(err))
+
+				problem.Write(w, r, problem.Internal, "Failed to reset failed sign-ins")
+				return
+			}
+		}
+
+		tokens, err := startSession(r.Context(), storage, signer, user.ID, ttl)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			log.Error("failed to start session", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to start session")
+			return
+		}
+
+		encoder.Encode(tokens)
+	}
+}
+
+// failChallenge counts a wrong code for the challenge with tokenHash and
+// spends it once there have been maxMFAAttempts.
+func failChallenge(ctx context.Context, storage MFAVerifier, tokenHash string) error {
+	attempts, err := storage.RecordMFAChallengeFailure(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, store.ErrMFAChallengeNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if attempts < maxMFAAttempts {
+		return nil
+	}
+
+	err = storage.DeleteMFAChallenge(ctx, tokenHash)
+	if err != nil && !errors.Is(err, store.ErrMFAChallengeNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+type TOTPEnroller interface {
+	SecondFactorChecker
+	UserByIDProvider
+	SetTOTPSecret(ctx context.Context, userID int64, secret string) error
+	EnableTOTP(ctx context.Context, userID int64, codeHashes []string) error
+	DisableTOTP(ctx context.Context, userID int64) error
+}
+
+// SetupTOTPHandler begins two-factor setup for the signed-in user with a
+// new secret, which it returns along with its otpauth URI for authenticator
+// apps. Sign-ins do not ask for codes until ConfirmTOTPHandler is given
+// one. Starting over replaces the secret of an unconfirmed setup.
+func SetupTOTPHandler(log *slog.Logger, storage TOTPEnroller, issuer string) http.HandlerFunc {
+	type response struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+
+		user, ok := currentUser(w, r, log, storage)
+		if !ok {
+			return
+		}
+
+		if user.TOTPEnabled {
+			problem.Write(w, r, problem.MFAEnabled, "Disable two-factor sign-in before setting it up again")
+			return
+		}
+
+		secret, err := totp.NewSecret()
+		if err != nil {
+			log.Error("failed to generate TOTP secret", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to generate TOTP secret")
+			return
+		}
+
+		if err := storage.SetTOTPSecret(r.Context(), user.ID, secret); err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			log.Error("failed to store TOTP secret", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to store TOTP secret")
+			return
+		}
+
+		encoder.Encode(response{Secret: secret, URI: totp.URI(issuer, user.Username, secret)})
+	}
+}
+
+// ConfirmTOTPHandler enables two-factor sign-in for the signed-in user,
+// given a code of the secret SetupTOTPHandler returned, which proves the
+// authenticator app has it. It returns the recovery codes of the user; they
+// are only ever shown in this response.
+func ConfirmTOTPHandler(log *slog.Logger, storage TOTPEnroller) http.HandlerFunc {
+	type response struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+
+		var req models.TOTPCode
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request body", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidRequest, "Failed to decode request body")
+			return
+		}
+
+		if errs := req.Validate(); len(errs) > 0 {
+			log.Error("validation error", logger.Err(fmt.Errorf("invalid TOTP code: %v", errs)))
+
+			problem.WriteValidation(w, r, "Invalid TOTP code", errs)
+			return
+		}
+
+		user, ok := currentUser(w, r, log, storage)
+		if !ok {
+			return
+		}
+
+		if user.TOTPEnabled {
+			problem.Write(w, r, problem.MFAEnabled, "Two-factor sign-in is already enabled")
+			return
+		}
+
+		if user.TOTPSecret == "" {
+			problem.Write(w, r, problem.MFASetupRequired, "Start two-factor setup first")
+			return
+		}
+
+		ok, err := checkSecondFactor(r.Context(), storage, user, req.Code, time.Now(), false)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			log.Error("failed to check code", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to check code")
+			return
+		}
+
+		if !ok {
+			problem.WriteValidation(w, r, "Invalid TOTP code", map[string]string{
+				"code": "Code is wrong or expired",
+			})
+			return
+		}
+
+		codes, hashes, err := newRecoveryCodes()
+		if err != nil {
+			log.Error("failed to generate recovery codes", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to generate recovery codes")
+			return
+		}
+
+		if err := storage.EnableTOTP(r.Context(), user.ID, hashes); err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			log.Error("failed to enable two-factor sign-in", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to enable two-factor sign-in")
+			return
+		}
+
+		log.Info("two-factor sign-in enabled", slog.Int64("user_id", user.ID))
+
+		encoder.Encode(response{RecoveryCodes: codes})
+	}
+}
+
+// DisableTOTPHandler turns two-factor sign-in off for the signed-in user,
+// given a code from the authenticator app or a recovery code, so that a
+// stolen access token is not enough.
+func DisableTOTPHandler(log *slog.Logger, storage TOTPEnroller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.TOTPCode
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request body", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidRequest, "Failed to decode request body")
+			return
+		}
+
+		if errs := req.Validate(); len(errs) > 0 {
+			log.Error("validation error", logger.Err(fmt.Errorf("invalid TOTP code: %v", errs)))
+
+			problem.WriteValidation(w, r, "Invalid TOTP code", errs)
+			return
+		}
+
+		user, ok := currentUser(w, r, log, storage)
+		if !ok {
+			return
+		}
+
+		if !user.TOTPEnabled {
+			problem.Write(w, r, problem.MFANotEnabled, "Two-factor sign-in is not enabled")
+			return
+		}
+
+		ok, err := checkSecondFactor(r.Context(), storage, user, req.Code, time.Now(), true)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			log.Error("failed to check code", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to check code")
+			return
+		}
+
+		if !ok {
+			problem.WriteValidation(w, r, "Invalid TOTP code", map[string]string{
+				"code": "Code is wrong or expired",
+			})
+			return
+		}
+
+		if err := storage.DisableTOTP(r.Context(), user.ID); err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			log.Error("failed to disable two-factor sign-in", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to disable two-factor sign-in")
+			return
+		}
+
+		log.Info("two-factor sign-in disabled", slog.Int64("user_id", user.ID))
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+type UserByIDProvider interface {
+	UserByID(ctx context.Context, id int64) (*models.User, error)
+}
+
+// currentUser loads the signed-in user of r. If that fails, it answers r
+// and reports false.
+func currentUser(w http.ResponseWriter, r *http.Request, log *slog.Logger, storage UserByIDProvider) (*models.User, bool) {
+	userID, ok := r.Context().Value(utils.UserIDKey).(string)
+	if !ok {
+		log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
+
+		problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
+		return nil, false
+	}
+
+	userIDInt, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		log.Error("error when converting user ID to int", logger.Err(err))
+
+		problem.Write(w, r, problem.Internal, "Failed to convert user ID")
+		return nil, false
+	}
+
+	user, err := storage.UserByID(r.Context(), userIDInt)
+	if err != nil {
+		if problem.WriteContextError(w, r, err) {
+			return nil, false
+		}
+
+		if errors.Is(err, store.ErrUserNotFound) {
+			log.Warn("request of deleted user", slog.Int64("user_id", userIDInt))
+
+			problem.Write(w, r, problem.Unauthorized, "User not found")
+			return nil, false
+		}
+
+		log.Error("failed to retrieve user", logger.Err(err))
+
+		problem.Write(w, r, problem.Internal, "Failed to retrieve user")
+		return nil, false
+	}
+
+	return user, true
+}
@@ -0,0 +1,358 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"notes-api/internal/models"
+	store "notes-api/internal/storage"
+	"notes-api/internal/totp"
+	"notes-api/internal/utils"
+)
+
+// totpCode returns a secret and its code for the current time step, and
+// that step.
+func totpCode(t *testing.T) (secret, code string, step int64) {
+	t.Helper()
+
+	secret, err := totp.NewSecret()
+	require.NoError(t, err)
+
+	step = totp.Step(time.Now())
+	code, err = totp.Code(secret, step)
+	require.NoError(t, err)
+
+	return secret, code, step
+}
+
+func TestSetupTOTPHandler(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		user    *models.User
+		code    int
+		problem string
+	}{
+		{name: "starts", user: &models.User{ID: testUserID, Username: "alice"}, code: http.StatusOK},
+		{name: "starts over", user: &models.User{ID: testUserID, Username: "alice", TOTPSecret: "JBSWY3DPEHPK3PXP"}, code: http.StatusOK},
+		{name: "already enabled", user: &models.User{ID: testUserID, Username: "alice", TOTPSecret: "JBSWY3DPEHPK3PXP", TOTPEnabled: true}, code: http.StatusConflict, problem: "mfa_enabled"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewMockTOTPEnroller(t)
+			storage.EXPECT().UserByID(mock.Anything, int64(testUserID)).Return(tt.user, nil).Once()
+
+			var stored string
+			if tt.problem == "" {
+				storage.EXPECT().SetTOTPSecret(mock.Anything, int64(testUserID), mock.Anything).
+					Run(func(_ context.Context, _ int64, secret string) { stored = secret }).
+					Return(nil).Once()
+			}
+
+			rec := serve("/auth/2fa/setup", SetupTOTPHandler(discard, storage, "Notes"), http.MethodPost, "/auth/2fa/setup", "")
+			assert.Equal(t, tt.code, rec.Code)
+
+			if tt.problem != "" {
+				assert.Equal(t, tt.problem, problemCode(t, rec))
+				return
+			}
+
+			var body struct {
+				Secret string `json:"secret"`
+				URI    string `json:"uri"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, stored, body.Secret)
+			assert.NotEqual(t, tt.user.TOTPSecret, body.Secret)
+			assert.Equal(t, totp.URI("Notes", "alice", stored), body.URI)
+		})
+	}
+}
+
+func TestConfirmTOTPHandler(t *testing.T) {
+	secret, code, step := totpCode(t)
+	staleCode, err := totp.Code(secret, step-100)
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name    string
+		user    *models.User
+		code    string
+		expect  func(storage *MockTOTPEnroller)
+		status  int
+		problem string
+	}{
+		{
+			name: "enables",
+			user: &models.User{ID: testUserID, TOTPSecret: secret},
+			code: code,
+			expect: func(storage *MockTOTPEnroller) {
+				storage.EXPECT().UseTOTPStep(mock.Anything, int64(testUserID), step).Return(true, nil).Once()
+				storage.EXPECT().EnableTOTP(mock.Anything, int64(testUserID), mock.Anything).Return(nil).Once()
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:    "wrong code",
+			user:    &models.User{ID: testUserID, TOTPSecret: secret},
+			code:    staleCode,
+			expect:  func(*MockTOTPEnroller) {},
+			status:  http.StatusBadRequest,
+			problem: "validation_failed",
+		},
+		{
+			name: "replayed code",
+			user: &models.User{ID: testUserID, TOTPSecret: secret},
+			code: code,
+			expect: func(storage *MockTOTPEnroller) {
+				storage.EXPECT().UseTOTPStep(mock.Anything, int64(testUserID), step).Return(false, nil).Once()
+			},
+			status:  http.StatusBadRequest,
+			problem: "validation_failed",
+		},
+		{
+			name:    "setup not started",
+			user:    &models.User{ID: testUserID},
+			code:    code,
+			expect:  func(*MockTOTPEnroller) {},
+			status:  http.StatusConflict,
+			problem: "mfa_setup_required",
+		},
+		{
+			name:    "already enabled",
+			user:    &models.User{ID: testUserID, TOTPSecret: secret, TOTPEnabled: true},
+			code:    code,
+			expect:  func(*MockTOTPEnroller) {},
+			status:  http.StatusConflict,
+			problem: "mfa_enabled",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewMockTOTPEnroller(t)
+			storage.EXPECT().UserByID(mock.Anything, int64(testUserID)).Return(tt.user, nil).Once()
+			tt.expect(storage)
+
+			rec := serve("/auth/2fa/confirm", ConfirmTOTPHandler(discard, storage), http.MethodPost, "/auth/2fa/confirm", `{"code":"`+tt.code+`"}`)
+			assert.Equal(t, tt.status, rec.Code)
+
+			if tt.problem != "" {
+				assert.Equal(t, tt.problem, problemCode(t, rec))
+				return
+			}
+
+			var body struct {
+				RecoveryCodes []string `json:"recovery_codes"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			require.Len(t, body.RecoveryCodes, recoveryCodeCount)
+
+			var hashes []string
+			for _, code := range body.RecoveryCodes {
+				hashes = append(hashes, utils.HashToken(normalizeRecoveryCode(code)))
+			}
+			storage.AssertCalled(t, "EnableTOTP", mock.Anything, int64(testUserID), hashes)
+		})
+	}
+}
+
+func TestDisableTOTPHandler(t *testing.T) {
+	secret, code, step := totpCode(t)
+	enabled := &models.User{ID: testUserID, TOTPSecret: secret, TOTPEnabled: true}
+
+	for _, tt := range []struct {
+		name    string
+		user    *models.User
+		code    string
+		expect  func(storage *MockTOTPEnroller)
+		status  int
+		problem string
+	}{
+		{
+			name: "with code",
+			user: enabled,
+			code: code,
+			expect: func(storage *MockTOTPEnroller) {
+				storage.EXPECT().UseTOTPStep(mock.Anything, int64(testUserID), step).Return(true, nil).Once()
+				storage.EXPECT().DisableTOTP(mock.Anything, int64(testUserID)).Return(nil).Once()
+			},
+			status: http.StatusNoContent,
+		},
+		{
+			name: "with recovery code",
+			user: enabled,
+			code: "ABCDE-FGHIJ",
+			expect: func(storage *MockTOTPEnroller) {
+				storage.EXPECT().UseRecoveryCode(mock.Anything, int64(testUserID), utils.HashToken("abcdefghij")).Return(nil).Once()
+				storage.EXPECT().DisableTOTP(mock.Anything, int64(testUserID)).Return(nil).Once()
+			},
+			status: http.StatusNoContent,
+		},
+		{
+			name: "spent recovery code",
+			user: enabled,
+			code: "abcde-fghij",
+			expect: func(storage *MockTOTPEnroller) {
+				storage.EXPECT().UseRecoveryCode(mock.Anything, int64(testUserID), utils.HashToken("abcdefghij")).Return(store.ErrRecoveryCodeNotFound).Once()
+			},
+			status:  http.StatusBadRequest,
+			problem: "validation_failed",
+		},
+		{
+			name:    "not enabled",
+			user:    &models.User{ID: testUserID, TOTPSecret: secret},
+			code:    code,
+			expect:  func(*MockTOTPEnroller) {},
+			status:  http.StatusConflict,
+			problem: "mfa_not_enabled",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewMockTOTPEnroller(t)
+			storage.EXPECT().UserByID(mock.Anything, int64(testUserID)).Return(tt.user, nil).Once()
+			tt.expect(storage)
+
+			rec := serve("/auth/2fa/disable", DisableTOTPHandler(discard, storage), http.MethodPost, "/auth/2fa/disable", `{"code":"`+tt.code+`"}`)
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.problem != "" {
+				assert.Equal(t, tt.problem, problemCode(t, rec))
+			}
+		})
+	}
+}
+
+func TestVerifyMFAHandler(t *testing.T) {
+	secret, code, step := totpCode(t)
+	staleCode, err := totp.Code(secret, step-100)
+	require.NoError(t, err)
+
+	mfaHash := utils.HashToken("mfa-token")
+	lockout := Lockout{Threshold: 3, Duration: time.Minute}
+	user := func(failedLogins int, lockedUntil time.Time) *models.User {
+		return &models.User{ID: testUserID, TOTPSecret: secret, TOTPEnabled: true, FailedLogins: failedLogins, LockedUntil: lockedUntil}
+	}
+
+	for _, tt := range []struct {
+		name    string
+		code    string
+		expect  func(storage *MockMFAVerifier)
+		status  int
+		problem string
+	}{
+		{
+			name: "signs in",
+			code: code,
+			expect: func(storage *MockMFAVerifier) {
+				storage.EXPECT().UserByID(mock.Anything, int64(testUserID)).Return(user(0, time.Time{}), nil).Once()
+				storage.EXPECT().UseTOTPStep(mock.Anything, int64(testUserID), step).Return(true, nil).Once()
+				storage.EXPECT().DeleteMFAChallenge(mock.Anything, mfaHash).Return(nil).Once()
+				storage.EXPECT().CreateRefreshToken(mock.Anything, int64(testUserID), mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "forgives failed sign-ins",
+			code: code,
+			expect: func(storage *MockMFAVerifier) {
+				storage.EXPECT().UserByID(mock.Anything, int64(testUserID)).Return(user(2, time.Time{}), nil).Once()
+				storage.EXPECT().UseTOTPStep(mock.Anything, int64(testUserID), step).Return(true, nil).Once()
+				storage.EXPECT().DeleteMFAChallenge(mock.Anything, mfaHash).Return(nil).Once()
+				storage.EXPECT().ResetFailedLogins(mock.Anything, int64(testUserID)).Return(nil).Once()
+				storage.EXPECT().CreateRefreshToken(mock.Anything, int64(testUserID), mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "wrong code",
+			code: staleCode,
+			expect: func(storage *MockMFAVerifier) {
+				storage.EXPECT().UserByID(mock.Anything, int64(testUserID)).Return(user(0, time.Time{}), nil).Once()
+				storage.EXPECT().RecordFailedLogin(mock.Anything, int64(testUserID)).Return(1, nil).Once()
+				storage.EXPECT().RecordMFAChallengeFailure(mock.Anything, mfaHash).Return(1, nil).Once()
+			},
+			status:  http.StatusUnauthorized,
+			problem: "unauthorized",
+		},
+		{
+			name: "wrong code spends challenge",
+			code: staleCode,
+			expect: func(storage *MockMFAVerifier) {
+				storage.EXPECT().UserByID(mock.Anything, int64(testUserID)).Return(user(0, time.Time{}), nil).Once()
+				storage.EXPECT().RecordFailedLogin(mock.Anything, int64(testUserID)).Return(1, nil).Once()
+				storage.EXPECT().RecordMFAChallengeFailure(mock.Anything, mfaHash).Return(maxMFAAttempts, nil).Once()
+				storage.EXPECT().DeleteMFAChallenge(mock.Anything, mfaHash).Return(nil).Once()
+			},
+			status:  http.StatusUnauthorized,
+			problem: "unauthorized",
+		},
+		{
+			name: "wrong code locks account",
+			code: staleCode,
+			expect: func(storage *MockMFAVerifier) {
+				storage.EXPECT().UserByID(mock.Anything, int64(testUserID)).Return(user(2, time.Time{}), nil).Once()
+				storage.EXPECT().RecordFailedLogin(mock.Anything, int64(testUserID)).Return(3, nil).Once()
+				storage.EXPECT().LockUser(mock.Anything, int64(testUserID), mock.Anything).Return(nil).Once()
+				storage.EXPECT().RecordMFAChallengeFailure(mock.Anything, mfaHash).Return(1, nil).Once()
+			},
+			status:  http.StatusUnauthorized,
+			problem: "unauthorized",
+		},
+		{
+			name: "locked account",
+			code: code,
+			expect: func(storage *MockMFAVerifier) {
+				storage.EXPECT().UserByID(mock.Anything, int64(testUserID)).Return(user(3, time.Now().Add(time.Minute)), nil).Once()
+			},
+			status:  http.StatusTooManyRequests,
+			problem: "account_locked",
+		},
+		{
+			name: "spent concurrently",
+			code: code,
+			expect: func(storage *MockMFAVerifier) {
+				storage.EXPECT().UserByID(mock.Anything, int64(testUserID)).Return(user(0, time.Time{}), nil).Once()
+				storage.EXPECT().UseTOTPStep(mock.Anything, int64(testUserID), step).Return(true, nil).Once()
+				storage.EXPECT().DeleteMFAChallenge(mock.Anything, mfaHash).Return(store.ErrMFAChallengeNotFound).Once()
+			},
+			status:  http.StatusUnauthorized,
+			problem: "unauthorized",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewMockMFAVerifier(t)
+			storage.EXPECT().MFAChallenge(mock.Anything, mfaHash).Return(&models.MFAChallenge{UserID: testUserID}, nil).Once()
+			tt.expect(storage)
+
+			rec := serve("/auth/2fa/verify", VerifyMFAHandler(discard, storage, testSigner(t), testTTL, lockout), http.MethodPost, "/auth/2fa/verify",
+				`{"mfa_token":"mfa-token","code":"`+tt.code+`"}`)
+			assert.Equal(t, tt.status, rec.Code)
+
+			if tt.problem != "" {
+				assert.Equal(t, tt.problem, problemCode(t, rec))
+				if tt.status == http.StatusTooManyRequests {
+					retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+					require.NoError(t, err)
+					assert.InDelta(t, 60, retryAfter, 1)
+				}
+				return
+			}
+
+			tokens := decodeTokens(t, rec)
+			assert.Equal(t, "access-token", tokens.Token)
+		})
+	}
+}
+
+func TestVerifyMFAHandlerUnknownChallenge(t *testing.T) {
+	storage := NewMockMFAVerifier(t)
+	storage.EXPECT().MFAChallenge(mock.Anything, utils.HashToken("mfa-token")).Return(nil, store.ErrMFAChallengeNotFound).Once()
+
+	rec := serve("/auth/2fa/verify", VerifyMFAHandler(discard, storage, testSigner(t), testTTL, Lockout{}), http.MethodPost, "/auth/2fa/verify", `{"mfa_token":"mfa-token","code":"123456"}`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "unauthorized", problemCode(t, rec))
+}
@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"notes-api/pkg/logger"
+)
+
+const (
+	statusOK   = "ok"
+	statusFail = "fail"
+	statusSkip = "skipped"
+)
+
+type ReadinessChecker interface {
+	Ping(ctx context.Context) error
+	PendingMigrations() (int, error)
+}
+
+type check struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type response struct {
+	Status string           `json:"status"`
+	Checks map[string]check `json:"checks,omitempty"`
+}
+
+// HealthzHandler reports that the process is up and serving requests. It
+// checks nothing else, so a failing dependency never gets the process
+// restarted.
+func HealthzHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response{Status: statusOK})
+	}
+}
+
+// ReadyzHandler reports whether the instance should receive traffic: the
+// database answers, its schema is up to date and shutdown has not begun.
+// Every check is listed in the response; any failing one makes it a 503.
+func ReadyzHandler(log *slog.Logger, storage ReadinessChecker, shuttingDown func() bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		resp := response{Status: statusOK, Checks: make(map[string]check)}
+		record := func(name string, err error) {
+			if err != nil {
+				log.Warn("readiness check failed", slog.String("check", name), logger.Err(err))
+
+				resp.Status = statusFail
+				resp.Checks[name] = check{Status: statusFail, Error: err.Error()}
+				return
+			}
+
+			resp.Checks[name] = check{Status: statusOK}
+		}
+
+		if shuttingDown() {
+			record("shutdown", fmt.Errorf("server is shutting down"))
+		} else {
+			record("shutdown", nil)
+		}
+
+		if err := storage.Ping(r.Context()); err != nil {
+			record("database", err)
+			// The schema cannot be read without the database.
+			resp.Checks["migrations"] = check{Status: statusSkip}
+		} else {
+			record("database", nil)
+
+			pending, err := storage.PendingMigrations()
+			if err == nil && pending > 0 {
+				err = fmt.Errorf("%d migrations pending", pending)
+			}
+			record("migrations", err)
+		}
+
+		if resp.Status != statusOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -0,0 +1,18 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"notes-api/internal/buildinfo"
+)
+
+// VersionHandler returns the build metadata of the running binary.
+func VersionHandler() http.HandlerFunc {
+	info := buildinfo.Get()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(info)
+	}
+}
@@ -1,10 +1,12 @@
 package notes
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"notes-api/internal/models"
+	"notes-api/internal/problem"
 	"notes-api/internal/utils"
 	"notes-api/pkg/logger"
 	"strconv"
@@ -13,36 +15,39 @@ import (
 )
 
 type NoteCreator interface {
-	CreateNote(userID int, title, content string) (int64, error)
+	CreateNote(ctx context.Context, userID int, title, content string, tags []string) (int64, error)
 }
 
 func CreateNoteHandler(log *slog.Logger, storage NoteCreator) http.HandlerFunc {
 	type response struct {
-		ID      int64  `json:"id"`
-		Title   string `json:"title"`
-		Content string `json:"content"`
+		ID      int64    `json:"id"`
+		Title   string   `json:"title"`
+		Content string   `json:"content"`
+		Tags    []string `json:"tags"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		var note models.Note
 
 		w.Header().Set("Content-Type", "application/json")
-		encoder := json.NewEncoder(w)
 
 		if err := json.NewDecoder(r.Body).Decode(&note); err != nil {
 			log.Error("failed to decode request body", logger.Err(err))
 
-			w.WriteHeader(http.StatusBadRequest)
-			encoder.Encode(map[string]string{"InvalidRequest": "Failed to decode request body"})
+			problem.Write(w, r, problem.InvalidRequest, "Failed to decode request body")
 
 			return
 		}
 
+		note.Tags = models.NormalizeTags(note.Tags)
+		if note.Tags == nil {
+			note.Tags = []string{}
+		}
+
 		if errs := note.Validate(); len(errs) > 0 {
 			log.Error("validation error", logger.Err(fmt.Errorf("invalid note data: %v", errs)))
 
-			w.WriteHeader(http.StatusBadRequest)
-			encoder.Encode(map[string]string{"ValidationError": fmt.Sprintf("Invalid note data: %v", errs)})
+			problem.WriteValidation(w, r, "Invalid note data", errs)
 
 			return
 		}
@@ -51,8 +56,7 @@ func CreateNoteHandler(log *slog.Logger, storage NoteCreator) http.HandlerFunc {
 		if !ok {
 			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
 
-			w.WriteHeader(http.StatusUnauthorized)
-			encoder.Encode(map[string]string{"Unauthorized": "User ID not found in context"})
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
 
 			return
 		}
@@ -61,26 +65,30 @@ func CreateNoteHandler(log *slog.Logger, storage NoteCreator) http.HandlerFunc {
 		if err != nil {
 			log.Error("invalid user ID", logger.Err(err))
 
-			w.WriteHeader(http.StatusInternalServerError)
-			encoder.Encode(map[string]string{"InvalidUserID": "Invalid user ID"})
+			problem.Write(w, r, problem.Internal, "Invalid user ID")
 
 			return
 		}
-		id, err := storage.CreateNote(userIDInt, note.Title, note.Content)
+		id, err := storage.CreateNote(r.Context(), userIDInt, note.Title, note.Content, note.Tags)
 		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
 			log.Error("error creating note", logger.Err(err))
 
-			w.WriteHeader(http.StatusInternalServerError)
-			encoder.Encode(map[string]string{"Error": "Error creating note"})
+			problem.Write(w, r, problem.Internal, "Error creating note")
 
 			return
 		}
 
+		w.Header().Set("ETag", etag(1))
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(response{
 			ID:      id,
 			Title:   note.Title,
 			Content: note.Content,
+			Tags:    note.Tags,
 		})
 	}
 }
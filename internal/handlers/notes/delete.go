@@ -1,6 +1,7 @@
 package notes
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -9,49 +10,86 @@ import (
 
 	"log/slog"
 
-	"encoding/json"
+	"errors"
 	"fmt"
+	"notes-api/internal/problem"
+	store "notes-api/internal/storage"
 	"notes-api/internal/utils"
 	"notes-api/pkg/logger"
 )
 
 type NoteDeleter interface {
-	DeleteNote(id, userID int) error
+	DeleteNote(ctx context.Context, id, userID, version int) error
+	PurgeNote(ctx context.Context, id, userID, version int) error
 }
 
+// DeleteNoteHandler trashes a note, or deletes it for good with ?permanent=true.
 func DeleteNoteHandler(log *slog.Logger, storage NoteDeleter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		encoder := json.NewEncoder(w)
 
 		id, err := strconv.Atoi(chi.URLParam(r, "id"))
 		if err != nil {
 			log.Error("error when converting id to int", logger.Err(err))
-			w.WriteHeader(http.StatusBadRequest)
-			encoder.Encode(map[string]string{"InvalidID": "ID must be an integer"})
+			problem.Write(w, r, problem.InvalidID, "ID must be an integer")
 			return
 		}
 
 		userID, ok := r.Context().Value(utils.UserIDKey).(string)
 		if !ok {
 			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
-			w.WriteHeader(http.StatusUnauthorized)
-			encoder.Encode(map[string]string{"Unauthorized": "User ID not found in context"})
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
 			return
 		}
 		userIDInt, err := strconv.Atoi(userID)
 		if err != nil {
 			log.Error("error when converting user ID to int", logger.Err(err))
-			w.WriteHeader(http.StatusInternalServerError)
-			encoder.Encode(map[string]string{"InternalError": "Failed to convert user ID"})
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
 			return
 		}
 
-		err = storage.DeleteNote(id, userIDInt)
+		permanent := false
+		if v := r.URL.Query().Get("permanent"); v != "" {
+			permanent, err = strconv.ParseBool(v)
+			if err != nil {
+				log.Error("error when parsing permanent flag", logger.Err(err))
+				problem.Write(w, r, problem.InvalidRequest, "permanent must be a boolean")
+				return
+			}
+		}
+
+		versions, err := ifMatchVersions(r.Header.Get("If-Match"))
 		if err != nil {
+			log.Error("invalid If-Match header", logger.Err(err))
+			problem.Write(w, r, problem.InvalidRequest, err.Error())
+			return
+		}
+
+		err = writeIfMatch(versions, func(version int) error {
+			if permanent {
+				return storage.PurgeNote(r.Context(), id, userIDInt, version)
+			}
+			return storage.DeleteNote(r.Context(), id, userIDInt, version)
+		})
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			if errors.Is(err, store.ErrNoteNotFound) {
+				log.Warn("note not found", logger.Err(err))
+				problem.Write(w, r, problem.NotFound, "Note not found")
+				return
+			}
+
+			if errors.Is(err, store.ErrVersionMismatch) {
+				log.Warn("note version mismatch", logger.Err(err))
+				problem.Write(w, r, problem.PreconditionFailed, "Note has been modified since it was read")
+				return
+			}
+
 			log.Error("error when deleting note", logger.Err(err))
-			w.WriteHeader(http.StatusInternalServerError)
-			encoder.Encode(map[string]string{"InternalError": "Failed to delete note"})
+			problem.Write(w, r, problem.Internal, "Failed to delete note")
 			return
 		}
 
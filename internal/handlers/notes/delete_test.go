@@ -0,0 +1,49 @@
+package notes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	store "notes-api/internal/storage"
+)
+
+func TestDeleteNoteMovesToTrash(t *testing.T) {
+	for _, target := range []string{"/notes/1", "/notes/1?permanent=false"} {
+		storage := NewMockNoteDeleter(t)
+		storage.EXPECT().DeleteNote(mock.Anything, 1, testUserID, 0).Return(nil).Once()
+
+		rec := serve("/notes/{id}", DeleteNoteHandler(discard, storage), http.MethodDelete, target, "")
+		assert.Equal(t, http.StatusNoContent, rec.Code, target)
+	}
+}
+
+func TestDeleteNotePermanently(t *testing.T) {
+	storage := NewMockNoteDeleter(t)
+	storage.EXPECT().PurgeNote(mock.Anything, 1, testUserID, 3).Return(nil).Once()
+
+	rec := serve("/notes/{id}", DeleteNoteHandler(discard, storage), http.MethodDelete, "/notes/1?permanent=true", "", "If-Match", `"3"`)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestDeleteNoteRejects(t *testing.T) {
+	rec := serve("/notes/{id}", DeleteNoteHandler(discard, NewMockNoteDeleter(t)), http.MethodDelete, "/notes/1?permanent=maybe", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "invalid_request", problemCode(t, rec))
+
+	storage := NewMockNoteDeleter(t)
+	storage.EXPECT().DeleteNote(mock.Anything, 1, testUserID, 2).Return(store.ErrVersionMismatch).Once()
+
+	rec = serve("/notes/{id}", DeleteNoteHandler(discard, storage), http.MethodDelete, "/notes/1", "", "If-Match", `"2"`)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	assert.Equal(t, "precondition_failed", problemCode(t, rec))
+
+	storage = NewMockNoteDeleter(t)
+	storage.EXPECT().PurgeNote(mock.Anything, 1, testUserID, 0).Return(store.ErrNoteNotFound).Once()
+
+	rec = serve("/notes/{id}", DeleteNoteHandler(discard, storage), http.MethodDelete, "/notes/1?permanent=true", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "not_found", problemCode(t, rec))
+}
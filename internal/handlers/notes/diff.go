@@ -0,0 +1,157 @@
+package notes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"notes-api/internal/diff"
+	"notes-api/internal/models"
+	"notes-api/internal/problem"
+	store "notes-api/internal/storage"
+	"notes-api/internal/utils"
+	"notes-api/pkg/logger"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const currentVersion = "current"
+
+type RevisionDiffer interface {
+	Note(ctx context.Context, id, userID int) (*models.Note, error)
+	NoteRevision(ctx context.Context, noteID, userID, revision int) (*models.Revision, error)
+}
+
+// DiffHandler diffs two versions of a note, each a revision number or "current".
+func DiffHandler(log *slog.Logger, storage RevisionDiffer) http.HandlerFunc {
+	type response struct {
+		From      string `json:"from"`
+		To        string `json:"to"`
+		FromTitle string `json:"from_title"`
+		ToTitle   string `json:"to_title"`
+		Diff      string `json:"diff"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Error("error when converting id to int", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidID, "ID must be an integer")
+			return
+		}
+
+		from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+		if to == "" {
+			to = currentVersion
+		}
+
+		if from == "" {
+			log.Error("missing from version", logger.Err(fmt.Errorf("from query parameter is required")))
+
+			problem.Write(w, r, problem.InvalidRequest, "from is required")
+			return
+		}
+
+		userID, ok := r.Context().Value(utils.UserIDKey).(string)
+		if !ok {
+			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
+
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
+			return
+		}
+
+		userIDInt, err := strconv.Atoi(userID)
+		if err != nil {
+			log.Error("error when converting user ID to int", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
+			return
+		}
+
+		var versions [2]models.Revision
+		for i, version := range []string{from, to} {
+			v, err := loadVersion(r.Context(), storage, id, userIDInt, version)
+			if err != nil {
+				if problem.WriteContextError(w, r, err) {
+					return
+				}
+
+				var numErr *strconv.NumError
+				switch {
+				case errors.As(err, &numErr):
+					log.Error("error when converting revision to int", logger.Err(err))
+
+					problem.Write(w, r, problem.InvalidRevision, "Revision must be an integer or current")
+				case errors.Is(err, store.ErrNoteNotFound):
+					log.Warn("note not found", logger.Err(err))
+
+					problem.Write(w, r, problem.NotFound, "Note not found")
+				case errors.Is(err, store.ErrRevisionNotFound):
+					log.Warn("revision not found", logger.Err(err))
+
+					problem.Write(w, r, problem.NotFound, fmt.Sprintf("Revision %s not found", version))
+				default:
+					log.Error("error when retrieving revision", logger.Err(err))
+
+					problem.Write(w, r, problem.Internal, "Failed to retrieve revision")
+				}
+				return
+			}
+
+			versions[i] = *v
+		}
+
+		patch, err := diff.Unified(
+			versionLabel(from), versionLabel(to),
+			versions[0].Content, versions[1].Content,
+			diff.DefaultContext,
+		)
+		if err != nil {
+			log.Warn("versions too far apart to diff", logger.Err(err))
+
+			problem.Write(w, r, problem.DiffTooLarge, fmt.Sprintf("The versions differ in more than %d lines", diff.MaxLines))
+			return
+		}
+
+		encoder.Encode(response{
+			From:      from,
+			To:        to,
+			FromTitle: versions[0].Title,
+			ToTitle:   versions[1].Title,
+			Diff:      patch,
+		})
+	}
+}
+
+func loadVersion(ctx context.Context, storage RevisionDiffer, id, userID int, version string) (*models.Revision, error) {
+	if version == currentVersion {
+		note, err := storage.Note(ctx, id, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &models.Revision{NoteID: note.ID, Title: note.Title, Content: note.Content, CreatedAt: note.UpdatedAt}, nil
+	}
+
+	rev, err := strconv.Atoi(version)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.NoteRevision(ctx, id, userID, rev)
+}
+
+func versionLabel(version string) string {
+	if version == currentVersion {
+		return currentVersion
+	}
+
+	return "revision " + version
+}
@@ -0,0 +1,58 @@
+package notes
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"notes-api/internal/diff"
+	"notes-api/internal/models"
+)
+
+func TestDiffHandler(t *testing.T) {
+	storage := NewMockRevisionDiffer(t)
+	storage.EXPECT().NoteRevision(mock.Anything, 1, testUserID, 2).Return(&models.Revision{Title: "old", Content: "one\ntwo\n"}, nil).Once()
+	storage.EXPECT().Note(mock.Anything, 1, testUserID).Return(&models.Note{Title: "new", Content: "one\n2\n"}, nil).Once()
+
+	rec := serve("/notes/{id}/diff", DiffHandler(discard, storage), http.MethodGet, "/notes/1/diff?from=2", "")
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{
+		"from": "2",
+		"to": "current",
+		"from_title": "old",
+		"to_title": "new",
+		"diff": "--- revision 2\n+++ current\n@@ -1,2 +1,2 @@\n one\n-two\n+2\n"
+	}`, rec.Body.String())
+}
+
+func TestDiffHandlerTooLarge(t *testing.T) {
+	content := func(format string) string {
+		var sb strings.Builder
+		for i := range diff.MaxLines {
+			fmt.Fprintf(&sb, format+"\n", i)
+		}
+		return sb.String()
+	}
+
+	storage := NewMockRevisionDiffer(t)
+	storage.EXPECT().NoteRevision(mock.Anything, 1, testUserID, 1).Return(&models.Revision{Content: content("old %d")}, nil).Once()
+	storage.EXPECT().NoteRevision(mock.Anything, 1, testUserID, 2).Return(&models.Revision{Content: content("new %d")}, nil).Once()
+
+	rec := serve("/notes/{id}/diff", DiffHandler(discard, storage), http.MethodGet, "/notes/1/diff?from=1&to=2", "")
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "diff_too_large", problemCode(t, rec))
+}
+
+func TestDiffHandlerRejects(t *testing.T) {
+	rec := serve("/notes/{id}/diff", DiffHandler(discard, NewMockRevisionDiffer(t)), http.MethodGet, "/notes/1/diff", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "invalid_request", problemCode(t, rec))
+
+	rec = serve("/notes/{id}/diff", DiffHandler(discard, NewMockRevisionDiffer(t)), http.MethodGet, "/notes/1/diff?from=first", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "invalid_revision", problemCode(t, rec))
+}
@@ -0,0 +1,100 @@
+package notes
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	store "notes-api/internal/storage"
+)
+
+// maxIfMatchTags bounds how many entity tags an If-Match header may list, as
+// every strong one can cost a conditional write.
+const maxIfMatchTags = 16
+
+var errTooManyETags = fmt.Errorf("If-Match must not list more than %d entity tags", maxIfMatchTags)
+
+// etag renders a note version as a strong entity tag.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersions turns an If-Match header into the versions a write is
+// conditional on. It returns nil when the header is absent or "*", and leaves
+// out tags that can never match one of ours, such as weak ones, so a header
+// listing only those yields an empty, non-nil slice.
+func ifMatchVersions(header string) ([]int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	tags := strings.Split(header, ",")
+	if len(tags) > maxIfMatchTags {
+		return nil, errTooManyETags
+	}
+
+	versions := []int{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+			continue
+		}
+
+		version, err := strconv.Atoi(tag[1 : len(tag)-1])
+		if err != nil || version < 1 || slices.Contains(versions, version) {
+			continue
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
+
+// writeIfMatch calls write with each version an If-Match header lists until
+// one is not a version mismatch, so the write succeeds if any of them is
+// current. Without a header, write is called once with 0; with only tags that
+// can never match, once with -1.
+func writeIfMatch(versions []int, write func(version int) error) error {
+	if versions == nil {
+		return write(0)
+	}
+
+	if len(versions) == 0 {
+		return write(-1)
+	}
+
+	var err error
+	for _, version := range versions {
+		if err = write(version); !errors.Is(err, store.ErrVersionMismatch) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// ifNoneMatch reports whether an If-None-Match header matches version, using
+// the weak comparison RFC 9110 prescribes for it.
+func ifNoneMatch(header string, version int) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+
+	if header == "*" {
+		return true
+	}
+
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == current {
+			return true
+		}
+	}
+
+	return false
+}
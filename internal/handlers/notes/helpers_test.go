@@ -0,0 +1,52 @@
+package notes
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"notes-api/internal/utils"
+)
+
+const testUserID = 7
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// serve routes a request to handler, mounted at pattern, as the test user
+// and returns the response.
+func serve(pattern string, handler http.HandlerFunc, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	router.Method(method, pattern, handler)
+
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	r = r.WithContext(context.WithValue(r.Context(), utils.UserIDKey, strconv.Itoa(testUserID)))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, r)
+
+	return rec
+}
+
+// problemCode returns the code of the problem document in rec.
+func problemCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body), rec.Body.String())
+
+	return body.Code
+}
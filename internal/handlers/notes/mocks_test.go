@@ -5,6 +5,7 @@
 package notes
 
 import (
+	"context"
 	"notes-api/internal/models"
 
 	mock "github.com/stretchr/testify/mock"
@@ -38,8 +39,8 @@ func (_m *MockNoteCreator) EXPECT() *MockNoteCreator_Expecter {
 }
 
 // CreateNote provides a mock function for the type MockNoteCreator
-func (_mock *MockNoteCreator) CreateNote(userID int, title string, content string) (int64, error) {
-	ret := _mock.Called(userID, title, content)
+func (_mock *MockNoteCreator) CreateNote(ctx context.Context, userID int, title string, content string, tags []string) (int64, error) {
+	ret := _mock.Called(ctx, userID, title, content, tags)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateNote")
@@ -47,16 +48,16 @@ func (_mock *MockNoteCreator) CreateNote(userID int, title string, content strin
 
 	var r0 int64
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(int, string, string) (int64, error)); ok {
-		return returnFunc(userID, title, content)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, string, string, []string) (int64, error)); ok {
+		return returnFunc(ctx, userID, title, content, tags)
 	}
-	if returnFunc, ok := ret.Get(0).(func(int, string, string) int64); ok {
-		r0 = returnFunc(userID, title, content)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, string, string, []string) int64); ok {
+		r0 = returnFunc(ctx, userID, title, content, tags)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
-	if returnFunc, ok := ret.Get(1).(func(int, string, string) error); ok {
-		r1 = returnFunc(userID, title, content)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int, string, string, []string) error); ok {
+		r1 = returnFunc(ctx, userID, title, content, tags)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -69,31 +70,43 @@ type MockNoteCreator_CreateNote_Call struct {
 }
 
 // CreateNote is a helper method to define mock.On call
+//   - ctx context.Context
 //   - userID int
 //   - title string
 //   - content string
-func (_e *MockNoteCreator_Expecter) CreateNote(userID interface{}, title interface{}, content interface{}) *MockNoteCreator_CreateNote_Call {
-	return &MockNoteCreator_CreateNote_Call{Call: _e.mock.On("CreateNote", userID, title, content)}
+//   - tags []string
+func (_e *MockNoteCreator_Expecter) CreateNote(ctx interface{}, userID interface{}, title interface{}, content interface{}, tags interface{}) *MockNoteCreator_CreateNote_Call {
+	return &MockNoteCreator_CreateNote_Call{Call: _e.mock.On("CreateNote", ctx, userID, title, content, tags)}
 }
 
-func (_c *MockNoteCreator_CreateNote_Call) Run(run func(userID int, title string, content string)) *MockNoteCreator_CreateNote_Call {
+func (_c *MockNoteCreator_CreateNote_Call) Run(run func(ctx context.Context, userID int, title string, content string, tags []string)) *MockNoteCreator_CreateNote_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 int
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(int)
+			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 int
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(int)
 		}
 		var arg2 string
 		if args[2] != nil {
 			arg2 = args[2].(string)
 		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 []string
+		if args[4] != nil {
+			arg4 = args[4].([]string)
+		}
 		run(
 			arg0,
 			arg1,
 			arg2,
+			arg3,
+			arg4,
 		)
 	})
 	return _c
@@ -104,7 +117,7 @@ func (_c *MockNoteCreator_CreateNote_Call) Return(n int64, err error) *MockNoteC
 	return _c
 }
 
-func (_c *MockNoteCreator_CreateNote_Call) RunAndReturn(run func(userID int, title string, content string) (int64, error)) *MockNoteCreator_CreateNote_Call {
+func (_c *MockNoteCreator_CreateNote_Call) RunAndReturn(run func(ctx context.Context, userID int, title string, content string, tags []string) (int64, error)) *MockNoteCreator_CreateNote_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -137,16 +150,16 @@ func (_m *MockNoteDeleter) EXPECT() *MockNoteDeleter_Expecter {
 }
 
 // DeleteNote provides a mock function for the type MockNoteDeleter
-func (_mock *MockNoteDeleter) DeleteNote(id int, userID int) error {
-	ret := _mock.Called(id, userID)
+func (_mock *MockNoteDeleter) DeleteNote(ctx context.Context, id int, userID int, version int) error {
+	ret := _mock.Called(ctx, id, userID, version)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteNote")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(int, int) error); ok {
-		r0 = returnFunc(id, userID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int, int) error); ok {
+		r0 = returnFunc(ctx, id, userID, version)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -159,25 +172,37 @@ type MockNoteDeleter_DeleteNote_Call struct {
 }
 
 // DeleteNote is a helper method to define mock.On call
+//   - ctx context.Context
 //   - id int
 //   - userID int
-func (_e *MockNoteDeleter_Expecter) DeleteNote(id interface{}, userID interface{}) *MockNoteDeleter_DeleteNote_Call {
-	return &MockNoteDeleter_DeleteNote_Call{Call: _e.mock.On("DeleteNote", id, userID)}
+//   - version int
+func (_e *MockNoteDeleter_Expecter) DeleteNote(ctx interface{}, id interface{}, userID interface{}, version interface{}) *MockNoteDeleter_DeleteNote_Call {
+	return &MockNoteDeleter_DeleteNote_Call{Call: _e.mock.On("DeleteNote", ctx, id, userID, version)}
 }
 
-func (_c *MockNoteDeleter_DeleteNote_Call) Run(run func(id int, userID int)) *MockNoteDeleter_DeleteNote_Call {
+func (_c *MockNoteDeleter_DeleteNote_Call) Run(run func(ctx context.Context, id int, userID int, version int)) *MockNoteDeleter_DeleteNote_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 int
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(int)
+			arg0 = args[0].(context.Context)
 		}
 		var arg1 int
 		if args[1] != nil {
 			arg1 = args[1].(int)
 		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
+			arg3,
 		)
 	})
 	return _c
@@ -188,7 +213,257 @@ func (_c *MockNoteDeleter_DeleteNote_Call) Return(err error) *MockNoteDeleter_De
 	return _c
 }
 
-func (_c *MockNoteDeleter_DeleteNote_Call) RunAndReturn(run func(id int, userID int) error) *MockNoteDeleter_DeleteNote_Call {
+func (_c *MockNoteDeleter_DeleteNote_Call) RunAndReturn(run func(ctx context.Context, id int, userID int, version int) error) *MockNoteDeleter_DeleteNote_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PurgeNote provides a mock function for the type MockNoteDeleter
+func (_mock *MockNoteDeleter) PurgeNote(ctx context.Context, id int, userID int, version int) error {
+	ret := _mock.Called(ctx, id, userID, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeNote")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int, int) error); ok {
+		r0 = returnFunc(ctx, id, userID, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockNoteDeleter_PurgeNote_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PurgeNote'
+type MockNoteDeleter_PurgeNote_Call struct {
+	*mock.Call
+}
+
+// PurgeNote is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int
+//   - userID int
+//   - version int
+func (_e *MockNoteDeleter_Expecter) PurgeNote(ctx interface{}, id interface{}, userID interface{}, version interface{}) *MockNoteDeleter_PurgeNote_Call {
+	return &MockNoteDeleter_PurgeNote_Call{Call: _e.mock.On("PurgeNote", ctx, id, userID, version)}
+}
+
+func (_c *MockNoteDeleter_PurgeNote_Call) Run(run func(ctx context.Context, id int, userID int, version int)) *MockNoteDeleter_PurgeNote_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockNoteDeleter_PurgeNote_Call) Return(err error) *MockNoteDeleter_PurgeNote_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockNoteDeleter_PurgeNote_Call) RunAndReturn(run func(ctx context.Context, id int, userID int, version int) error) *MockNoteDeleter_PurgeNote_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockRevisionDiffer creates a new instance of MockRevisionDiffer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockRevisionDiffer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockRevisionDiffer {
+	mock := &MockRevisionDiffer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockRevisionDiffer is an autogenerated mock type for the RevisionDiffer type
+type MockRevisionDiffer struct {
+	mock.Mock
+}
+
+type MockRevisionDiffer_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockRevisionDiffer) EXPECT() *MockRevisionDiffer_Expecter {
+	return &MockRevisionDiffer_Expecter{mock: &_m.Mock}
+}
+
+// Note provides a mock function for the type MockRevisionDiffer
+func (_mock *MockRevisionDiffer) Note(ctx context.Context, id int, userID int) (*models.Note, error) {
+	ret := _mock.Called(ctx, id, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Note")
+	}
+
+	var r0 *models.Note
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) (*models.Note, error)); ok {
+		return returnFunc(ctx, id, userID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) *models.Note); ok {
+		r0 = returnFunc(ctx, id, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Note)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = returnFunc(ctx, id, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockRevisionDiffer_Note_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Note'
+type MockRevisionDiffer_Note_Call struct {
+	*mock.Call
+}
+
+// Note is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int
+//   - userID int
+func (_e *MockRevisionDiffer_Expecter) Note(ctx interface{}, id interface{}, userID interface{}) *MockRevisionDiffer_Note_Call {
+	return &MockRevisionDiffer_Note_Call{Call: _e.mock.On("Note", ctx, id, userID)}
+}
+
+func (_c *MockRevisionDiffer_Note_Call) Run(run func(ctx context.Context, id int, userID int)) *MockRevisionDiffer_Note_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRevisionDiffer_Note_Call) Return(note *models.Note, err error) *MockRevisionDiffer_Note_Call {
+	_c.Call.Return(note, err)
+	return _c
+}
+
+func (_c *MockRevisionDiffer_Note_Call) RunAndReturn(run func(ctx context.Context, id int, userID int) (*models.Note, error)) *MockRevisionDiffer_Note_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NoteRevision provides a mock function for the type MockRevisionDiffer
+func (_mock *MockRevisionDiffer) NoteRevision(ctx context.Context, noteID int, userID int, revision int) (*models.Revision, error) {
+	ret := _mock.Called(ctx, noteID, userID, revision)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NoteRevision")
+	}
+
+	var r0 *models.Revision
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int, int) (*models.Revision, error)); ok {
+		return returnFunc(ctx, noteID, userID, revision)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int, int) *models.Revision); ok {
+		r0 = returnFunc(ctx, noteID, userID, revision)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Revision)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = returnFunc(ctx, noteID, userID, revision)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockRevisionDiffer_NoteRevision_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NoteRevision'
+type MockRevisionDiffer_NoteRevision_Call struct {
+	*mock.Call
+}
+
+// NoteRevision is a helper method to define mock.On call
+//   - ctx context.Context
+//   - noteID int
+//   - userID int
+//   - revision int
+func (_e *MockRevisionDiffer_Expecter) NoteRevision(ctx interface{}, noteID interface{}, userID interface{}, revision interface{}) *MockRevisionDiffer_NoteRevision_Call {
+	return &MockRevisionDiffer_NoteRevision_Call{Call: _e.mock.On("NoteRevision", ctx, noteID, userID, revision)}
+}
+
+func (_c *MockRevisionDiffer_NoteRevision_Call) Run(run func(ctx context.Context, noteID int, userID int, revision int)) *MockRevisionDiffer_NoteRevision_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRevisionDiffer_NoteRevision_Call) Return(revision *models.Revision, err error) *MockRevisionDiffer_NoteRevision_Call {
+	_c.Call.Return(revision, err)
+	return _c
+}
+
+func (_c *MockRevisionDiffer_NoteRevision_Call) RunAndReturn(run func(ctx context.Context, noteID int, userID int, revision int) (*models.Revision, error)) *MockRevisionDiffer_NoteRevision_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -221,8 +496,8 @@ func (_m *MockNoteProvider) EXPECT() *MockNoteProvider_Expecter {
 }
 
 // Note provides a mock function for the type MockNoteProvider
-func (_mock *MockNoteProvider) Note(id int, userID int) (*models.Note, error) {
-	ret := _mock.Called(id, userID)
+func (_mock *MockNoteProvider) Note(ctx context.Context, id int, userID int) (*models.Note, error) {
+	ret := _mock.Called(ctx, id, userID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Note")
@@ -230,18 +505,18 @@ func (_mock *MockNoteProvider) Note(id int, userID int) (*models.Note, error) {
 
 	var r0 *models.Note
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(int, int) (*models.Note, error)); ok {
-		return returnFunc(id, userID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) (*models.Note, error)); ok {
+		return returnFunc(ctx, id, userID)
 	}
-	if returnFunc, ok := ret.Get(0).(func(int, int) *models.Note); ok {
-		r0 = returnFunc(id, userID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) *models.Note); ok {
+		r0 = returnFunc(ctx, id, userID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Note)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(int, int) error); ok {
-		r1 = returnFunc(id, userID)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = returnFunc(ctx, id, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -254,25 +529,31 @@ type MockNoteProvider_Note_Call struct {
 }
 
 // Note is a helper method to define mock.On call
+//   - ctx context.Context
 //   - id int
 //   - userID int
-func (_e *MockNoteProvider_Expecter) Note(id interface{}, userID interface{}) *MockNoteProvider_Note_Call {
-	return &MockNoteProvider_Note_Call{Call: _e.mock.On("Note", id, userID)}
+func (_e *MockNoteProvider_Expecter) Note(ctx interface{}, id interface{}, userID interface{}) *MockNoteProvider_Note_Call {
+	return &MockNoteProvider_Note_Call{Call: _e.mock.On("Note", ctx, id, userID)}
 }
 
-func (_c *MockNoteProvider_Note_Call) Run(run func(id int, userID int)) *MockNoteProvider_Note_Call {
+func (_c *MockNoteProvider_Note_Call) Run(run func(ctx context.Context, id int, userID int)) *MockNoteProvider_Note_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 int
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(int)
+			arg0 = args[0].(context.Context)
 		}
 		var arg1 int
 		if args[1] != nil {
 			arg1 = args[1].(int)
 		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
@@ -283,7 +564,7 @@ func (_c *MockNoteProvider_Note_Call) Return(note *models.Note, err error) *Mock
 	return _c
 }
 
-func (_c *MockNoteProvider_Note_Call) RunAndReturn(run func(id int, userID int) (*models.Note, error)) *MockNoteProvider_Note_Call {
+func (_c *MockNoteProvider_Note_Call) RunAndReturn(run func(ctx context.Context, id int, userID int) (*models.Note, error)) *MockNoteProvider_Note_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -316,27 +597,27 @@ func (_m *MockNotesProvider) EXPECT() *MockNotesProvider_Expecter {
 }
 
 // Notes provides a mock function for the type MockNotesProvider
-func (_mock *MockNotesProvider) Notes(userID int) ([]models.Note, error) {
-	ret := _mock.Called(userID)
+func (_mock *MockNotesProvider) Notes(ctx context.Context, userID int, query models.NotesQuery) (*models.NotesPage, error) {
+	ret := _mock.Called(ctx, userID, query)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Notes")
 	}
 
-	var r0 []models.Note
+	var r0 *models.NotesPage
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(int) ([]models.Note, error)); ok {
-		return returnFunc(userID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, models.NotesQuery) (*models.NotesPage, error)); ok {
+		return returnFunc(ctx, userID, query)
 	}
-	if returnFunc, ok := ret.Get(0).(func(int) []models.Note); ok {
-		r0 = returnFunc(userID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, models.NotesQuery) *models.NotesPage); ok {
+		r0 = returnFunc(ctx, userID, query)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]models.Note)
+			r0 = ret.Get(0).(*models.NotesPage)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(int) error); ok {
-		r1 = returnFunc(userID)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int, models.NotesQuery) error); ok {
+		r1 = returnFunc(ctx, userID, query)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -349,41 +630,53 @@ type MockNotesProvider_Notes_Call struct {
 }
 
 // Notes is a helper method to define mock.On call
+//   - ctx context.Context
 //   - userID int
-func (_e *MockNotesProvider_Expecter) Notes(userID interface{}) *MockNotesProvider_Notes_Call {
-	return &MockNotesProvider_Notes_Call{Call: _e.mock.On("Notes", userID)}
+//   - query models.NotesQuery
+func (_e *MockNotesProvider_Expecter) Notes(ctx interface{}, userID interface{}, query interface{}) *MockNotesProvider_Notes_Call {
+	return &MockNotesProvider_Notes_Call{Call: _e.mock.On("Notes", ctx, userID, query)}
 }
 
-func (_c *MockNotesProvider_Notes_Call) Run(run func(userID int)) *MockNotesProvider_Notes_Call {
+func (_c *MockNotesProvider_Notes_Call) Run(run func(ctx context.Context, userID int, query models.NotesQuery)) *MockNotesProvider_Notes_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 int
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(int)
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		var arg2 models.NotesQuery
+		if args[2] != nil {
+			arg2 = args[2].(models.NotesQuery)
 		}
 		run(
 			arg0,
+			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *MockNotesProvider_Notes_Call) Return(notes []models.Note, err error) *MockNotesProvider_Notes_Call {
-	_c.Call.Return(notes, err)
+func (_c *MockNotesProvider_Notes_Call) Return(notesPage *models.NotesPage, err error) *MockNotesProvider_Notes_Call {
+	_c.Call.Return(notesPage, err)
 	return _c
 }
 
-func (_c *MockNotesProvider_Notes_Call) RunAndReturn(run func(userID int) ([]models.Note, error)) *MockNotesProvider_Notes_Call {
+func (_c *MockNotesProvider_Notes_Call) RunAndReturn(run func(ctx context.Context, userID int, query models.NotesQuery) (*models.NotesPage, error)) *MockNotesProvider_Notes_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// NewMockNoteUpdater creates a new instance of MockNoteUpdater. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// NewMockNotePatcher creates a new instance of MockNotePatcher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
-func NewMockNoteUpdater(t interface {
+func NewMockNotePatcher(t interface {
 	mock.TestingT
 	Cleanup(func())
-}) *MockNoteUpdater {
-	mock := &MockNoteUpdater{}
+}) *MockNotePatcher {
+	mock := &MockNotePatcher{}
 	mock.Mock.Test(t)
 
 	t.Cleanup(func() { mock.AssertExpectations(t) })
@@ -391,84 +684,793 @@ func NewMockNoteUpdater(t interface {
 	return mock
 }
 
-// MockNoteUpdater is an autogenerated mock type for the NoteUpdater type
-type MockNoteUpdater struct {
+// MockNotePatcher is an autogenerated mock type for the NotePatcher type
+type MockNotePatcher struct {
 	mock.Mock
 }
 
-type MockNoteUpdater_Expecter struct {
+type MockNotePatcher_Expecter struct {
 	mock *mock.Mock
 }
 
-func (_m *MockNoteUpdater) EXPECT() *MockNoteUpdater_Expecter {
-	return &MockNoteUpdater_Expecter{mock: &_m.Mock}
+func (_m *MockNotePatcher) EXPECT() *MockNotePatcher_Expecter {
+	return &MockNotePatcher_Expecter{mock: &_m.Mock}
 }
 
-// UpdateNote provides a mock function for the type MockNoteUpdater
-func (_mock *MockNoteUpdater) UpdateNote(id int, userID int, title string, content string) error {
-	ret := _mock.Called(id, userID, title, content)
+// Note provides a mock function for the type MockNotePatcher
+func (_mock *MockNotePatcher) Note(ctx context.Context, id int, userID int) (*models.Note, error) {
+	ret := _mock.Called(ctx, id, userID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateNote")
+		panic("no return value specified for Note")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(int, int, string, string) error); ok {
-		r0 = returnFunc(id, userID, title, content)
+	var r0 *models.Note
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) (*models.Note, error)); ok {
+		return returnFunc(ctx, id, userID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) *models.Note); ok {
+		r0 = returnFunc(ctx, id, userID)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Note)
+		}
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = returnFunc(ctx, id, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockNoteUpdater_UpdateNote_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateNote'
-type MockNoteUpdater_UpdateNote_Call struct {
+// MockNotePatcher_Note_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Note'
+type MockNotePatcher_Note_Call struct {
 	*mock.Call
 }
 
-// UpdateNote is a helper method to define mock.On call
+// Note is a helper method to define mock.On call
+//   - ctx context.Context
 //   - id int
 //   - userID int
-//   - title string
-//   - content string
-func (_e *MockNoteUpdater_Expecter) UpdateNote(id interface{}, userID interface{}, title interface{}, content interface{}) *MockNoteUpdater_UpdateNote_Call {
-	return &MockNoteUpdater_UpdateNote_Call{Call: _e.mock.On("UpdateNote", id, userID, title, content)}
+func (_e *MockNotePatcher_Expecter) Note(ctx interface{}, id interface{}, userID interface{}) *MockNotePatcher_Note_Call {
+	return &MockNotePatcher_Note_Call{Call: _e.mock.On("Note", ctx, id, userID)}
 }
 
-func (_c *MockNoteUpdater_UpdateNote_Call) Run(run func(id int, userID int, title string, content string)) *MockNoteUpdater_UpdateNote_Call {
+func (_c *MockNotePatcher_Note_Call) Run(run func(ctx context.Context, id int, userID int)) *MockNotePatcher_Note_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 int
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(int)
+			arg0 = args[0].(context.Context)
 		}
 		var arg1 int
 		if args[1] != nil {
 			arg1 = args[1].(int)
 		}
-		var arg2 string
+		var arg2 int
 		if args[2] != nil {
-			arg2 = args[2].(string)
-		}
-		var arg3 string
-		if args[3] != nil {
-			arg3 = args[3].(string)
+			arg2 = args[2].(int)
 		}
 		run(
 			arg0,
 			arg1,
 			arg2,
-			arg3,
 		)
 	})
 	return _c
 }
 
-func (_c *MockNoteUpdater_UpdateNote_Call) Return(err error) *MockNoteUpdater_UpdateNote_Call {
-	_c.Call.Return(err)
+func (_c *MockNotePatcher_Note_Call) Return(note *models.Note, err error) *MockNotePatcher_Note_Call {
+	_c.Call.Return(note, err)
+	return _c
+}
+
+func (_c *MockNotePatcher_Note_Call) RunAndReturn(run func(ctx context.Context, id int, userID int) (*models.Note, error)) *MockNotePatcher_Note_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PatchNote provides a mock function for the type MockNotePatcher
+func (_mock *MockNotePatcher) PatchNote(ctx context.Context, id int, userID int, patch models.NotePatch, version int) (*models.Note, error) {
+	ret := _mock.Called(ctx, id, userID, patch, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PatchNote")
+	}
+
+	var r0 *models.Note
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int, models.NotePatch, int) (*models.Note, error)); ok {
+		return returnFunc(ctx, id, userID, patch, version)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int, models.NotePatch, int) *models.Note); ok {
+		r0 = returnFunc(ctx, id, userID, patch, version)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Note)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int, int, models.NotePatch, int) error); ok {
+		r1 = returnFunc(ctx, id, userID, patch, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockNotePatcher_PatchNote_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PatchNote'
+type MockNotePatcher_PatchNote_Call struct {
+	*mock.Call
+}
+
+// PatchNote is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int
+//   - userID int
+//   - patch models.NotePatch
+//   - version int
+func (_e *MockNotePatcher_Expecter) PatchNote(ctx interface{}, id interface{}, userID interface{}, patch interface{}, version interface{}) *MockNotePatcher_PatchNote_Call {
+	return &MockNotePatcher_PatchNote_Call{Call: _e.mock.On("PatchNote", ctx, id, userID, patch, version)}
+}
+
+func (_c *MockNotePatcher_PatchNote_Call) Run(run func(ctx context.Context, id int, userID int, patch models.NotePatch, version int)) *MockNotePatcher_PatchNote_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 models.NotePatch
+		if args[3] != nil {
+			arg3 = args[3].(models.NotePatch)
+		}
+		var arg4 int
+		if args[4] != nil {
+			arg4 = args[4].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *MockNotePatcher_PatchNote_Call) Return(note *models.Note, err error) *MockNotePatcher_PatchNote_Call {
+	_c.Call.Return(note, err)
+	return _c
+}
+
+func (_c *MockNotePatcher_PatchNote_Call) RunAndReturn(run func(ctx context.Context, id int, userID int, patch models.NotePatch, version int) (*models.Note, error)) *MockNotePatcher_PatchNote_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockNoteRestorer creates a new instance of MockNoteRestorer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockNoteRestorer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockNoteRestorer {
+	mock := &MockNoteRestorer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockNoteRestorer is an autogenerated mock type for the NoteRestorer type
+type MockNoteRestorer struct {
+	mock.Mock
+}
+
+type MockNoteRestorer_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockNoteRestorer) EXPECT() *MockNoteRestorer_Expecter {
+	return &MockNoteRestorer_Expecter{mock: &_m.Mock}
+}
+
+// RestoreNote provides a mock function for the type MockNoteRestorer
+func (_mock *MockNoteRestorer) RestoreNote(ctx context.Context, id int, userID int) error {
+	ret := _mock.Called(ctx, id, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreNote")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = returnFunc(ctx, id, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockNoteRestorer_RestoreNote_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RestoreNote'
+type MockNoteRestorer_RestoreNote_Call struct {
+	*mock.Call
+}
+
+// RestoreNote is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int
+//   - userID int
+func (_e *MockNoteRestorer_Expecter) RestoreNote(ctx interface{}, id interface{}, userID interface{}) *MockNoteRestorer_RestoreNote_Call {
+	return &MockNoteRestorer_RestoreNote_Call{Call: _e.mock.On("RestoreNote", ctx, id, userID)}
+}
+
+func (_c *MockNoteRestorer_RestoreNote_Call) Run(run func(ctx context.Context, id int, userID int)) *MockNoteRestorer_RestoreNote_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockNoteRestorer_RestoreNote_Call) Return(err error) *MockNoteRestorer_RestoreNote_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockNoteRestorer_RestoreNote_Call) RunAndReturn(run func(ctx context.Context, id int, userID int) error) *MockNoteRestorer_RestoreNote_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockRevisionRestorer creates a new instance of MockRevisionRestorer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockRevisionRestorer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockRevisionRestorer {
+	mock := &MockRevisionRestorer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockRevisionRestorer is an autogenerated mock type for the RevisionRestorer type
+type MockRevisionRestorer struct {
+	mock.Mock
+}
+
+type MockRevisionRestorer_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockRevisionRestorer) EXPECT() *MockRevisionRestorer_Expecter {
+	return &MockRevisionRestorer_Expecter{mock: &_m.Mock}
+}
+
+// RestoreRevision provides a mock function for the type MockRevisionRestorer
+func (_mock *MockRevisionRestorer) RestoreRevision(ctx context.Context, noteID int, userID int, revision int) error {
+	ret := _mock.Called(ctx, noteID, userID, revision)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreRevision")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int, int) error); ok {
+		r0 = returnFunc(ctx, noteID, userID, revision)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockRevisionRestorer_RestoreRevision_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RestoreRevision'
+type MockRevisionRestorer_RestoreRevision_Call struct {
+	*mock.Call
+}
+
+// RestoreRevision is a helper method to define mock.On call
+//   - ctx context.Context
+//   - noteID int
+//   - userID int
+//   - revision int
+func (_e *MockRevisionRestorer_Expecter) RestoreRevision(ctx interface{}, noteID interface{}, userID interface{}, revision interface{}) *MockRevisionRestorer_RestoreRevision_Call {
+	return &MockRevisionRestorer_RestoreRevision_Call{Call: _e.mock.On("RestoreRevision", ctx, noteID, userID, revision)}
+}
+
+func (_c *MockRevisionRestorer_RestoreRevision_Call) Run(run func(ctx context.Context, noteID int, userID int, revision int)) *MockRevisionRestorer_RestoreRevision_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRevisionRestorer_RestoreRevision_Call) Return(err error) *MockRevisionRestorer_RestoreRevision_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockRevisionRestorer_RestoreRevision_Call) RunAndReturn(run func(ctx context.Context, noteID int, userID int, revision int) error) *MockRevisionRestorer_RestoreRevision_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockRevisionsProvider creates a new instance of MockRevisionsProvider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockRevisionsProvider(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockRevisionsProvider {
+	mock := &MockRevisionsProvider{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockRevisionsProvider is an autogenerated mock type for the RevisionsProvider type
+type MockRevisionsProvider struct {
+	mock.Mock
+}
+
+type MockRevisionsProvider_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockRevisionsProvider) EXPECT() *MockRevisionsProvider_Expecter {
+	return &MockRevisionsProvider_Expecter{mock: &_m.Mock}
+}
+
+// NoteRevisions provides a mock function for the type MockRevisionsProvider
+func (_mock *MockRevisionsProvider) NoteRevisions(ctx context.Context, noteID int, userID int) ([]models.Revision, error) {
+	ret := _mock.Called(ctx, noteID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NoteRevisions")
+	}
+
+	var r0 []models.Revision
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) ([]models.Revision, error)); ok {
+		return returnFunc(ctx, noteID, userID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) []models.Revision); ok {
+		r0 = returnFunc(ctx, noteID, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Revision)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = returnFunc(ctx, noteID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockRevisionsProvider_NoteRevisions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NoteRevisions'
+type MockRevisionsProvider_NoteRevisions_Call struct {
+	*mock.Call
+}
+
+// NoteRevisions is a helper method to define mock.On call
+//   - ctx context.Context
+//   - noteID int
+//   - userID int
+func (_e *MockRevisionsProvider_Expecter) NoteRevisions(ctx interface{}, noteID interface{}, userID interface{}) *MockRevisionsProvider_NoteRevisions_Call {
+	return &MockRevisionsProvider_NoteRevisions_Call{Call: _e.mock.On("NoteRevisions", ctx, noteID, userID)}
+}
+
+func (_c *MockRevisionsProvider_NoteRevisions_Call) Run(run func(ctx context.Context, noteID int, userID int)) *MockRevisionsProvider_NoteRevisions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRevisionsProvider_NoteRevisions_Call) Return(revisions []models.Revision, err error) *MockRevisionsProvider_NoteRevisions_Call {
+	_c.Call.Return(revisions, err)
+	return _c
+}
+
+func (_c *MockRevisionsProvider_NoteRevisions_Call) RunAndReturn(run func(ctx context.Context, noteID int, userID int) ([]models.Revision, error)) *MockRevisionsProvider_NoteRevisions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockRevisionProvider creates a new instance of MockRevisionProvider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockRevisionProvider(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockRevisionProvider {
+	mock := &MockRevisionProvider{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockRevisionProvider is an autogenerated mock type for the RevisionProvider type
+type MockRevisionProvider struct {
+	mock.Mock
+}
+
+type MockRevisionProvider_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockRevisionProvider) EXPECT() *MockRevisionProvider_Expecter {
+	return &MockRevisionProvider_Expecter{mock: &_m.Mock}
+}
+
+// NoteRevision provides a mock function for the type MockRevisionProvider
+func (_mock *MockRevisionProvider) NoteRevision(ctx context.Context, noteID int, userID int, revision int) (*models.Revision, error) {
+	ret := _mock.Called(ctx, noteID, userID, revision)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NoteRevision")
+	}
+
+	var r0 *models.Revision
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int, int) (*models.Revision, error)); ok {
+		return returnFunc(ctx, noteID, userID, revision)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int, int) *models.Revision); ok {
+		r0 = returnFunc(ctx, noteID, userID, revision)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Revision)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = returnFunc(ctx, noteID, userID, revision)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockRevisionProvider_NoteRevision_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NoteRevision'
+type MockRevisionProvider_NoteRevision_Call struct {
+	*mock.Call
+}
+
+// NoteRevision is a helper method to define mock.On call
+//   - ctx context.Context
+//   - noteID int
+//   - userID int
+//   - revision int
+func (_e *MockRevisionProvider_Expecter) NoteRevision(ctx interface{}, noteID interface{}, userID interface{}, revision interface{}) *MockRevisionProvider_NoteRevision_Call {
+	return &MockRevisionProvider_NoteRevision_Call{Call: _e.mock.On("NoteRevision", ctx, noteID, userID, revision)}
+}
+
+func (_c *MockRevisionProvider_NoteRevision_Call) Run(run func(ctx context.Context, noteID int, userID int, revision int)) *MockRevisionProvider_NoteRevision_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRevisionProvider_NoteRevision_Call) Return(revision *models.Revision, err error) *MockRevisionProvider_NoteRevision_Call {
+	_c.Call.Return(revision, err)
+	return _c
+}
+
+func (_c *MockRevisionProvider_NoteRevision_Call) RunAndReturn(run func(ctx context.Context, noteID int, userID int, revision int) (*models.Revision, error)) *MockRevisionProvider_NoteRevision_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockNoteSearcher creates a new instance of MockNoteSearcher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockNoteSearcher(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockNoteSearcher {
+	mock := &MockNoteSearcher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockNoteSearcher is an autogenerated mock type for the NoteSearcher type
+type MockNoteSearcher struct {
+	mock.Mock
+}
+
+type MockNoteSearcher_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockNoteSearcher) EXPECT() *MockNoteSearcher_Expecter {
+	return &MockNoteSearcher_Expecter{mock: &_m.Mock}
+}
+
+// SearchNotes provides a mock function for the type MockNoteSearcher
+func (_mock *MockNoteSearcher) SearchNotes(ctx context.Context, userID int, query models.SearchQuery) ([]models.SearchResult, error) {
+	ret := _mock.Called(ctx, userID, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchNotes")
+	}
+
+	var r0 []models.SearchResult
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, models.SearchQuery) ([]models.SearchResult, error)); ok {
+		return returnFunc(ctx, userID, query)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, models.SearchQuery) []models.SearchResult); ok {
+		r0 = returnFunc(ctx, userID, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.SearchResult)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int, models.SearchQuery) error); ok {
+		r1 = returnFunc(ctx, userID, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockNoteSearcher_SearchNotes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SearchNotes'
+type MockNoteSearcher_SearchNotes_Call struct {
+	*mock.Call
+}
+
+// SearchNotes is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID int
+//   - query models.SearchQuery
+func (_e *MockNoteSearcher_Expecter) SearchNotes(ctx interface{}, userID interface{}, query interface{}) *MockNoteSearcher_SearchNotes_Call {
+	return &MockNoteSearcher_SearchNotes_Call{Call: _e.mock.On("SearchNotes", ctx, userID, query)}
+}
+
+func (_c *MockNoteSearcher_SearchNotes_Call) Run(run func(ctx context.Context, userID int, query models.SearchQuery)) *MockNoteSearcher_SearchNotes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		var arg2 models.SearchQuery
+		if args[2] != nil {
+			arg2 = args[2].(models.SearchQuery)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockNoteSearcher_SearchNotes_Call) Return(searchResults []models.SearchResult, err error) *MockNoteSearcher_SearchNotes_Call {
+	_c.Call.Return(searchResults, err)
+	return _c
+}
+
+func (_c *MockNoteSearcher_SearchNotes_Call) RunAndReturn(run func(ctx context.Context, userID int, query models.SearchQuery) ([]models.SearchResult, error)) *MockNoteSearcher_SearchNotes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockNoteUpdater creates a new instance of MockNoteUpdater. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockNoteUpdater(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockNoteUpdater {
+	mock := &MockNoteUpdater{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockNoteUpdater is an autogenerated mock type for the NoteUpdater type
+type MockNoteUpdater struct {
+	mock.Mock
+}
+
+type MockNoteUpdater_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockNoteUpdater) EXPECT() *MockNoteUpdater_Expecter {
+	return &MockNoteUpdater_Expecter{mock: &_m.Mock}
+}
+
+// UpdateNote provides a mock function for the type MockNoteUpdater
+func (_mock *MockNoteUpdater) UpdateNote(ctx context.Context, id int, userID int, title string, content string, tags []string, version int) (int, error) {
+	ret := _mock.Called(ctx, id, userID, title, content, tags, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateNote")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int, string, string, []string, int) (int, error)); ok {
+		return returnFunc(ctx, id, userID, title, content, tags, version)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int, string, string, []string, int) int); ok {
+		r0 = returnFunc(ctx, id, userID, title, content, tags, version)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int, int, string, string, []string, int) error); ok {
+		r1 = returnFunc(ctx, id, userID, title, content, tags, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockNoteUpdater_UpdateNote_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateNote'
+type MockNoteUpdater_UpdateNote_Call struct {
+	*mock.Call
+}
+
+// UpdateNote is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int
+//   - userID int
+//   - title string
+//   - content string
+//   - tags []string
+//   - version int
+func (_e *MockNoteUpdater_Expecter) UpdateNote(ctx interface{}, id interface{}, userID interface{}, title interface{}, content interface{}, tags interface{}, version interface{}) *MockNoteUpdater_UpdateNote_Call {
+	return &MockNoteUpdater_UpdateNote_Call{Call: _e.mock.On("UpdateNote", ctx, id, userID, title, content, tags, version)}
+}
+
+func (_c *MockNoteUpdater_UpdateNote_Call) Run(run func(ctx context.Context, id int, userID int, title string, content string, tags []string, version int)) *MockNoteUpdater_UpdateNote_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		var arg2 int
+		if args[2] != nil {
+			arg2 = args[2].(int)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 string
+		if args[4] != nil {
+			arg4 = args[4].(string)
+		}
+		var arg5 []string
+		if args[5] != nil {
+			arg5 = args[5].([]string)
+		}
+		var arg6 int
+		if args[6] != nil {
+			arg6 = args[6].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+			arg5,
+			arg6,
+		)
+	})
+	return _c
+}
+
+func (_c *MockNoteUpdater_UpdateNote_Call) Return(n int, err error) *MockNoteUpdater_UpdateNote_Call {
+	_c.Call.Return(n, err)
 	return _c
 }
 
-func (_c *MockNoteUpdater_UpdateNote_Call) RunAndReturn(run func(id int, userID int, title string, content string) error) *MockNoteUpdater_UpdateNote_Call {
+func (_c *MockNoteUpdater_UpdateNote_Call) RunAndReturn(run func(ctx context.Context, id int, userID int, title string, content string, tags []string, version int) (int, error)) *MockNoteUpdater_UpdateNote_Call {
 	_c.Call.Return(run)
 	return _c
 }
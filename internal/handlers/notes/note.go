@@ -1,12 +1,14 @@
 package notes
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"notes-api/internal/models"
+	"notes-api/internal/problem"
 	store "notes-api/internal/storage"
 	"notes-api/internal/utils"
 	"notes-api/pkg/logger"
@@ -16,7 +18,7 @@ import (
 )
 
 type NoteProvider interface {
-	Note(id, userID int) (*models.Note, error)
+	Note(ctx context.Context, id, userID int) (*models.Note, error)
 }
 
 func NoteHandler(log *slog.Logger, storage NoteProvider) http.HandlerFunc {
@@ -28,8 +30,7 @@ func NoteHandler(log *slog.Logger, storage NoteProvider) http.HandlerFunc {
 		if err != nil {
 			log.Error("error when converting id to int", logger.Err(err))
 
-			w.WriteHeader(http.StatusBadRequest)
-			encoder.Encode(map[string]string{"InvalidID": "ID must be an integer"})
+			problem.Write(w, r, problem.InvalidID, "ID must be an integer")
 			return
 		}
 
@@ -37,8 +38,7 @@ func NoteHandler(log *slog.Logger, storage NoteProvider) http.HandlerFunc {
 		if !ok {
 			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
 
-			w.WriteHeader(http.StatusUnauthorized)
-			encoder.Encode(map[string]string{"Unauthorized": "User ID not found in context"})
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
 			return
 		}
 
@@ -46,25 +46,34 @@ func NoteHandler(log *slog.Logger, storage NoteProvider) http.HandlerFunc {
 		if err != nil {
 			log.Error("error when converting user ID to int", logger.Err(err))
 
-			w.WriteHeader(http.StatusInternalServerError)
-			encoder.Encode(map[string]string{"InternalError": "Failed to convert user ID"})
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
 			return
 		}
 
-		note, err := storage.Note(id, userIDInt)
+		note, err := storage.Note(r.Context(), id, userIDInt)
 		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
 			if errors.Is(err, store.ErrNoteNotFound) {
 				log.Warn("note not found", logger.Err(err))
 
-				w.WriteHeader(http.StatusNotFound)
-				encoder.Encode(map[string]string{"NotFound": "Note not found"})
+				problem.Write(w, r, problem.NotFound, "Note not found")
 				return
 			}
 
 			log.Error("error when retrieving note", logger.Err(err))
 
-			w.WriteHeader(http.StatusInternalServerError)
-			encoder.Encode(map[string]string{"InternalError": "Failed to retrieve note"})
+			problem.Write(w, r, problem.Internal, "Failed to retrieve note")
+			return
+		}
+
+		w.Header().Set("ETag", etag(note.Version))
+
+		if ifNoneMatch(r.Header.Get("If-None-Match"), note.Version) {
+			w.Header().Del("Content-Type")
+			w.WriteHeader(http.StatusNotModified)
 			return
 		}
 
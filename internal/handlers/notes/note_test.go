@@ -0,0 +1,53 @@
+package notes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"notes-api/internal/models"
+	store "notes-api/internal/storage"
+)
+
+func testNote(version int, title string) *models.Note {
+	return &models.Note{ID: 1, UserID: testUserID, Title: title, Content: "content", Version: version, Tags: []string{}}
+}
+
+func TestNoteHandlerETag(t *testing.T) {
+	for _, tt := range []struct {
+		ifNoneMatch string
+		code        int
+	}{
+		{"", http.StatusOK},
+		{`"2"`, http.StatusOK},
+		{`"3"`, http.StatusNotModified},
+		{`W/"3"`, http.StatusNotModified},
+		{`"1", "3"`, http.StatusNotModified},
+		{"*", http.StatusNotModified},
+	} {
+		storage := NewMockNoteProvider(t)
+		storage.EXPECT().Note(mock.Anything, 1, testUserID).Return(testNote(3, "title"), nil).Once()
+
+		rec := serve("/notes/{id}", NoteHandler(discard, storage), http.MethodGet, "/notes/1", "", "If-None-Match", tt.ifNoneMatch)
+		assert.Equal(t, tt.code, rec.Code, tt.ifNoneMatch)
+		assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+
+		if tt.code == http.StatusNotModified {
+			assert.Empty(t, rec.Body.String())
+		}
+	}
+}
+
+func TestNoteHandlerNotFound(t *testing.T) {
+	storage := NewMockNoteProvider(t)
+	storage.EXPECT().Note(mock.Anything, 1, testUserID).Return(nil, store.ErrNoteNotFound).Once()
+
+	rec := serve("/notes/{id}", NoteHandler(discard, storage), http.MethodGet, "/notes/1", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serve("/notes/{id}", NoteHandler(discard, NewMockNoteProvider(t)), http.MethodGet, "/notes/one", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "invalid_id", problemCode(t, rec))
+}
@@ -1,14 +1,18 @@
 package notes
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"notes-api/internal/models"
 	"notes-api/internal/utils"
 	"strconv"
+	"time"
 
+	"notes-api/internal/problem"
 	"notes-api/pkg/logger"
 
 	"errors"
@@ -16,10 +20,19 @@ import (
 )
 
 type NotesProvider interface {
-	Notes(userID int) ([]models.Note, error)
+	Notes(ctx context.Context, userID int, query models.NotesQuery) (*models.NotesPage, error)
 }
 
 func NotesHandler(log *slog.Logger, storage NotesProvider) http.HandlerFunc {
+	return listNotes(log, storage, false)
+}
+
+// TrashHandler lists trashed notes, most recently deleted first by default.
+func TrashHandler(log *slog.Logger, storage NotesProvider) http.HandlerFunc {
+	return listNotes(log, storage, true)
+}
+
+func listNotes(log *slog.Logger, storage NotesProvider, trashed bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		encoder := json.NewEncoder(w)
@@ -28,8 +41,7 @@ func NotesHandler(log *slog.Logger, storage NotesProvider) http.HandlerFunc {
 		if !ok {
 			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
 
-			w.WriteHeader(http.StatusUnauthorized)
-			encoder.Encode(map[string]string{"Unauthorized": "User ID not found in context"})
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
 			return
 		}
 
@@ -37,27 +49,105 @@ func NotesHandler(log *slog.Logger, storage NotesProvider) http.HandlerFunc {
 		if err != nil {
 			log.Error("error when converting user ID to int", logger.Err(err))
 
-			w.WriteHeader(http.StatusInternalServerError)
-			encoder.Encode(map[string]string{"InternalError": "Failed to convert user ID"})
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
 			return
 		}
 
-		notes, err := storage.Notes(userIDInt)
+		query, err := parseNotesQuery(r.URL.Query())
 		if err != nil {
-			if errors.Is(err, store.ErrNoteNotFound) {
-				log.Warn("no notes found for user", logger.Err(err))
-				w.WriteHeader(http.StatusNotFound)
-				encoder.Encode(map[string]string{"NotFound": "No notes found for user"})
+			log.Error("failed to parse query parameters", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidRequest, err.Error())
+			return
+		}
+
+		query.Trashed = trashed
+		if trashed && r.URL.Query().Get("sort") == "" {
+			query.SortBy = "deleted_at"
+		}
+
+		if errs := query.Validate(); len(errs) > 0 {
+			log.Error("validation error", logger.Err(fmt.Errorf("invalid notes query: %v", errs)))
+
+			problem.WriteValidation(w, r, "Invalid notes query", errs)
+			return
+		}
+
+		page, err := storage.Notes(r.Context(), userIDInt, query)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			if errors.Is(err, store.ErrInvalidCursor) {
+				log.Warn("invalid cursor", logger.Err(err))
+
+				problem.Write(w, r, problem.InvalidCursor, "Cursor is malformed or does not match the requested sort")
 				return
 			}
 
 			log.Error("error when retrieving notes", logger.Err(err))
 
-			w.WriteHeader(http.StatusInternalServerError)
-			encoder.Encode(map[string]string{"InternalError": "Failed to retrieve notes"})
+			problem.Write(w, r, problem.Internal, "Failed to retrieve notes")
 			return
 		}
 
-		encoder.Encode(notes)
+		encoder.Encode(page)
 	}
 }
+
+func parseNotesQuery(values url.Values) (models.NotesQuery, error) {
+	query := models.NotesQuery{
+		Limit:   models.DefaultNotesLimit,
+		Cursor:  values.Get("cursor"),
+		SortBy:  "created_at",
+		Order:   "desc",
+		Tags:    models.NormalizeTags(values["tag"]),
+		TagMode: models.TagModeAll,
+	}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return query, fmt.Errorf("limit must be an integer")
+		}
+		query.Limit = limit
+	}
+
+	if v := values.Get("sort"); v != "" {
+		query.SortBy = v
+	}
+
+	if v := values.Get("order"); v != "" {
+		query.Order = v
+	}
+
+	if v := values.Get("tag_mode"); v != "" {
+		query.TagMode = v
+	}
+
+	timestamps := []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"created_after", &query.CreatedAfter},
+		{"created_before", &query.CreatedBefore},
+		{"updated_after", &query.UpdatedAfter},
+		{"updated_before", &query.UpdatedBefore},
+	}
+
+	for _, ts := range timestamps {
+		v := values.Get(ts.name)
+		if v == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return query, fmt.Errorf("%s must be an RFC 3339 timestamp", ts.name)
+		}
+		*ts.dst = t
+	}
+
+	return query, nil
+}
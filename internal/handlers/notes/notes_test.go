@@ -0,0 +1,99 @@
+package notes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"notes-api/internal/models"
+	store "notes-api/internal/storage"
+)
+
+func TestNotesHandlerQuery(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		target string
+		want   models.NotesQuery
+	}{
+		{
+			name:   "defaults",
+			target: "/notes",
+			want:   models.NotesQuery{Limit: models.DefaultNotesLimit, SortBy: "created_at", Order: "desc", TagMode: models.TagModeAll},
+		},
+		{
+			name:   "cursor and sort",
+			target: "/notes?cursor=abc&limit=5&sort=title&order=asc",
+			want:   models.NotesQuery{Limit: 5, Cursor: "abc", SortBy: "title", Order: "asc", TagMode: models.TagModeAll},
+		},
+		{
+			name:   "filters",
+			target: "/notes?created_after=2026-01-02T03:04:05Z&updated_before=2026-02-01T00:00:00Z",
+			want: models.NotesQuery{
+				Limit:         models.DefaultNotesLimit,
+				SortBy:        "created_at",
+				Order:         "desc",
+				CreatedAfter:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+				UpdatedBefore: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+				TagMode:       models.TagModeAll,
+			},
+		},
+		{
+			name:   "tags",
+			target: "/notes?tag=Work&tag=work&tag=home&tag_mode=any",
+			want: models.NotesQuery{
+				Limit:   models.DefaultNotesLimit,
+				SortBy:  "created_at",
+				Order:   "desc",
+				Tags:    []string{"work", "home"},
+				TagMode: models.TagModeAny,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewMockNotesProvider(t)
+			storage.EXPECT().Notes(mock.Anything, testUserID, tt.want).Return(&models.NotesPage{Notes: []models.Note{}, NextCursor: "next"}, nil).Once()
+
+			rec := serve("/notes", NotesHandler(discard, storage), http.MethodGet, tt.target, "")
+			assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.JSONEq(t, `{"notes":[],"next_cursor":"next","total":0}`, rec.Body.String())
+		})
+	}
+}
+
+func TestTrashHandlerSortsByDeletion(t *testing.T) {
+	storage := NewMockNotesProvider(t)
+	storage.EXPECT().Notes(mock.Anything, testUserID, mock.MatchedBy(func(q models.NotesQuery) bool {
+		return q.Trashed && q.SortBy == "deleted_at"
+	})).Return(&models.NotesPage{Notes: []models.Note{}}, nil).Once()
+
+	rec := serve("/notes/trash", TrashHandler(discard, storage), http.MethodGet, "/notes/trash", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestNotesHandlerRejects(t *testing.T) {
+	for target, code := range map[string]string{
+		"/notes?limit=ten":                "invalid_request",
+		"/notes?created_before=yesterday": "invalid_request",
+		"/notes?limit=0":                  "validation_failed",
+		"/notes?sort=deleted_at":          "validation_failed",
+		"/notes?order=sideways":           "validation_failed",
+		"/notes?tag_mode=some":            "validation_failed",
+		"/notes?created_after=2026-01-02T00:00:00Z&created_before=2026-01-01T00:00:00Z": "validation_failed",
+	} {
+		rec := serve("/notes", NotesHandler(discard, NewMockNotesProvider(t)), http.MethodGet, target, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, target)
+		assert.Equal(t, code, problemCode(t, rec), target)
+	}
+}
+
+func TestNotesHandlerInvalidCursor(t *testing.T) {
+	storage := NewMockNotesProvider(t)
+	storage.EXPECT().Notes(mock.Anything, testUserID, models.NotesQuery{Limit: models.DefaultNotesLimit, Cursor: "bogus", SortBy: "created_at", Order: "desc", TagMode: models.TagModeAll}).Return(nil, store.ErrInvalidCursor).Once()
+
+	rec := serve("/notes", NotesHandler(discard, storage), http.MethodGet, "/notes?cursor=bogus", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "invalid_cursor", problemCode(t, rec))
+}
@@ -0,0 +1,227 @@
+package notes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"notes-api/internal/models"
+	"notes-api/internal/patch"
+	"notes-api/internal/problem"
+	store "notes-api/internal/storage"
+	"notes-api/internal/utils"
+	"notes-api/pkg/logger"
+)
+
+type NotePatcher interface {
+	Note(ctx context.Context, id, userID int) (*models.Note, error)
+	PatchNote(ctx context.Context, id, userID int, patch models.NotePatch, version int) (*models.Note, error)
+}
+
+// patchDocument is the part of a note a patch is applied to.
+type patchDocument struct {
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags"`
+}
+
+var acceptPatch = patch.MergePatchContentType + ", " + patch.JSONPatchContentType
+
+// maxPatchAttempts bounds how often a patch sent without If-Match is applied
+// again after a concurrent write got in first.
+const maxPatchAttempts = 3
+
+// PatchNoteHandler partially updates a note with a JSON Merge Patch or, when
+// sent as application/json-patch+json, a JSON Patch. Only fields whose value
+// changes are written, and the updated note is returned. A patch sent with
+// If-Match fails with 412 if the note is at another version; one sent without
+// is applied to whatever version is current, or fails with 409 if the note
+// keeps changing underneath it.
+func PatchNoteHandler(log *slog.Logger, storage NotePatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Error("error when converting id to int", logger.Err(err))
+			problem.Write(w, r, problem.InvalidID, "ID must be an integer")
+			return
+		}
+
+		userID, ok := r.Context().Value(utils.UserIDKey).(string)
+		if !ok {
+			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
+			return
+		}
+
+		userIDInt, err := strconv.Atoi(userID)
+		if err != nil {
+			log.Error("error when converting user ID to int", logger.Err(err))
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
+			return
+		}
+
+		var apply func(doc, patch []byte) ([]byte, error)
+
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case patch.MergePatchContentType, "application/json":
+			apply = patch.Merge
+		case patch.JSONPatchContentType:
+			apply = patch.Apply
+		default:
+			log.Warn("unsupported patch content type", slog.String("content_type", mediaType))
+			w.Header().Set("Accept-Patch", acceptPatch)
+			problem.Write(w, r, problem.UnsupportedMediaType, "Content-Type must be one of "+acceptPatch)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Error("failed to read request body", logger.Err(err))
+			problem.Write(w, r, problem.InvalidRequest, "Failed to read request body")
+			return
+		}
+
+		expected, err := ifMatchVersions(r.Header.Get("If-Match"))
+		if err != nil {
+			log.Error("invalid If-Match header", logger.Err(err))
+			problem.Write(w, r, problem.InvalidRequest, err.Error())
+			return
+		}
+
+		for attempt := 1; ; attempt++ {
+			current, err := storage.Note(r.Context(), id, userIDInt)
+			if err != nil {
+				if problem.WriteContextError(w, r, err) {
+					return
+				}
+
+				if errors.Is(err, store.ErrNoteNotFound) {
+					log.Warn("note not found", logger.Err(err))
+					problem.Write(w, r, problem.NotFound, "Note not found")
+					return
+				}
+
+				log.Error("error when retrieving note", logger.Err(err))
+				problem.Write(w, r, problem.Internal, "Failed to retrieve note")
+				return
+			}
+
+			if expected != nil && !slices.Contains(expected, current.Version) {
+				log.Warn("note version mismatch", slog.Any("expected", expected), slog.Int("current", current.Version))
+				problem.Write(w, r, problem.PreconditionFailed, "Note has been modified since it was read")
+				return
+			}
+
+			doc, err := json.Marshal(patchDocument{Title: current.Title, Content: current.Content, Tags: current.Tags})
+			if err != nil {
+				log.Error("failed to encode note", logger.Err(err))
+				problem.Write(w, r, problem.Internal, "Failed to patch note")
+				return
+			}
+
+			patched, err := apply(doc, body)
+			if err != nil {
+				switch {
+				case errors.Is(err, patch.ErrTestFailed):
+					log.Warn("patch test failed", logger.Err(err))
+					problem.Write(w, r, problem.PatchTestFailed, err.Error())
+				case errors.Is(err, patch.ErrInvalidPatch):
+					log.Warn("invalid patch", logger.Err(err))
+					problem.Write(w, r, problem.InvalidPatch, err.Error())
+				default:
+					log.Error("failed to apply patch", logger.Err(err))
+					problem.Write(w, r, problem.Internal, "Failed to patch note")
+				}
+				return
+			}
+
+			var merged patchDocument
+			decoder := json.NewDecoder(bytes.NewReader(patched))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(&merged); err != nil {
+				log.Warn("patched note is malformed", logger.Err(err))
+				problem.Write(w, r, problem.UnpatchableNote, "Only title, content and tags can be patched, as a string, string and array of strings")
+				return
+			}
+
+			note := models.Note{Title: merged.Title, Content: merged.Content, Tags: models.NormalizeTags(merged.Tags)}
+			if note.Tags == nil {
+				note.Tags = []string{}
+			}
+			slices.Sort(note.Tags)
+
+			if errs := note.Validate(); len(errs) > 0 {
+				log.Error("validation error", logger.Err(fmt.Errorf("invalid note data: %v", errs)))
+				problem.WriteValidation(w, r, "Invalid note data", errs)
+				return
+			}
+
+			var changes models.NotePatch
+			if note.Title != current.Title {
+				changes.Title = &note.Title
+			}
+			if note.Content != current.Content {
+				changes.Content = &note.Content
+			}
+			if !slices.Equal(note.Tags, current.Tags) {
+				changes.Tags = note.Tags
+			}
+
+			if changes.Empty() {
+				w.Header().Set("ETag", etag(current.Version))
+				encoder.Encode(current)
+				return
+			}
+
+			// The patch was applied to the version just read, so the write must not
+			// land on top of a concurrent one even without If-Match. Without it,
+			// the patch is applied again to the version that won: the same as if
+			// it had been sent a moment later.
+			updated, err := storage.PatchNote(r.Context(), id, userIDInt, changes, current.Version)
+			if errors.Is(err, store.ErrVersionMismatch) && expected == nil && attempt < maxPatchAttempts {
+				log.Info("note modified while patching, retrying", slog.Int("attempt", attempt))
+				continue
+			}
+
+			if err != nil {
+				if problem.WriteContextError(w, r, err) {
+					return
+				}
+
+				switch {
+				case errors.Is(err, store.ErrNoteNotFound):
+					log.Warn("note not found", logger.Err(err))
+					problem.Write(w, r, problem.NotFound, "Note not found")
+				case errors.Is(err, store.ErrVersionMismatch) && expected != nil:
+					log.Warn("note version mismatch", logger.Err(err))
+					problem.Write(w, r, problem.PreconditionFailed, "Note has been modified since it was read")
+				case errors.Is(err, store.ErrVersionMismatch):
+					log.Warn("note modified while patching", logger.Err(err))
+					problem.Write(w, r, problem.NoteConflict, "Note kept being modified while the patch was applied; try again")
+				default:
+					log.Error("error when patching note", logger.Err(err))
+					problem.Write(w, r, problem.Internal, "Failed to patch note")
+				}
+				return
+			}
+
+			w.Header().Set("ETag", etag(updated.Version))
+			encoder.Encode(updated)
+			return
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package notes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"notes-api/internal/models"
+	"notes-api/internal/patch"
+	store "notes-api/internal/storage"
+)
+
+func TestPatchNote(t *testing.T) {
+	storage := NewMockNotePatcher(t)
+	storage.EXPECT().Note(mock.Anything, 1, testUserID).Return(testNote(3, "old"), nil).Once()
+	storage.EXPECT().PatchNote(mock.Anything, 1, testUserID, mock.MatchedBy(func(p models.NotePatch) bool {
+		return p.Title != nil && *p.Title == "new" && p.Content == nil && p.Tags == nil
+	}), 3).Return(testNote(4, "new"), nil).Once()
+
+	rec := serve("/notes/{id}", PatchNoteHandler(discard, storage), http.MethodPatch, "/notes/1",
+		`{"title":"new"}`, "Content-Type", patch.MergePatchContentType)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, `"4"`, rec.Header().Get("ETag"))
+	assert.Contains(t, rec.Body.String(), `"title":"new"`)
+}
+
+func TestPatchNoteJSONPatch(t *testing.T) {
+	storage := NewMockNotePatcher(t)
+	storage.EXPECT().Note(mock.Anything, 1, testUserID).Return(testNote(3, "old"), nil)
+
+	rec := serve("/notes/{id}", PatchNoteHandler(discard, storage), http.MethodPatch, "/notes/1",
+		`[{"op":"test","path":"/title","value":"other"},{"op":"replace","path":"/title","value":"new"}]`,
+		"Content-Type", patch.JSONPatchContentType)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "patch_test_failed", problemCode(t, rec))
+}
+
+func TestPatchNoteUnsupportedMediaType(t *testing.T) {
+	rec := serve("/notes/{id}", PatchNoteHandler(discard, NewMockNotePatcher(t)), http.MethodPatch, "/notes/1",
+		`{"title":"new"}`, "Content-Type", "text/plain")
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	assert.Equal(t, acceptPatch, rec.Header().Get("Accept-Patch"))
+}
+
+func TestPatchNoteIfMatch(t *testing.T) {
+	t.Run("stale", func(t *testing.T) {
+		storage := NewMockNotePatcher(t)
+		storage.EXPECT().Note(mock.Anything, 1, testUserID).Return(testNote(3, "old"), nil).Once()
+
+		rec := serve("/notes/{id}", PatchNoteHandler(discard, storage), http.MethodPatch, "/notes/1",
+			`{"title":"new"}`, "Content-Type", patch.MergePatchContentType, "If-Match", `"2"`)
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		assert.Equal(t, "precondition_failed", problemCode(t, rec))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		storage := NewMockNotePatcher(t)
+		storage.EXPECT().Note(mock.Anything, 1, testUserID).Return(testNote(3, "old"), nil).Once()
+		storage.EXPECT().PatchNote(mock.Anything, 1, testUserID, mock.Anything, 3).Return(testNote(4, "new"), nil).Once()
+
+		rec := serve("/notes/{id}", PatchNoteHandler(discard, storage), http.MethodPatch, "/notes/1",
+			`{"title":"new"}`, "Content-Type", patch.MergePatchContentType, "If-Match", `"2", "3"`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `"4"`, rec.Header().Get("ETag"))
+	})
+
+	// A write that lands between reading and writing the note fails the
+	// precondition too, rather than being patched over.
+	t.Run("concurrent write", func(t *testing.T) {
+		storage := NewMockNotePatcher(t)
+		storage.EXPECT().Note(mock.Anything, 1, testUserID).Return(testNote(3, "old"), nil).Once()
+		storage.EXPECT().PatchNote(mock.Anything, 1, testUserID, mock.Anything, 3).Return(nil, store.ErrVersionMismatch).Once()
+
+		rec := serve("/notes/{id}", PatchNoteHandler(discard, storage), http.MethodPatch, "/notes/1",
+			`{"title":"new"}`, "Content-Type", patch.MergePatchContentType, "If-Match", `"3"`)
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	})
+}
+
+func TestPatchNoteRetriesWithoutIfMatch(t *testing.T) {
+	storage := NewMockNotePatcher(t)
+	storage.EXPECT().Note(mock.Anything, 1, testUserID).Return(testNote(3, "old"), nil).Once()
+	storage.EXPECT().PatchNote(mock.Anything, 1, testUserID, mock.Anything, 3).Return(nil, store.ErrVersionMismatch).Once()
+	storage.EXPECT().Note(mock.Anything, 1, testUserID).Return(testNote(4, "renamed"), nil).Once()
+	storage.EXPECT().PatchNote(mock.Anything, 1, testUserID, mock.Anything, 4).Return(testNote(5, "new"), nil).Once()
+
+	rec := serve("/notes/{id}", PatchNoteHandler(discard, storage), http.MethodPatch, "/notes/1",
+		`{"title":"new"}`, "Content-Type", patch.MergePatchContentType)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, `"5"`, rec.Header().Get("ETag"))
+}
+
+func TestPatchNoteConflict(t *testing.T) {
+	storage := NewMockNotePatcher(t)
+	storage.EXPECT().Note(mock.Anything, 1, testUserID).Return(testNote(3, "old"), nil).Times(maxPatchAttempts)
+	storage.EXPECT().PatchNote(mock.Anything, 1, testUserID, mock.Anything, 3).Return(nil, store.ErrVersionMismatch).Times(maxPatchAttempts)
+
+	rec := serve("/notes/{id}", PatchNoteHandler(discard, storage), http.MethodPatch, "/notes/1",
+		`{"title":"new"}`, "Content-Type", patch.MergePatchContentType)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "note_conflict", problemCode(t, rec))
+}
@@ -0,0 +1,65 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"notes-api/internal/problem"
+	store "notes-api/internal/storage"
+	"notes-api/internal/utils"
+	"notes-api/pkg/logger"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type NoteRestorer interface {
+	RestoreNote(ctx context.Context, id, userID int) error
+}
+
+func RestoreNoteHandler(log *slog.Logger, storage NoteRestorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Error("error when converting id to int", logger.Err(err))
+			problem.Write(w, r, problem.InvalidID, "ID must be an integer")
+			return
+		}
+
+		userID, ok := r.Context().Value(utils.UserIDKey).(string)
+		if !ok {
+			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
+			return
+		}
+
+		userIDInt, err := strconv.Atoi(userID)
+		if err != nil {
+			log.Error("error when converting user ID to int", logger.Err(err))
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
+			return
+		}
+
+		if err := storage.RestoreNote(r.Context(), id, userIDInt); err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			if errors.Is(err, store.ErrNoteNotFound) {
+				log.Warn("trashed note not found", logger.Err(err))
+				problem.Write(w, r, problem.NotFound, "Note not found in trash")
+				return
+			}
+
+			log.Error("error when restoring note", logger.Err(err))
+			problem.Write(w, r, problem.Internal, "Failed to restore note")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
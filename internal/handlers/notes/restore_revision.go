@@ -0,0 +1,75 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"notes-api/internal/problem"
+	store "notes-api/internal/storage"
+	"notes-api/internal/utils"
+	"notes-api/pkg/logger"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type RevisionRestorer interface {
+	RestoreRevision(ctx context.Context, noteID, userID, revision int) error
+}
+
+func RestoreRevisionHandler(log *slog.Logger, storage RevisionRestorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Error("error when converting id to int", logger.Err(err))
+			problem.Write(w, r, problem.InvalidID, "ID must be an integer")
+			return
+		}
+
+		rev, err := strconv.Atoi(chi.URLParam(r, "rev"))
+		if err != nil {
+			log.Error("error when converting revision to int", logger.Err(err))
+			problem.Write(w, r, problem.InvalidRevision, "Revision must be an integer")
+			return
+		}
+
+		userID, ok := r.Context().Value(utils.UserIDKey).(string)
+		if !ok {
+			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
+			return
+		}
+
+		userIDInt, err := strconv.Atoi(userID)
+		if err != nil {
+			log.Error("error when converting user ID to int", logger.Err(err))
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
+			return
+		}
+
+		if err := storage.RestoreRevision(r.Context(), id, userIDInt, rev); err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			switch {
+			case errors.Is(err, store.ErrNoteNotFound):
+				log.Warn("note not found", logger.Err(err))
+				problem.Write(w, r, problem.NotFound, "Note not found")
+			case errors.Is(err, store.ErrRevisionNotFound):
+				log.Warn("revision not found", logger.Err(err))
+				problem.Write(w, r, problem.NotFound, "Revision not found")
+			default:
+				log.Error("error when restoring revision", logger.Err(err))
+				problem.Write(w, r, problem.Internal, "Failed to restore revision")
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,146 @@
+package notes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"notes-api/internal/models"
+	"notes-api/internal/problem"
+	store "notes-api/internal/storage"
+	"notes-api/internal/utils"
+	"notes-api/pkg/logger"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type RevisionsProvider interface {
+	NoteRevisions(ctx context.Context, noteID, userID int) ([]models.Revision, error)
+}
+
+type RevisionProvider interface {
+	NoteRevision(ctx context.Context, noteID, userID, revision int) (*models.Revision, error)
+}
+
+func RevisionsHandler(log *slog.Logger, storage RevisionsProvider) http.HandlerFunc {
+	type response struct {
+		Revisions []models.Revision `json:"revisions"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Error("error when converting id to int", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidID, "ID must be an integer")
+			return
+		}
+
+		userID, ok := r.Context().Value(utils.UserIDKey).(string)
+		if !ok {
+			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
+
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
+			return
+		}
+
+		userIDInt, err := strconv.Atoi(userID)
+		if err != nil {
+			log.Error("error when converting user ID to int", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
+			return
+		}
+
+		revisions, err := storage.NoteRevisions(r.Context(), id, userIDInt)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			if errors.Is(err, store.ErrNoteNotFound) {
+				log.Warn("note not found", logger.Err(err))
+
+				problem.Write(w, r, problem.NotFound, "Note not found")
+				return
+			}
+
+			log.Error("error when retrieving revisions", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to retrieve revisions")
+			return
+		}
+
+		encoder.Encode(response{Revisions: revisions})
+	}
+}
+
+func RevisionHandler(log *slog.Logger, storage RevisionProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Error("error when converting id to int", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidID, "ID must be an integer")
+			return
+		}
+
+		rev, err := strconv.Atoi(chi.URLParam(r, "rev"))
+		if err != nil {
+			log.Error("error when converting revision to int", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidRevision, "Revision must be an integer")
+			return
+		}
+
+		userID, ok := r.Context().Value(utils.UserIDKey).(string)
+		if !ok {
+			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
+
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
+			return
+		}
+
+		userIDInt, err := strconv.Atoi(userID)
+		if err != nil {
+			log.Error("error when converting user ID to int", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
+			return
+		}
+
+		revision, err := storage.NoteRevision(r.Context(), id, userIDInt, rev)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			switch {
+			case errors.Is(err, store.ErrNoteNotFound):
+				log.Warn("note not found", logger.Err(err))
+
+				problem.Write(w, r, problem.NotFound, "Note not found")
+			case errors.Is(err, store.ErrRevisionNotFound):
+				log.Warn("revision not found", logger.Err(err))
+
+				problem.Write(w, r, problem.NotFound, "Revision not found")
+			default:
+				log.Error("error when retrieving revision", logger.Err(err))
+
+				problem.Write(w, r, problem.Internal, "Failed to retrieve revision")
+			}
+			return
+		}
+
+		encoder.Encode(revision)
+	}
+}
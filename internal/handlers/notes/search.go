@@ -0,0 +1,110 @@
+package notes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"notes-api/internal/models"
+	"notes-api/internal/problem"
+	store "notes-api/internal/storage"
+	"notes-api/internal/utils"
+	"notes-api/pkg/logger"
+	"strconv"
+	"strings"
+)
+
+type NoteSearcher interface {
+	SearchNotes(ctx context.Context, userID int, query models.SearchQuery) ([]models.SearchResult, error)
+}
+
+func SearchNotesHandler(log *slog.Logger, storage NoteSearcher) http.HandlerFunc {
+	type response struct {
+		Results []models.SearchResult `json:"results"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+
+		userID, ok := r.Context().Value(utils.UserIDKey).(string)
+		if !ok {
+			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
+
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
+			return
+		}
+
+		userIDInt, err := strconv.Atoi(userID)
+		if err != nil {
+			log.Error("error when converting user ID to int", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
+			return
+		}
+
+		query, err := parseSearchQuery(r.URL.Query())
+		if err != nil {
+			log.Error("failed to parse query parameters", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidRequest, err.Error())
+			return
+		}
+
+		if errs := query.Validate(); len(errs) > 0 {
+			log.Error("validation error", logger.Err(fmt.Errorf("invalid search query: %v", errs)))
+
+			problem.WriteValidation(w, r, "Invalid search query", errs)
+			return
+		}
+
+		results, err := storage.SearchNotes(r.Context(), userIDInt, query)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			switch {
+			case errors.Is(err, store.ErrInvalidSearchQuery):
+				log.Warn("invalid search query", logger.Err(err))
+
+				problem.Write(w, r, problem.InvalidQuery, "Search query syntax is invalid")
+			default:
+				log.Error("error when searching notes", logger.Err(err))
+
+				problem.Write(w, r, problem.Internal, "Failed to search notes")
+			}
+			return
+		}
+
+		encoder.Encode(response{Results: results})
+	}
+}
+
+func parseSearchQuery(values url.Values) (models.SearchQuery, error) {
+	query := models.SearchQuery{
+		Query: strings.TrimSpace(values.Get("q")),
+		Limit: models.DefaultNotesLimit,
+	}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return query, fmt.Errorf("limit must be an integer")
+		}
+		query.Limit = limit
+	}
+
+	if v := values.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil {
+			return query, fmt.Errorf("offset must be an integer")
+		}
+		query.Offset = offset
+	}
+
+	return query, nil
+}
@@ -1,6 +1,7 @@
 package notes
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -10,8 +11,11 @@ import (
 	"log/slog"
 
 	"encoding/json"
+	"errors"
 
 	"notes-api/internal/models"
+	"notes-api/internal/problem"
+	store "notes-api/internal/storage"
 	"notes-api/internal/utils"
 
 	"fmt"
@@ -20,53 +24,82 @@ import (
 )
 
 type NoteUpdater interface {
-	UpdateNote(id, userID int, title, content string) error
+	UpdateNote(ctx context.Context, id, userID int, title, content string, tags []string, version int) (int, error)
 }
 
+// UpdateNoteHandler replaces a note, failing with 412 if If-Match is stale.
 func UpdateNoteHandler(log *slog.Logger, storage NoteUpdater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		encoder := json.NewEncoder(w)
 
 		id, err := strconv.Atoi(chi.URLParam(r, "id"))
 		if err != nil {
 			log.Error("error when converting id to int", logger.Err(err))
-			w.WriteHeader(http.StatusBadRequest)
-			encoder.Encode(map[string]string{"InvalidID": "ID must be an integer"})
+			problem.Write(w, r, problem.InvalidID, "ID must be an integer")
 			return
 		}
 
 		var note models.Note
 		if err := json.NewDecoder(r.Body).Decode(&note); err != nil {
 			log.Error("failed to decode request body", logger.Err(err))
-			w.WriteHeader(http.StatusBadRequest)
-			encoder.Encode(map[string]string{"InvalidRequest": "Failed to decode request body"})
+			problem.Write(w, r, problem.InvalidRequest, "Failed to decode request body")
+			return
+		}
+
+		note.Tags = models.NormalizeTags(note.Tags)
+
+		if errs := note.Validate(); len(errs) > 0 {
+			log.Error("validation error", logger.Err(fmt.Errorf("invalid note data: %v", errs)))
+			problem.WriteValidation(w, r, "Invalid note data", errs)
 			return
 		}
 
 		userID, ok := r.Context().Value(utils.UserIDKey).(string)
 		if !ok {
 			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
-			w.WriteHeader(http.StatusUnauthorized)
-			encoder.Encode(map[string]string{"Unauthorized": "User ID not found in context"})
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
 			return
 		}
 
 		userIDInt, err := strconv.Atoi(userID)
 		if err != nil {
 			log.Error("error when converting user ID to int", logger.Err(err))
-			w.WriteHeader(http.StatusInternalServerError)
-			encoder.Encode(map[string]string{"InternalError": "Failed to convert user ID"})
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
 			return
 		}
 
-		if err := storage.UpdateNote(id, userIDInt, note.Title, note.Content); err != nil {
-			log.Error("error when updating note", logger.Err(err))
-			w.WriteHeader(http.StatusInternalServerError)
-			encoder.Encode(map[string]string{"InternalError": "Failed to update note"})
+		versions, err := ifMatchVersions(r.Header.Get("If-Match"))
+		if err != nil {
+			log.Error("invalid If-Match header", logger.Err(err))
+			problem.Write(w, r, problem.InvalidRequest, err.Error())
+			return
+		}
+
+		var version int
+		err = writeIfMatch(versions, func(expected int) (err error) {
+			version, err = storage.UpdateNote(r.Context(), id, userIDInt, note.Title, note.Content, note.Tags, expected)
+			return err
+		})
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			switch {
+			case errors.Is(err, store.ErrNoteNotFound):
+				log.Warn("note not found", logger.Err(err))
+				problem.Write(w, r, problem.NotFound, "Note not found")
+			case errors.Is(err, store.ErrVersionMismatch):
+				log.Warn("note version mismatch", logger.Err(err))
+				problem.Write(w, r, problem.PreconditionFailed, "Note has been modified since it was read")
+			default:
+				log.Error("error when updating note", logger.Err(err))
+				problem.Write(w, r, problem.Internal, "Failed to update note")
+			}
 			return
 		}
 
+		w.Header().Set("ETag", etag(version))
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
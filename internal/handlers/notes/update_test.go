@@ -0,0 +1,71 @@
+package notes
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	store "notes-api/internal/storage"
+)
+
+const updateBody = `{"title":"title","content":"content","tags":["Work"]}`
+
+func TestUpdateNoteIfMatch(t *testing.T) {
+	for _, tt := range []struct {
+		ifMatch string
+		version int
+	}{
+		{"", 0},
+		{"*", 0},
+		{`"3"`, 3},
+		// A weak tag never matches, so the update must fail.
+		{`W/"3"`, -1},
+	} {
+		storage := NewMockNoteUpdater(t)
+		storage.EXPECT().UpdateNote(mock.Anything, 1, testUserID, "title", "content", []string{"work"}, tt.version).Return(4, nil).Once()
+
+		rec := serve("/notes/{id}", UpdateNoteHandler(discard, storage), http.MethodPut, "/notes/1", updateBody, "If-Match", tt.ifMatch)
+		assert.Equal(t, http.StatusNoContent, rec.Code, tt.ifMatch)
+		assert.Equal(t, `"4"`, rec.Header().Get("ETag"))
+	}
+}
+
+// Any strong tag in an If-Match list may match, so each is tried in turn.
+func TestUpdateNoteIfMatchList(t *testing.T) {
+	storage := NewMockNoteUpdater(t)
+	storage.EXPECT().UpdateNote(mock.Anything, 1, testUserID, "title", "content", []string{"work"}, 2).Return(0, store.ErrVersionMismatch).Once()
+	storage.EXPECT().UpdateNote(mock.Anything, 1, testUserID, "title", "content", []string{"work"}, 3).Return(4, nil).Once()
+
+	rec := serve("/notes/{id}", UpdateNoteHandler(discard, storage), http.MethodPut, "/notes/1", updateBody, "If-Match", `"2", W/"3", "3", "2"`)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, `"4"`, rec.Header().Get("ETag"))
+
+	storage = NewMockNoteUpdater(t)
+	storage.EXPECT().UpdateNote(mock.Anything, 1, testUserID, "title", "content", []string{"work"}, -1).Return(0, store.ErrVersionMismatch).Once()
+
+	rec = serve("/notes/{id}", UpdateNoteHandler(discard, storage), http.MethodPut, "/notes/1", updateBody, "If-Match", `W/"2", W/"3"`)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	assert.Equal(t, "precondition_failed", problemCode(t, rec))
+}
+
+func TestUpdateNotePreconditionFailed(t *testing.T) {
+	storage := NewMockNoteUpdater(t)
+	storage.EXPECT().UpdateNote(mock.Anything, 1, testUserID, "title", "content", []string{"work"}, 2).Return(0, store.ErrVersionMismatch).Once()
+
+	rec := serve("/notes/{id}", UpdateNoteHandler(discard, storage), http.MethodPut, "/notes/1", updateBody, "If-Match", `"2"`)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	assert.Equal(t, "precondition_failed", problemCode(t, rec))
+}
+
+func TestUpdateNoteRejects(t *testing.T) {
+	rec := serve("/notes/{id}", UpdateNoteHandler(discard, NewMockNoteUpdater(t)), http.MethodPut, "/notes/1", updateBody, "If-Match", strings.Repeat(`"1", `, maxIfMatchTags)+`"2"`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "invalid_request", problemCode(t, rec))
+
+	rec = serve("/notes/{id}", UpdateNoteHandler(discard, NewMockNoteUpdater(t)), http.MethodPut, "/notes/1", `{"title":""}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "validation_failed", problemCode(t, rec))
+}
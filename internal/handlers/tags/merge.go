@@ -0,0 +1,80 @@
+package tags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"notes-api/internal/models"
+	"notes-api/internal/problem"
+	store "notes-api/internal/storage"
+	"notes-api/internal/utils"
+	"notes-api/pkg/logger"
+	"strconv"
+	"strings"
+)
+
+type TagMerger interface {
+	MergeTags(ctx context.Context, userID int, sources []string, target string) error
+}
+
+func MergeTagsHandler(log *slog.Logger, storage TagMerger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req models.TagMerge
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request body", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidRequest, "Failed to decode request body")
+			return
+		}
+		req.Sources = models.NormalizeTags(req.Sources)
+		req.Target = strings.ToLower(strings.TrimSpace(req.Target))
+
+		if errs := req.Validate(); len(errs) > 0 {
+			log.Error("validation error", logger.Err(fmt.Errorf("invalid merge data: %v", errs)))
+
+			problem.WriteValidation(w, r, "Invalid merge data", errs)
+			return
+		}
+
+		userID, ok := r.Context().Value(utils.UserIDKey).(string)
+		if !ok {
+			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
+
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
+			return
+		}
+
+		userIDInt, err := strconv.Atoi(userID)
+		if err != nil {
+			log.Error("error when converting user ID to int", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
+			return
+		}
+
+		if err := storage.MergeTags(r.Context(), userIDInt, req.Sources, req.Target); err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			if errors.Is(err, store.ErrTagNotFound) {
+				log.Warn("tag not found", logger.Err(err))
+
+				problem.Write(w, r, problem.NotFound, "One of the source tags was not found")
+				return
+			}
+
+			log.Error("error when merging tags", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to merge tags")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,95 @@
+package tags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"notes-api/internal/models"
+	"notes-api/internal/problem"
+	store "notes-api/internal/storage"
+	"notes-api/internal/utils"
+	"notes-api/pkg/logger"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type TagRenamer interface {
+	RenameTag(ctx context.Context, userID int, name, newName string) error
+}
+
+func RenameTagHandler(log *slog.Logger, storage TagRenamer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		name, err := url.PathUnescape(chi.URLParam(r, "name"))
+		if err != nil {
+			log.Error("failed to unescape tag name", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidRequest, "Tag name is malformed")
+			return
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		var req models.TagRename
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Error("failed to decode request body", logger.Err(err))
+
+			problem.Write(w, r, problem.InvalidRequest, "Failed to decode request body")
+			return
+		}
+		req.Name = strings.ToLower(strings.TrimSpace(req.Name))
+
+		if errs := req.Validate(); len(errs) > 0 {
+			log.Error("validation error", logger.Err(fmt.Errorf("invalid tag data: %v", errs)))
+
+			problem.WriteValidation(w, r, "Invalid tag data", errs)
+			return
+		}
+
+		userID, ok := r.Context().Value(utils.UserIDKey).(string)
+		if !ok {
+			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
+
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
+			return
+		}
+
+		userIDInt, err := strconv.Atoi(userID)
+		if err != nil {
+			log.Error("error when converting user ID to int", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
+			return
+		}
+
+		if err := storage.RenameTag(r.Context(), userIDInt, name, req.Name); err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			switch {
+			case errors.Is(err, store.ErrTagNotFound):
+				log.Warn("tag not found", logger.Err(err))
+
+				problem.Write(w, r, problem.NotFound, "Tag not found")
+			case errors.Is(err, store.ErrTagAlreadyExists):
+				log.Warn("tag already exists", logger.Err(err))
+
+				problem.Write(w, r, problem.TagExists, "A tag with this name already exists, merge the tags instead")
+			default:
+				log.Error("error when renaming tag", logger.Err(err))
+
+				problem.Write(w, r, problem.Internal, "Failed to rename tag")
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
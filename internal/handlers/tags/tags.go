@@ -0,0 +1,59 @@
+package tags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"notes-api/internal/models"
+	"notes-api/internal/problem"
+	"notes-api/internal/utils"
+	"notes-api/pkg/logger"
+	"strconv"
+)
+
+type TagsProvider interface {
+	Tags(ctx context.Context, userID int) ([]models.Tag, error)
+}
+
+func TagsHandler(log *slog.Logger, storage TagsProvider) http.HandlerFunc {
+	type response struct {
+		Tags []models.Tag `json:"tags"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+
+		userID, ok := r.Context().Value(utils.UserIDKey).(string)
+		if !ok {
+			log.Error("user ID not found in context", logger.Err(fmt.Errorf("user ID not found in context")))
+
+			problem.Write(w, r, problem.Unauthorized, "User ID not found in context")
+			return
+		}
+
+		userIDInt, err := strconv.Atoi(userID)
+		if err != nil {
+			log.Error("error when converting user ID to int", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to convert user ID")
+			return
+		}
+
+		tags, err := storage.Tags(r.Context(), userIDInt)
+		if err != nil {
+			if problem.WriteContextError(w, r, err) {
+				return
+			}
+
+			log.Error("error when retrieving tags", logger.Err(err))
+
+			problem.Write(w, r, problem.Internal, "Failed to retrieve tags")
+			return
+		}
+
+		encoder.Encode(response{Tags: tags})
+	}
+}
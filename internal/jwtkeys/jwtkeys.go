@@ -0,0 +1,315 @@
+// Package jwtkeys signs and verifies access tokens. Tokens are signed with
+// an HMAC secret, which only this API can verify them with, or with one of
+// a set of asymmetric keys, whose public halves are published as a JSON Web
+// Key Set so that other services can verify them too.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"notes-api/internal/config"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSABits is the smallest RSA modulus accepted, as RFC 7518 requires for
+// RS256.
+const minRSABits = 2048
+
+// key is one signing or verification key. Keys loaded from a public key
+// file have no private half and can only verify.
+type key struct {
+	id      string
+	method  jwt.SigningMethod
+	private any
+	public  any
+}
+
+// KeySet holds the key new tokens are signed with and every key tokens are
+// verified with.
+type KeySet struct {
+	issuer   string
+	audience string
+	leeway   time.Duration
+	signing  *key
+	keys     map[string]*key
+	jwks     []byte
+}
+
+// New returns the keys cfg configures, or an HS256 key of secret if it
+// configures none.
+func New(cfg config.JWT, secret []byte) (*KeySet, error) {
+	s := &KeySet{
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		leeway:   cfg.Leeway,
+		keys:     make(map[string]*key),
+	}
+
+	if len(cfg.Keys) == 0 {
+		if len(secret) == 0 {
+			return nil, errors.New("no JWT secret or keys configured")
+		}
+
+		s.signing = &key{method: jwt.SigningMethodHS256, private: secret, public: secret}
+	}
+
+	for _, kc := range cfg.Keys {
+		if kc.ID == "" {
+			return nil, errors.New("JWT key without an id")
+		}
+
+		if _, ok := s.keys[kc.ID]; ok {
+			return nil, fmt.Errorf("duplicate JWT key id %q", kc.ID)
+		}
+
+		k, err := load(kc)
+		if err != nil {
+			return nil, fmt.Errorf("JWT key %q: %w", kc.ID, err)
+		}
+
+		s.keys[k.id] = k
+	}
+
+	if len(cfg.Keys) > 0 {
+		k, ok := s.keys[cfg.SigningKey]
+		if !ok {
+			return nil, fmt.Errorf("signing key %q is not among the JWT keys", cfg.SigningKey)
+		}
+
+		if k.private == nil {
+			return nil, fmt.Errorf("signing key %q has no private key", cfg.SigningKey)
+		}
+
+		s.signing = k
+	}
+
+	jwks, err := s.marshalJWKS(cfg.Keys)
+	if err != nil {
+		return nil, err
+	}
+	s.jwks = jwks
+
+	return s, nil
+}
+
+// Sign signs claims with the signing key, adding the issuer and audience.
+func (s *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	if s.issuer != "" {
+		claims["iss"] = s.issuer
+	}
+
+	if s.audience != "" {
+		claims["aud"] = s.audience
+	}
+
+	token := jwt.NewWithClaims(s.signing.method, claims)
+	if s.signing.id != "" {
+		token.Header["kid"] = s.signing.id
+	}
+
+	return token.SignedString(s.signing.private)
+}
+
+// Verify checks the signature of token with the key its kid names and
+// returns its claims. The token must be signed with the algorithm of that
+// key, must be unexpired and must not be used before its nbf, and must carry
+// the issuer and audience of s.
+func (s *KeySet) Verify(token string) (jwt.MapClaims, error) {
+	opts := []jwt.ParserOption{jwt.WithLeeway(s.leeway), jwt.WithExpirationRequired()}
+	if s.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(s.issuer))
+	}
+
+	if s.audience != "" {
+		opts = append(opts, jwt.WithAudience(s.audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		k, err := s.lookup(t)
+		if err != nil {
+			return nil, err
+		}
+
+		// Comparing the algorithm, rather than its family, keeps a token
+		// from getting an RSA public key used as an HMAC secret.
+		if t.Method.Alg() != k.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+
+		return k.public, nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (s *KeySet) lookup(t *jwt.Token) (*key, error) {
+	// The HMAC secret is the only key when there is one.
+	if len(s.keys) == 0 {
+		return s.signing, nil
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	k, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	return k, nil
+}
+
+// Handler serves the public keys as a JSON Web Key Set. It is empty when
+// tokens are signed with an HMAC secret.
+func (s *KeySet) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.Write(s.jwks)
+	}
+}
+
+// load reads the key cfg configures and checks that it suits its algorithm.
+func load(cfg config.JWTKey) (*key, error) {
+	if (cfg.PrivateKeyFile == "") == (cfg.PublicKeyFile == "") {
+		return nil, errors.New("exactly one of private_key_file and public_key_file is required")
+	}
+
+	path := cfg.PublicKeyFile
+	if cfg.PrivateKeyFile != "" {
+		path = cfg.PrivateKeyFile
+	}
+
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &key{id: cfg.ID}
+	private := cfg.PrivateKeyFile != ""
+
+	switch cfg.Algorithm {
+	case config.JWTRS256:
+		k.method = jwt.SigningMethodRS256
+		if private {
+			var priv *rsa.PrivateKey
+			priv, err = jwt.ParseRSAPrivateKeyFromPEM(pem)
+			k.private = priv
+			if err == nil {
+				k.public = &priv.PublicKey
+			}
+		} else {
+			k.public, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+		}
+
+		if err == nil && k.public.(*rsa.PublicKey).N.BitLen() < minRSABits {
+			err = fmt.Errorf("RSA keys must have at least %d bits", minRSABits)
+		}
+	case config.JWTES256:
+		k.method = jwt.SigningMethodES256
+		if private {
+			var priv *ecdsa.PrivateKey
+			priv, err = jwt.ParseECPrivateKeyFromPEM(pem)
+			k.private = priv
+			if err == nil {
+				k.public = &priv.PublicKey
+			}
+		} else {
+			k.public, err = jwt.ParseECPublicKeyFromPEM(pem)
+		}
+
+		if err == nil && k.public.(*ecdsa.PublicKey).Curve != elliptic.P256() {
+			err = errors.New("ES256 needs a P-256 key")
+		}
+	case config.JWTEdDSA:
+		k.method = jwt.SigningMethodEdDSA
+		if private {
+			var priv crypto.PrivateKey
+			priv, err = jwt.ParseEdPrivateKeyFromPEM(pem)
+			k.private = priv
+			if err == nil {
+				k.public = priv.(ed25519.PrivateKey).Public()
+			}
+		} else {
+			k.public, err = jwt.ParseEdPublicKeyFromPEM(pem)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return k, nil
+}
+
+// jwk is a public key in the form of RFC 7517.
+type jwk struct {
+	KeyType string `json:"kty"`
+	ID      string `json:"kid"`
+	Use     string `json:"use"`
+	Alg     string `json:"alg"`
+	Curve   string `json:"crv,omitempty"`
+	N       string `json:"n,omitempty"`
+	E       string `json:"e,omitempty"`
+	X       string `json:"x,omitempty"`
+	Y       string `json:"y,omitempty"`
+}
+
+// marshalJWKS encodes the public keys, in the order they were configured.
+func (s *KeySet) marshalJWKS(order []config.JWTKey) ([]byte, error) {
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{Keys: []jwk{}}
+
+	for _, kc := range order {
+		k := s.keys[kc.ID]
+		j := jwk{ID: k.id, Use: "sig", Alg: k.method.Alg()}
+
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			j.KeyType = "RSA"
+			j.N = encode(pub.N.Bytes())
+			j.E = encode(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			point, err := pub.ECDH()
+			if err != nil {
+				return nil, fmt.Errorf("JWT key %q: %w", k.id, err)
+			}
+
+			// The point is uncompressed: 0x04, then x and y of equal size.
+			b := point.Bytes()[1:]
+			j.KeyType = "EC"
+			j.Curve = "P-256"
+			j.X = encode(b[:len(b)/2])
+			j.Y = encode(b[len(b)/2:])
+		case ed25519.PublicKey:
+			j.KeyType = "OKP"
+			j.Curve = "Ed25519"
+			j.X = encode(pub)
+		}
+
+		set.Keys = append(set.Keys, j)
+	}
+
+	return json.Marshal(set)
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
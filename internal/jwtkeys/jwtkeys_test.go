@@ -0,0 +1,354 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notes-api/internal/config"
+)
+
+// writeKey writes the PKCS #8 private key and the PKIX public key of priv
+// to files in dir and returns their paths.
+func writeKey(t *testing.T, dir, name string, priv crypto.Signer) (string, string) {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	privPath := filepath.Join(dir, name+".pem")
+	require.NoError(t, os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	der, err = x509.MarshalPKIXPublicKey(priv.Public())
+	require.NoError(t, err)
+	pubPath := filepath.Join(dir, name+".pub.pem")
+	require.NoError(t, os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644))
+
+	return privPath, pubPath
+}
+
+// newKeyFiles writes a private and a public key file for each algorithm
+// and returns their paths by algorithm.
+func newKeyFiles(t *testing.T) (priv, pub map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	priv, pub = map[string]string{}, map[string]string{}
+	for alg, key := range map[string]crypto.Signer{config.JWTRS256: rsaKey, config.JWTES256: ecKey, config.JWTEdDSA: edKey} {
+		priv[alg], pub[alg] = writeKey(t, dir, alg, key)
+	}
+
+	return priv, pub
+}
+
+func claims(ttl time.Duration) jwt.MapClaims {
+	now := time.Now()
+
+	return jwt.MapClaims{
+		"sub": "1",
+		"iat": jwt.NewNumericDate(now),
+		"nbf": jwt.NewNumericDate(now),
+		"exp": jwt.NewNumericDate(now.Add(ttl)),
+	}
+}
+
+func TestHMAC(t *testing.T) {
+	keys, err := New(config.JWT{Issuer: "notes-api", Audience: "notes-api"}, []byte("secret"))
+	require.NoError(t, err)
+
+	token, err := keys.Sign(claims(time.Minute))
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "HS256", parsed.Method.Alg())
+	assert.NotContains(t, parsed.Header, "kid")
+
+	got, err := keys.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "1", got["sub"])
+	assert.Equal(t, "notes-api", got["iss"])
+
+	other, err := New(config.JWT{Issuer: "notes-api", Audience: "notes-api"}, []byte("other"))
+	require.NoError(t, err)
+	_, err = other.Verify(token)
+	assert.Error(t, err)
+
+	rec := httptest.NewRecorder()
+	keys.Handler()(rec, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+	assert.JSONEq(t, `{"keys":[]}`, rec.Body.String())
+
+	_, err = New(config.JWT{}, nil)
+	assert.Error(t, err)
+}
+
+func TestAlgorithms(t *testing.T) {
+	priv, _ := newKeyFiles(t)
+
+	for _, alg := range []string{config.JWTRS256, config.JWTES256, config.JWTEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			keys, err := New(config.JWT{
+				Issuer:     "notes-api",
+				Audience:   "notes-api",
+				SigningKey: "k1",
+				Keys:       []config.JWTKey{{ID: "k1", Algorithm: alg, PrivateKeyFile: priv[alg]}},
+			}, []byte("secret"))
+			require.NoError(t, err)
+
+			token, err := keys.Sign(claims(time.Minute))
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, alg, parsed.Method.Alg())
+			assert.Equal(t, "k1", parsed.Header["kid"])
+
+			_, err = keys.Verify(token)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// A token signed before a rotation is still accepted while the key that
+// signed it is listed, even with only its public half.
+func TestRotation(t *testing.T) {
+	priv, pub := newKeyFiles(t)
+
+	before, err := New(config.JWT{
+		SigningKey: "2025",
+		Keys:       []config.JWTKey{{ID: "2025", Algorithm: config.JWTRS256, PrivateKeyFile: priv[config.JWTRS256]}},
+	}, nil)
+	require.NoError(t, err)
+
+	oldToken, err := before.Sign(claims(time.Minute))
+	require.NoError(t, err)
+
+	after, err := New(config.JWT{
+		SigningKey: "2026",
+		Keys: []config.JWTKey{
+			{ID: "2026", Algorithm: config.JWTEdDSA, PrivateKeyFile: priv[config.JWTEdDSA]},
+			{ID: "2025", Algorithm: config.JWTRS256, PublicKeyFile: pub[config.JWTRS256]},
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = after.Verify(oldToken)
+	assert.NoError(t, err)
+
+	newToken, err := after.Sign(claims(time.Minute))
+	require.NoError(t, err)
+	_, err = after.Verify(newToken)
+	assert.NoError(t, err)
+
+	// Once the old key is dropped, its tokens are refused.
+	_, err = before.Verify(newToken)
+	assert.Error(t, err)
+}
+
+func TestVerifyRejects(t *testing.T) {
+	priv, pub := newKeyFiles(t)
+	cfg := config.JWT{
+		Issuer:     "notes-api",
+		Audience:   "notes-api",
+		SigningKey: "rs",
+		Keys:       []config.JWTKey{{ID: "rs", Algorithm: config.JWTRS256, PrivateKeyFile: priv[config.JWTRS256]}},
+	}
+	keys, err := New(cfg, []byte("secret"))
+	require.NoError(t, err)
+
+	sign := func(c jwt.MapClaims, edit func(jwt.MapClaims)) string {
+		edit(c)
+		token, err := keys.Sign(c)
+		require.NoError(t, err)
+
+		return token
+	}
+
+	for name, token := range map[string]string{
+		"expired": sign(claims(-time.Hour), func(jwt.MapClaims) {}),
+		"not yet valid": sign(claims(time.Hour), func(c jwt.MapClaims) {
+			c["nbf"] = jwt.NewNumericDate(time.Now().Add(time.Hour))
+		}),
+		"without expiry": sign(claims(time.Hour), func(c jwt.MapClaims) { delete(c, "exp") }),
+	} {
+		_, err := keys.Verify(token)
+		assert.Error(t, err, name)
+	}
+
+	// Another issuer or audience.
+	for _, other := range []config.JWT{
+		{Issuer: "elsewhere", Audience: cfg.Audience, SigningKey: cfg.SigningKey, Keys: cfg.Keys},
+		{Issuer: cfg.Issuer, Audience: "elsewhere", SigningKey: cfg.SigningKey, Keys: cfg.Keys},
+	} {
+		signer, err := New(other, nil)
+		require.NoError(t, err)
+
+		token, err := signer.Sign(claims(time.Minute))
+		require.NoError(t, err)
+
+		_, err = keys.Verify(token)
+		assert.Error(t, err)
+	}
+
+	valid := claims(time.Minute)
+	valid["iss"], valid["aud"] = cfg.Issuer, cfg.Audience
+
+	// A token signed with HS256 and the public key as the secret must not
+	// be checked with the public key as an HMAC secret.
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, valid)
+	forged.Header["kid"] = "rs"
+	token, err := forged.SignedString(mustRead(t, pub[config.JWTRS256]))
+	require.NoError(t, err)
+	_, err = keys.Verify(token)
+	assert.Error(t, err)
+
+	unsigned := jwt.NewWithClaims(jwt.SigningMethodNone, valid)
+	unsigned.Header["kid"] = "rs"
+	token, err = unsigned.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = keys.Verify(token)
+	assert.Error(t, err)
+
+	// Unknown and missing key IDs.
+	rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(mustRead(t, priv[config.JWTRS256]))
+	require.NoError(t, err)
+
+	for _, kid := range []any{"other", nil} {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, valid)
+		if kid != nil {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(rsaKey)
+		require.NoError(t, err)
+
+		_, err = keys.Verify(signed)
+		assert.Error(t, err, kid)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	priv, pub := newKeyFiles(t)
+	keys, err := New(config.JWT{
+		SigningKey: "ec",
+		Keys: []config.JWTKey{
+			{ID: "ec", Algorithm: config.JWTES256, PrivateKeyFile: priv[config.JWTES256]},
+			{ID: "rs", Algorithm: config.JWTRS256, PublicKeyFile: pub[config.JWTRS256]},
+			{ID: "ed", Algorithm: config.JWTEdDSA, PublicKeyFile: pub[config.JWTEdDSA]},
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	keys.Handler()(rec, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &set))
+	require.Len(t, set.Keys, 3)
+
+	// Every key rebuilt from the set matches the configured one.
+	for i, want := range []string{"ec", "rs", "ed"} {
+		j := set.Keys[i]
+		assert.Equal(t, want, j["kid"])
+		assert.Equal(t, "sig", j["use"])
+
+		var got crypto.PublicKey
+		switch j["kty"] {
+		case "EC":
+			assert.Equal(t, "P-256", j["crv"])
+			got = &ecdsa.PublicKey{Curve: elliptic.P256(), X: decodeInt(t, j["x"]), Y: decodeInt(t, j["y"])}
+		case "RSA":
+			got = &rsa.PublicKey{N: decodeInt(t, j["n"]), E: int(decodeInt(t, j["e"]).Int64())}
+		case "OKP":
+			assert.Equal(t, "Ed25519", j["crv"])
+			got = ed25519.PublicKey(decode(t, j["x"]))
+		default:
+			t.Fatalf("unexpected key type %q", j["kty"])
+		}
+
+		assert.Equal(t, keys.keys[want].method.Alg(), j["alg"])
+		assert.True(t, got.(interface{ Equal(crypto.PublicKey) bool }).Equal(keys.keys[want].public), want)
+	}
+}
+
+func TestNewRejects(t *testing.T) {
+	priv, pub := newKeyFiles(t)
+	dir := t.TempDir()
+
+	smallRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	smallPath, _ := writeKey(t, dir, "small", smallRSA)
+
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	p384Path, _ := writeKey(t, dir, "p384", p384)
+
+	for name, keys := range map[string][]config.JWTKey{
+		"public signing key":  {{ID: "k", Algorithm: config.JWTRS256, PublicKeyFile: pub[config.JWTRS256]}},
+		"duplicate id":        {{ID: "k", Algorithm: config.JWTRS256, PrivateKeyFile: priv[config.JWTRS256]}, {ID: "k", Algorithm: config.JWTEdDSA, PrivateKeyFile: priv[config.JWTEdDSA]}},
+		"no id":               {{Algorithm: config.JWTRS256, PrivateKeyFile: priv[config.JWTRS256]}},
+		"unknown algorithm":   {{ID: "k", Algorithm: "HS256", PrivateKeyFile: priv[config.JWTRS256]}},
+		"wrong key type":      {{ID: "k", Algorithm: config.JWTEdDSA, PrivateKeyFile: priv[config.JWTRS256]}},
+		"small RSA key":       {{ID: "k", Algorithm: config.JWTRS256, PrivateKeyFile: smallPath}},
+		"P-384 key for ES256": {{ID: "k", Algorithm: config.JWTES256, PrivateKeyFile: p384Path}},
+		"missing file":        {{ID: "k", Algorithm: config.JWTRS256, PrivateKeyFile: filepath.Join(dir, "missing.pem")}},
+		"both files":          {{ID: "k", Algorithm: config.JWTRS256, PrivateKeyFile: priv[config.JWTRS256], PublicKeyFile: pub[config.JWTRS256]}},
+		"no file":             {{ID: "k", Algorithm: config.JWTRS256}},
+	} {
+		_, err := New(config.JWT{SigningKey: "k", Keys: keys}, nil)
+		assert.Error(t, err, name)
+	}
+
+	_, err = New(config.JWT{
+		SigningKey: "other",
+		Keys:       []config.JWTKey{{ID: "k", Algorithm: config.JWTRS256, PrivateKeyFile: priv[config.JWTRS256]}},
+	}, nil)
+	assert.Error(t, err)
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	return b
+}
+
+func decode(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	require.NoError(t, err)
+
+	return b
+}
+
+func decodeInt(t *testing.T, s string) *big.Int {
+	return new(big.Int).SetBytes(decode(t, s))
+}
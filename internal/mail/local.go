@@ -0,0 +1,86 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Log writes messages to the log instead of sending them. Whatever secrets
+// they carry end up in the log, so it is only fit for local development.
+type Log struct {
+	From string
+	Log  *slog.Logger
+}
+
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.Log.Info("mail",
+		slog.String("component", "mail/log"),
+		slog.String("from", l.From),
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("body", msg.Body),
+	)
+
+	return nil
+}
+
+// Dir writes every message to a file of its own in the directory at Path,
+// which it creates if needed. The files are named after the time they were
+// written and end in .eml, so mail clients open them.
+type Dir struct {
+	From string
+	Path string
+}
+
+func (d *Dir) Send(ctx context.Context, msg Message) error {
+	const op = "mail.Dir.Send"
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	raw, err := format(d.From, msg, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.MkdirAll(d.Path, 0o700); err != nil {
+		return fmt.Errorf("%s: failed to create directory: %w", op, err)
+	}
+
+	// Several messages can be written in the same nanosecond on platforms
+	// with a coarse clock; O_EXCL makes the later ones pick another name.
+	name := now.UTC().Format("20060102T150405.000000000Z")
+	for i := 0; ; i++ {
+		path := filepath.Join(d.Path, fmt.Sprintf("%s-%d.eml", name, i))
+
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: failed to create file: %w", op, err)
+		}
+
+		if _, err := f.Write(raw); err != nil {
+			f.Close()
+			return fmt.Errorf("%s: failed to write message: %w", op, err)
+		}
+
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("%s: failed to write message: %w", op, err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,96 @@
+// Package mail delivers the messages the API sends to users, such as
+// password reset links. A Mailer either hands them to an SMTP server or, for
+// local development and tests, writes them to the log or to files.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"notes-api/internal/config"
+	"strings"
+	"time"
+)
+
+// Message is a plain text message to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the mailer cfg selects, or nil if mail is disabled.
+func New(cfg config.Mail, log *slog.Logger) Mailer {
+	switch cfg.Transport {
+	case config.MailLog:
+		return &Log{From: cfg.From, Log: log}
+	case config.MailDir:
+		return &Dir{From: cfg.From, Path: cfg.Dir}
+	case config.MailSMTP:
+		return &SMTP{
+			From:     cfg.From,
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+		}
+	default:
+		return nil
+	}
+}
+
+// format renders msg from from as an RFC 5322 message with CRLF line
+// endings, ready to be sent over SMTP.
+func format(from string, msg Message, now time.Time) ([]byte, error) {
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %w", from, err)
+	}
+
+	toAddr, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient %q: %w", msg.To, err)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate message ID: %w", err)
+	}
+	domain := fromAddr.Address[strings.LastIndexByte(fromAddr.Address, '@')+1:]
+
+	var b bytes.Buffer
+	header := func(name, value string) {
+		b.WriteString(name + ": " + value + "\r\n")
+	}
+
+	header("From", fromAddr.String())
+	header("To", toAddr.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", "<"+hex.EncodeToString(id)+"@"+domain+">")
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	b.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&b)
+	body := strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := w.Write([]byte(body)); err != nil {
+		return nil, fmt.Errorf("failed to encode body: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode body: %w", err)
+	}
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,182 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var msg = Message{
+	To:      "Alice <alice@example.com>",
+	Subject: "Réinitialiser",
+	Body:    "Open this link:\n\nhttps://notes.example.com/reset?token=" + strings.Repeat("a", 64) + "\n",
+}
+
+// parse reads a formatted message back and decodes its body.
+func parse(t *testing.T, raw []byte) (*mail.Message, string) {
+	t.Helper()
+
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+
+	date, err := m.Header.Date()
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), date, time.Minute)
+
+	body, err := io.ReadAll(quotedprintable.NewReader(m.Body))
+	require.NoError(t, err)
+
+	return m, string(body)
+}
+
+func TestFormat(t *testing.T) {
+	raw, err := format("Notes <notes@example.com>", msg, time.Now())
+	require.NoError(t, err)
+
+	assert.NotContains(t, strings.ReplaceAll(string(raw), "\r\n", ""), "\n", "lines must end in CRLF")
+
+	m, body := parse(t, raw)
+	assert.Equal(t, `"Notes" <notes@example.com>`, m.Header.Get("From"))
+	assert.Equal(t, `"Alice" <alice@example.com>`, m.Header.Get("To"))
+	assert.Regexp(t, `^<[0-9a-f]{32}@example\.com>$`, m.Header.Get("Message-ID"))
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, msg.Subject, subject)
+
+	// The long link is wrapped with soft line breaks, which decoding undoes.
+	assert.Equal(t, strings.ReplaceAll(msg.Body, "\n", "\r\n"), body)
+
+	_, err = format("Notes <notes@example.com>", Message{To: "not an address"}, time.Now())
+	assert.Error(t, err)
+}
+
+func TestDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	d := &Dir{From: "notes@example.com", Path: dir}
+
+	for range 3 {
+		require.NoError(t, d.Send(t.Context(), msg))
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	for _, e := range entries {
+		assert.True(t, strings.HasSuffix(e.Name(), ".eml"), e.Name())
+
+		raw, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		require.NoError(t, err)
+
+		m, _ := parse(t, raw)
+		assert.Equal(t, `"Alice" <alice@example.com>`, m.Header.Get("To"))
+	}
+}
+
+func TestLog(t *testing.T) {
+	var out bytes.Buffer
+	l := &Log{From: "notes@example.com", Log: slog.New(slog.NewTextHandler(&out, nil))}
+
+	require.NoError(t, l.Send(t.Context(), msg))
+	assert.Contains(t, out.String(), "alice@example.com")
+	assert.Contains(t, out.String(), "token="+strings.Repeat("a", 64))
+}
+
+func TestSMTP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan []string, 1)
+	go serveSMTP(ln, received)
+
+	s := &SMTP{From: "notes@example.com", Host: "127.0.0.1", Port: ln.Addr().(*net.TCPAddr).Port}
+	require.NoError(t, s.Send(t.Context(), msg))
+
+	commands := <-received
+	assert.Contains(t, commands, "MAIL FROM:<notes@example.com>")
+	assert.Contains(t, commands, "RCPT TO:<alice@example.com>")
+	assert.Contains(t, commands, "QUIT")
+}
+
+func TestSMTPContext(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	// A server that accepts but never greets.
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	s := &SMTP{From: "notes@example.com", Host: "127.0.0.1", Port: ln.Addr().(*net.TCPAddr).Port}
+	assert.Error(t, s.Send(ctx, msg))
+}
+
+// serveSMTP answers a single session as an SMTP server without extensions
+// would, and reports the commands it was sent.
+func serveSMTP(ln net.Listener, received chan<- []string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+
+	var commands []string
+	defer func() { received <- commands }()
+
+	reply("220 localhost ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		commands = append(commands, line)
+
+		verb, _, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "DATA":
+			reply("354 go ahead")
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+			}
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
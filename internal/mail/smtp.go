@@ -0,0 +1,106 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// implicitTLSPort is the submission port that speaks TLS from the start
+// rather than upgrading with STARTTLS.
+const implicitTLSPort = 465
+
+// SMTP sends messages through the server at Host:Port. It upgrades the
+// connection with STARTTLS when the server offers it, or uses TLS from the
+// start on port 465, and authenticates as Username if set; credentials are
+// never sent unencrypted except to localhost.
+type SMTP struct {
+	From     string
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) (err error) {
+	const op = "mail.SMTP.Send"
+
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%s: %w", op, err)
+		}
+	}()
+
+	raw, err := format(s.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// format has checked that both addresses parse.
+	from, _ := mail.ParseAddress(s.From)
+	to, _ := mail.ParseAddress(msg.To)
+
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+
+	var conn net.Conn
+	if s.Port == implicitTLSPort {
+		conn, err = (&tls.Dialer{Config: &tls.Config{ServerName: s.Host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	// net/smtp knows nothing of contexts; a deadline on the connection makes
+	// every exchange give up when ctx would.
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to greet server: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && s.Port != implicitTLSPort {
+		if err := c.StartTLS(&tls.Config{ServerName: s.Host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("sender rejected: %w", err)
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("recipient rejected: %w", err)
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message: %w", err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+
+	return c.Quit()
+}
@@ -2,69 +2,154 @@ package middleware
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
+	"notes-api/internal/models"
+	"notes-api/internal/problem"
+	store "notes-api/internal/storage"
 	"notes-api/internal/utils"
+	"strconv"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func JWTAuthMiddleware(secret []byte) func(next http.Handler) http.Handler {
+// TokenVerifier checks the signature and registered claims of an access
+// token and returns its claims.
+type TokenVerifier interface {
+	Verify(token string) (jwt.MapClaims, error)
+}
+
+type TokenDenylist interface {
+	AccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// AuthFailureRecorder counts the requests the auth middleware rejects, by
+// reason.
+type AuthFailureRecorder interface {
+	AuthFailure(reason string)
+}
+
+type TokenAuthenticator interface {
+	AccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	AuthenticatePersonalAccessToken(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error)
+}
+
+// JWTAuthMiddleware authenticates requests with a bearer access token and
+// rejects tokens whose jti has been revoked. Routes behind it can only be
+// reached from a login session.
+func JWTAuthMiddleware(verifier TokenVerifier, denylist TokenDenylist, failures AuthFailureRecorder) func(next http.Handler) http.Handler {
+	return authMiddleware(verifier, denylist, nil, failures)
+}
+
+// TokenAuthMiddleware accepts personal access tokens as well as access
+// tokens. Requests made with a personal access token carry its scopes, which
+// RequireScope checks.
+func TokenAuthMiddleware(verifier TokenVerifier, storage TokenAuthenticator, failures AuthFailureRecorder) func(next http.Handler) http.Handler {
+	return authMiddleware(verifier, storage, storage, failures)
+}
+
+func authMiddleware(verifier TokenVerifier, denylist TokenDenylist, pats TokenAuthenticator, failures AuthFailureRecorder) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Type", "application/json")
-			encoder := json.NewEncoder(w)
-
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				w.WriteHeader(http.StatusUnauthorized)
-				encoder.Encode(map[string]string{"Unauthorized": "Authorization header is required"})
+				failures.AuthFailure("missing_header")
+				problem.Write(w, r, problem.Unauthorized, "Authorization header is required")
 
 				return
 			}
 
 			parts := strings.SplitN(authHeader, " ", 2)
 			if len(parts) != 2 || parts[0] != "Bearer" {
-				w.WriteHeader(http.StatusUnauthorized)
-				encoder.Encode(map[string]string{"Unauthorized": "Invalid authorization format"})
+				failures.AuthFailure("invalid_format")
+				problem.Write(w, r, problem.Unauthorized, "Invalid authorization format")
 
 				return
 			}
 
 			tokenString := parts[1]
-			token, err := jwt.Parse(tokenString, func(t *jwt.Token) (any, error) {
-				if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+
+			if pats != nil && strings.HasPrefix(tokenString, models.PersonalAccessTokenPrefix) {
+				pat, err := pats.AuthenticatePersonalAccessToken(r.Context(), utils.HashToken(tokenString))
+				if err != nil {
+					if problem.WriteContextError(w, r, err) {
+						return
+					}
+
+					if errors.Is(err, store.ErrTokenNotFound) {
+						failures.AuthFailure("invalid_personal_token")
+						problem.Write(w, r, problem.Unauthorized, "Invalid or expired personal access token")
+
+						return
+					}
+
+					problem.Write(w, r, problem.Internal, "Failed to check token")
+
+					return
 				}
-				return []byte(secret), nil
-			})
 
-			if err != nil || !token.Valid {
-				w.WriteHeader(http.StatusUnauthorized)
-				encoder.Encode(map[string]string{"Unauthorized": "Invalid token"})
+				ctx := context.WithValue(r.Context(), utils.UserIDKey, strconv.FormatInt(pat.UserID, 10))
+				ctx = context.WithValue(ctx, utils.ScopesKey, pat.Scopes)
+
+				next.ServeHTTP(w, r.WithContext(ctx))
 
 				return
 			}
 
-			claims, ok := token.Claims.(jwt.MapClaims)
-			if !ok {
-				w.WriteHeader(http.StatusUnauthorized)
-				encoder.Encode(map[string]string{"Unauthorized": "Invalid token claims"})
+			claims, err := verifier.Verify(tokenString)
+			if err != nil {
+				failures.AuthFailure("invalid_token")
+				problem.Write(w, r, problem.Unauthorized, "Invalid token")
 
 				return
 			}
 
 			userID, ok := claims["sub"].(string)
 			if !ok {
-				w.WriteHeader(http.StatusUnauthorized)
-				encoder.Encode(map[string]string{"Unauthorized": "Invalid token subject"})
+				failures.AuthFailure("invalid_claims")
+				problem.Write(w, r, problem.Unauthorized, "Invalid token subject")
+
+				return
+			}
+
+			jti, ok := claims["jti"].(string)
+			if !ok || jti == "" {
+				failures.AuthFailure("invalid_claims")
+				problem.Write(w, r, problem.Unauthorized, "Invalid token ID")
+
+				return
+			}
+
+			expiresAt, err := claims.GetExpirationTime()
+			if err != nil || expiresAt == nil {
+				failures.AuthFailure("invalid_claims")
+				problem.Write(w, r, problem.Unauthorized, "Invalid token expiry")
+
+				return
+			}
+
+			revoked, err := denylist.AccessTokenRevoked(r.Context(), jti)
+			if err != nil {
+				if problem.WriteContextError(w, r, err) {
+					return
+				}
+
+				problem.Write(w, r, problem.Internal, "Failed to check token")
+
+				return
+			}
+
+			if revoked {
+				failures.AuthFailure("revoked_token")
+				problem.Write(w, r, problem.Unauthorized, "Token has been revoked")
 
 				return
 			}
 
 			ctx := context.WithValue(r.Context(), utils.UserIDKey, userID)
+			ctx = context.WithValue(ctx, utils.TokenIDKey, jti)
+			ctx = context.WithValue(ctx, utils.TokenExpiresAtKey, expiresAt.Time)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		}
@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"notes-api/internal/models"
+	store "notes-api/internal/storage"
+	"notes-api/internal/utils"
+)
+
+var testExpiry = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "7", "jti": "test-jti", "exp": float64(testExpiry.Unix())}
+}
+
+// authenticate sends a request with the authorization header through
+// middleware and returns the response along with the request that reached
+// the handler behind it, if one did.
+func authenticate(middleware func(http.Handler) http.Handler, authorization string) (*httptest.ResponseRecorder, *http.Request) {
+	r := httptest.NewRequest(http.MethodGet, "/notes", nil)
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+
+	return through(middleware, r)
+}
+
+func TestJWTAuthMiddleware(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		authorization string
+		claims        jwt.MapClaims
+		revoked       bool
+		denylistErr   error
+		code          int
+		reason        string
+	}{
+		{name: "valid", authorization: "Bearer token", claims: testClaims(), code: http.StatusNoContent},
+		{name: "revoked", authorization: "Bearer token", claims: testClaims(), revoked: true, code: http.StatusUnauthorized, reason: "revoked_token"},
+		{name: "denylist failure", authorization: "Bearer token", claims: testClaims(), denylistErr: errors.New("disk on fire"), code: http.StatusInternalServerError},
+		{name: "no jti", authorization: "Bearer token", claims: jwt.MapClaims{"sub": "7", "exp": float64(testExpiry.Unix())}, code: http.StatusUnauthorized, reason: "invalid_claims"},
+		{name: "invalid token", authorization: "Bearer token", code: http.StatusUnauthorized, reason: "invalid_token"},
+		{name: "no header", code: http.StatusUnauthorized, reason: "missing_header"},
+		{name: "not a bearer token", authorization: "Basic dXNlcjpwYXNz", code: http.StatusUnauthorized, reason: "invalid_format"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, denylist, failures := NewMockTokenVerifier(t), NewMockTokenDenylist(t), NewMockAuthFailureRecorder(t)
+
+			if tt.claims != nil {
+				verifier.EXPECT().Verify("token").Return(tt.claims, nil).Once()
+			} else if tt.reason == "invalid_token" {
+				verifier.EXPECT().Verify("token").Return(nil, jwt.ErrTokenExpired).Once()
+			}
+			if tt.claims["jti"] != nil {
+				denylist.EXPECT().AccessTokenRevoked(mock.Anything, "test-jti").Return(tt.revoked, tt.denylistErr).Once()
+			}
+			if tt.reason != "" {
+				failures.EXPECT().AuthFailure(tt.reason).Return().Once()
+			}
+
+			rec, reached := authenticate(JWTAuthMiddleware(verifier, denylist, failures), tt.authorization)
+			assert.Equal(t, tt.code, rec.Code)
+
+			if tt.code != http.StatusNoContent {
+				assert.Nil(t, reached)
+				if tt.reason != "" {
+					assert.Equal(t, "unauthorized", problemCode(t, rec))
+				}
+				return
+			}
+
+			require.NotNil(t, reached)
+			assert.Equal(t, "7", reached.Context().Value(utils.UserIDKey))
+			assert.Equal(t, "test-jti", reached.Context().Value(utils.TokenIDKey))
+			expiresAt, _ := reached.Context().Value(utils.TokenExpiresAtKey).(time.Time)
+			assert.True(t, testExpiry.Equal(expiresAt), expiresAt)
+		})
+	}
+}
+
+func TestTokenAuthMiddleware(t *testing.T) {
+	const pat = models.PersonalAccessTokenPrefix + "secret"
+
+	for _, tt := range []struct {
+		name          string
+		authorization string
+		expect        func(verifier *MockTokenVerifier, storage *MockTokenAuthenticator)
+		code          int
+		reason        string
+		scopes        []string
+	}{
+		{
+			name:          "personal access token",
+			authorization: "Bearer " + pat,
+			expect: func(verifier *MockTokenVerifier, storage *MockTokenAuthenticator) {
+				storage.EXPECT().AuthenticatePersonalAccessToken(mock.Anything, utils.HashToken(pat)).
+					Return(&models.PersonalAccessToken{UserID: 7, Scopes: []string{models.ScopeNotesRead}}, nil).Once()
+			},
+			code:   http.StatusNoContent,
+			scopes: []string{models.ScopeNotesRead},
+		},
+		{
+			name:          "unknown personal access token",
+			authorization: "Bearer " + pat,
+			expect: func(verifier *MockTokenVerifier, storage *MockTokenAuthenticator) {
+				storage.EXPECT().AuthenticatePersonalAccessToken(mock.Anything, utils.HashToken(pat)).Return(nil, store.ErrTokenNotFound).Once()
+			},
+			code:   http.StatusUnauthorized,
+			reason: "invalid_personal_token",
+		},
+		{
+			name:          "storage failure",
+			authorization: "Bearer " + pat,
+			expect: func(verifier *MockTokenVerifier, storage *MockTokenAuthenticator) {
+				storage.EXPECT().AuthenticatePersonalAccessToken(mock.Anything, utils.HashToken(pat)).Return(nil, errors.New("disk on fire")).Once()
+			},
+			code: http.StatusInternalServerError,
+		},
+		// Only the prefix makes a token a personal access token; everything
+		// else is verified as an access token and checked against the
+		// denylist.
+		{
+			name:          "access token",
+			authorization: "Bearer token",
+			expect: func(verifier *MockTokenVerifier, storage *MockTokenAuthenticator) {
+				verifier.EXPECT().Verify("token").Return(testClaims(), nil).Once()
+				storage.EXPECT().AccessTokenRevoked(mock.Anything, "test-jti").Return(false, nil).Once()
+			},
+			code: http.StatusNoContent,
+		},
+		{
+			name:          "revoked access token",
+			authorization: "Bearer token",
+			expect: func(verifier *MockTokenVerifier, storage *MockTokenAuthenticator) {
+				verifier.EXPECT().Verify("token").Return(testClaims(), nil).Once()
+				storage.EXPECT().AccessTokenRevoked(mock.Anything, "test-jti").Return(true, nil).Once()
+			},
+			code:   http.StatusUnauthorized,
+			reason: "revoked_token",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, storage, failures := NewMockTokenVerifier(t), NewMockTokenAuthenticator(t), NewMockAuthFailureRecorder(t)
+			tt.expect(verifier, storage)
+			if tt.reason != "" {
+				failures.EXPECT().AuthFailure(tt.reason).Return().Once()
+			}
+
+			rec, reached := authenticate(TokenAuthMiddleware(verifier, storage, failures), tt.authorization)
+			assert.Equal(t, tt.code, rec.Code)
+
+			if tt.code != http.StatusNoContent {
+				assert.Nil(t, reached)
+				return
+			}
+
+			require.NotNil(t, reached)
+			assert.Equal(t, "7", reached.Context().Value(utils.UserIDKey))
+
+			scopes, ok := reached.Context().Value(utils.ScopesKey).([]string)
+			assert.Equal(t, tt.scopes != nil, ok, "only personal access tokens carry scopes")
+			assert.Equal(t, tt.scopes, scopes)
+		})
+	}
+}
+
+// Routes that only take access tokens verify personal access tokens as
+// such, which fails.
+func TestJWTAuthMiddlewareRejectsPersonalAccessTokens(t *testing.T) {
+	const pat = models.PersonalAccessTokenPrefix + "secret"
+
+	verifier, failures := NewMockTokenVerifier(t), NewMockAuthFailureRecorder(t)
+	verifier.EXPECT().Verify(pat).Return(nil, jwt.ErrTokenMalformed).Once()
+	failures.EXPECT().AuthFailure("invalid_token").Return().Once()
+
+	rec, reached := authenticate(JWTAuthMiddleware(verifier, NewMockTokenDenylist(t), failures), "Bearer "+pat)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Nil(t, reached)
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// through sends r through middleware and returns the response along with
+// the request that reached the handler behind it, if one did.
+func through(middleware func(http.Handler) http.Handler, r *http.Request) (*httptest.ResponseRecorder, *http.Request) {
+	var reached *http.Request
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+
+	return rec, reached
+}
+
+// problemCode returns the code of the problem document in rec.
+func problemCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body), rec.Body.String())
+
+	return body.Code
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func LoggerMiddleware(log *slog.Logger) func(next http.Handler) http.Handler {
@@ -25,6 +26,15 @@ func LoggerMiddleware(log *slog.Logger) func(next http.Handler) http.Handler {
 				slog.String("request_id", middleware.GetReqID(r.Context())),
 			)
 
+			// The tracing middleware runs first, so the request is already
+			// part of a trace.
+			if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+				entry = entry.With(
+					slog.String("trace_id", sc.TraceID().String()),
+					slog.String("span_id", sc.SpanID().String()),
+				)
+			}
+
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 
 			t1 := time.Now()
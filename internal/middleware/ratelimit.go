@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"notes-api/internal/problem"
+	"notes-api/internal/ratelimit"
+	"notes-api/internal/utils"
+	"notes-api/pkg/logger"
+	"strconv"
+)
+
+type RateLimiter interface {
+	Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error)
+}
+
+// RateLimitRecorder counts the requests a rate limit rejects, by the name of
+// the limit.
+type RateLimitRecorder interface {
+	RateLimited(limit string)
+}
+
+// RateLimitByIP limits the requests of every client IP address. The address
+// is the one the connection comes from, so behind a reverse proxy all
+// clients share one limit.
+func RateLimitByIP(log *slog.Logger, limiter RateLimiter, limit ratelimit.Limit, failOpen bool, rejections RateLimitRecorder) func(next http.Handler) http.Handler {
+	return rateLimit(log, limiter, limit, failOpen, rejections, "ip", func(r *http.Request) string {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return r.RemoteAddr
+		}
+
+		return host
+	})
+}
+
+// RateLimitByUser limits the requests of every user, whichever token they
+// authenticate with. It must run behind the auth middleware.
+func RateLimitByUser(log *slog.Logger, limiter RateLimiter, limit ratelimit.Limit, failOpen bool, rejections RateLimitRecorder) func(next http.Handler) http.Handler {
+	return rateLimit(log, limiter, limit, failOpen, rejections, "user", func(r *http.Request) string {
+		userID, _ := r.Context().Value(utils.UserIDKey).(string)
+		return userID
+	})
+}
+
+// rateLimit takes a token from the bucket of the client key identifies for
+// every request, and rejects the request with 429 when there is none. All
+// responses tell the client where it stands in RateLimit-* headers. Should
+// the limiter fail, requests pass if failOpen, as an outage of the limiter
+// is not worth one of the API, and are rejected with 503 otherwise.
+func rateLimit(log *slog.Logger, limiter RateLimiter, limit ratelimit.Limit, failOpen bool, rejections RateLimitRecorder, name string, key func(r *http.Request) string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !limit.Enabled() {
+			return next
+		}
+
+		log := log.With(slog.String("component", "middleware/ratelimit"), slog.String("limit", name))
+		policy := strconv.Itoa(limit.Requests) + ";w=" + strconv.Itoa(ratelimit.Seconds(limit.Period))
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			res, err := limiter.Take(r.Context(), name+":"+key(r), limit)
+			if err != nil {
+				if problem.WriteContextError(w, r, err) {
+					return
+				}
+
+				log.Error("failed to take rate limit token", logger.Err(err))
+
+				if failOpen {
+					next.ServeHTTP(w, r)
+				} else {
+					problem.Write(w, r, problem.RateLimitUnavailable, "Rate limiter is unavailable, retry later")
+				}
+
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Policy", policy)
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ratelimit.Seconds(res.Reset)))
+
+			if !res.Allowed {
+				rejections.RateLimited(name)
+
+				h.Set("Retry-After", strconv.Itoa(ratelimit.Seconds(res.RetryAfter)))
+				problem.Write(w, r, problem.RateLimited, "Rate limit exceeded, retry later")
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"notes-api/internal/ratelimit"
+	"notes-api/internal/utils"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestRateLimitByUser(t *testing.T) {
+	limit := ratelimit.Limit{Requests: 10, Period: time.Minute}
+	failure := errors.New("database is locked")
+
+	for _, tt := range []struct {
+		name     string
+		limit    ratelimit.Limit
+		result   ratelimit.Result
+		err      error
+		failOpen bool
+		code     int
+		problem  string
+		headers  map[string]string
+	}{
+		{
+			name:   "allowed",
+			limit:  limit,
+			result: ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9, Reset: 6 * time.Second},
+			code:   http.StatusNoContent,
+			headers: map[string]string{
+				"RateLimit-Policy":    "10;w=60",
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "9",
+				"RateLimit-Reset":     "6",
+			},
+		},
+		{
+			name:    "rejected",
+			limit:   limit,
+			result:  ratelimit.Result{Limit: 10, Reset: time.Minute, RetryAfter: 6 * time.Second},
+			code:    http.StatusTooManyRequests,
+			problem: "rate_limited",
+			headers: map[string]string{"RateLimit-Remaining": "0", "Retry-After": "6"},
+		},
+		{
+			name:     "limiter failure, failing open",
+			limit:    limit,
+			err:      failure,
+			failOpen: true,
+			code:     http.StatusNoContent,
+			headers:  map[string]string{"RateLimit-Limit": ""},
+		},
+		{
+			name:    "limiter failure, failing closed",
+			limit:   limit,
+			err:     failure,
+			code:    http.StatusServiceUnavailable,
+			problem: "rate_limit_unavailable",
+			headers: map[string]string{"RateLimit-Limit": ""},
+		},
+		{name: "disabled", code: http.StatusNoContent},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, rejections := NewMockRateLimiter(t), NewMockRateLimitRecorder(t)
+			if tt.limit.Enabled() {
+				limiter.EXPECT().Take(mock.Anything, "user:7", tt.limit).Return(tt.result, tt.err).Once()
+			}
+			if tt.problem == "rate_limited" {
+				rejections.EXPECT().RateLimited("user").Return().Once()
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/notes", nil)
+			r = r.WithContext(context.WithValue(r.Context(), utils.UserIDKey, "7"))
+
+			rec, reached := through(RateLimitByUser(discard, limiter, tt.limit, tt.failOpen, rejections), r)
+			assert.Equal(t, tt.code, rec.Code)
+			assert.Equal(t, tt.problem == "", reached != nil)
+			for name, value := range tt.headers {
+				assert.Equal(t, value, rec.Header().Get(name), name)
+			}
+			if tt.problem != "" {
+				assert.Equal(t, tt.problem, problemCode(t, rec))
+			}
+		})
+	}
+}
+
+func TestRateLimitByIP(t *testing.T) {
+	limit := ratelimit.Limit{Requests: 10, Period: time.Minute}
+
+	limiter := NewMockRateLimiter(t)
+	limiter.EXPECT().Take(mock.Anything, "ip:192.0.2.1", limit).Return(ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9}, nil).Once()
+
+	r := httptest.NewRequest(http.MethodPost, "/auth/signin", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+
+	rec, reached := through(RateLimitByIP(discard, limiter, limit, true, NewMockRateLimitRecorder(t)), r)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.NotNil(t, reached)
+}
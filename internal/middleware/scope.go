@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+	"notes-api/internal/problem"
+	"notes-api/internal/utils"
+	"slices"
+)
+
+// RequireScope rejects requests made with a personal access token that was
+// not granted scope. Requests authenticated by a login session pass.
+func RequireScope(scope string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			scopes, ok := r.Context().Value(utils.ScopesKey).([]string)
+			if ok && !slices.Contains(scopes, scope) {
+				problem.Write(w, r, problem.Forbidden, "Token is missing the "+scope+" scope")
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
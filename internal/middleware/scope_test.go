@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"notes-api/internal/models"
+	"notes-api/internal/utils"
+)
+
+func TestRequireScope(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		scopes any
+		code   int
+	}{
+		{name: "access token", code: http.StatusNoContent},
+		{name: "granted", scopes: []string{models.ScopeNotesRead, models.ScopeNotesWrite}, code: http.StatusNoContent},
+		{name: "not granted", scopes: []string{models.ScopeNotesRead}, code: http.StatusForbidden},
+		{name: "no scopes", scopes: []string{}, code: http.StatusForbidden},
+		{name: "nil scopes", scopes: []string(nil), code: http.StatusForbidden},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/notes", nil)
+			if tt.scopes != nil {
+				r = r.WithContext(context.WithValue(r.Context(), utils.ScopesKey, tt.scopes))
+			}
+
+			rec, reached := through(RequireScope(models.ScopeNotesWrite), r)
+			assert.Equal(t, tt.code, rec.Code)
+
+			if tt.code == http.StatusForbidden {
+				assert.Nil(t, reached)
+				assert.Equal(t, "insufficient_scope", problemCode(t, rec))
+			}
+		})
+	}
+}
+
+// A personal access token stored without scopes, however it came to be,
+// grants nothing rather than everything.
+func TestRequireScopeAfterTokenAuth(t *testing.T) {
+	const pat = models.PersonalAccessTokenPrefix + "secret"
+
+	for _, scopes := range [][]string{nil, {}} {
+		storage := NewMockTokenAuthenticator(t)
+		storage.EXPECT().AuthenticatePersonalAccessToken(mock.Anything, utils.HashToken(pat)).
+			Return(&models.PersonalAccessToken{UserID: 7, Scopes: scopes}, nil).Once()
+
+		chain := func(next http.Handler) http.Handler {
+			return TokenAuthMiddleware(NewMockTokenVerifier(t), storage, NewMockAuthFailureRecorder(t))(RequireScope(models.ScopeNotesWrite)(next))
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/notes", nil)
+		r.Header.Set("Authorization", "Bearer "+pat)
+
+		rec, reached := through(chain, r)
+		assert.Equal(t, http.StatusForbidden, rec.Code, scopes)
+		assert.Nil(t, reached)
+	}
+}
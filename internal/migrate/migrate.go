@@ -0,0 +1,379 @@
+// Package migrate applies numbered SQL migrations to a database and records
+// them in a schema_migrations table.
+//
+// Migrations are pairs of files named NNNN_name.up.sql and NNNN_name.down.sql.
+// Each one runs in its own transaction together with its bookkeeping row, and
+// the checksum of every applied up migration is verified before changing the
+// schema, so that editing a migration after it shipped is caught instead of
+// silently diverging databases.
+package migrate
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrChecksumMismatch = errors.New("migration has been modified since it was applied")
+var ErrUnknownMigration = errors.New("applied migration is missing from the source")
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Checksum identifies the contents of the up migration.
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt string
+	// Modified is set when the applied checksum differs from the source.
+	Modified bool
+}
+
+type applied struct {
+	checksum  string
+	appliedAt string
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	// bind rewrites the ? placeholders of the bookkeeping queries for the
+	// driver in use.
+	bind func(query string) string
+}
+
+type Option func(*Migrator)
+
+// DollarPlaceholders makes the migrator use $1-style placeholders, as
+// PostgreSQL drivers expect.
+func DollarPlaceholders() Option {
+	return func(m *Migrator) {
+		m.bind = func(query string) string {
+			var sb strings.Builder
+			n := 0
+			for _, r := range query {
+				if r == '?' {
+					n++
+					sb.WriteString("$" + strconv.Itoa(n))
+					continue
+				}
+				sb.WriteRune(r)
+			}
+			return sb.String()
+		}
+	}
+}
+
+// New loads the migrations in the root of fsys.
+func New(db *sql.DB, fsys fs.FS, opts ...Option) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Migrator{db: db, migrations: migrations, bind: func(query string) string { return query }}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m, nil
+}
+
+// Load reads and orders the migrations in the root of fsys.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected file %q among migrations", entry.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid migration version in %q", entry.Name())
+		}
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up file", m.Version, m.Name)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TEXT NOT NULL DEFAULT current_timestamp
+		);`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) applied() (map[int]applied, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.Query("SELECT version, checksum, applied_at FROM schema_migrations;")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[int]applied)
+	for rows.Next() {
+		var (
+			version int
+			a       applied
+		)
+		if err := rows.Scan(&version, &a.checksum, &a.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+
+		result[version] = a
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate applied migrations: %w", err)
+	}
+
+	return result, nil
+}
+
+// Status reports every known migration and whether it has been applied.
+func (m *Migrator) Status() ([]Status, error) {
+	done, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i].Migration = migration
+
+		if a, ok := done[migration.Version]; ok {
+			statuses[i].Applied = true
+			statuses[i].AppliedAt = a.appliedAt
+			statuses[i].Modified = a.checksum != migration.Checksum()
+		}
+	}
+
+	return statuses, nil
+}
+
+// verify fails when an applied migration has been edited or removed.
+func (m *Migrator) verify(done map[int]applied) error {
+	known := make(map[int]bool, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+
+		if a, ok := done[migration.Version]; ok && a.checksum != migration.Checksum() {
+			return fmt.Errorf("%w: %04d_%s", ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+
+	for version := range done {
+		if !known[version] {
+			return fmt.Errorf("%w: version %d", ErrUnknownMigration, version)
+		}
+	}
+
+	return nil
+}
+
+// Up applies up to steps pending migrations in order, or all of them when
+// steps is 0, and returns the ones it applied.
+func (m *Migrator) Up(steps int) ([]Migration, error) {
+	done, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.verify(done); err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for _, migration := range m.migrations {
+		if _, ok := done[migration.Version]; ok {
+			continue
+		}
+
+		if steps > 0 && len(ran) == steps {
+			break
+		}
+
+		err := m.run(migration.Up, func(tx *sql.Tx) error {
+			_, err := tx.Exec(
+				m.bind("INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?);"),
+				migration.Version, migration.Name, migration.Checksum(),
+			)
+			return err
+		})
+		if err != nil {
+			return ran, fmt.Errorf("failed to apply migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		ran = append(ran, migration)
+	}
+
+	return ran, nil
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// the ones it reverted.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	done, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.verify(done); err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(ran) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := done[migration.Version]; !ok {
+			continue
+		}
+
+		if migration.Down == "" {
+			return ran, fmt.Errorf("migration %04d_%s has no down file", migration.Version, migration.Name)
+		}
+
+		err := m.run(migration.Down, func(tx *sql.Tx) error {
+			_, err := tx.Exec(m.bind("DELETE FROM schema_migrations WHERE version = ?;"), migration.Version)
+			return err
+		})
+		if err != nil {
+			return ran, fmt.Errorf("failed to revert migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		ran = append(ran, migration)
+	}
+
+	return ran, nil
+}
+
+func (m *Migrator) run(script string, record func(tx *sql.Tx) error) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+
+	if err := record(tx); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Create writes up and down files for a new migration to dir, numbered after
+// the newest migration already there, and returns their paths.
+func Create(dir, name string) (string, string, error) {
+	if !regexp.MustCompile(`^[a-z0-9_]+$`).MatchString(name) {
+		return "", "", fmt.Errorf("migration name %q must consist of lowercase letters, digits and underscores", name)
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+
+	version := 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%04d_%s", version, name))
+	up, down := base+".up.sql", base+".down.sql"
+
+	files := []struct{ path, direction string }{{up, "up"}, {down, "down"}}
+	for _, file := range files {
+		f, err := os.OpenFile(file.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to create migration file: %w", err)
+		}
+
+		_, err = fmt.Fprintf(f, "-- %04d_%s (%s)\n", version, name, file.direction)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("failed to write migration file: %w", err)
+		}
+	}
+
+	return up, down, nil
+}
@@ -0,0 +1,272 @@
+package migrate
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	// Every connection to :memory: opens a database of its own.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func file(s string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(s)}
+}
+
+var source = fstest.MapFS{
+	"0001_notes.up.sql":     file("CREATE TABLE notes (id INTEGER PRIMARY KEY);"),
+	"0001_notes.down.sql":   file("DROP TABLE notes;"),
+	"0002_tags.up.sql":      file("CREATE TABLE tags (id INTEGER PRIMARY KEY);"),
+	"0002_tags.down.sql":    file("DROP TABLE tags;"),
+	"0003_title.up.sql":     file("ALTER TABLE notes ADD COLUMN title TEXT;"),
+	"0003_title.down.sql":   file("ALTER TABLE notes DROP COLUMN title;"),
+	"README.md.placeholder": nil,
+}
+
+func sourceWith(changes map[string]*fstest.MapFile) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for name, f := range source {
+		if f != nil {
+			fsys[name] = f
+		}
+	}
+
+	for name, f := range changes {
+		if f == nil {
+			delete(fsys, name)
+		} else {
+			fsys[name] = f
+		}
+	}
+
+	return fsys
+}
+
+func newMigrator(t *testing.T, db *sql.DB, fsys fstest.MapFS) *Migrator {
+	t.Helper()
+
+	m, err := New(db, fsys)
+	require.NoError(t, err)
+
+	return m
+}
+
+func tables(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name != 'schema_migrations' ORDER BY name;")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+
+	return names
+}
+
+func versions(migrations []Migration) []int {
+	var result []int
+	for _, m := range migrations {
+		result = append(result, m.Version)
+	}
+
+	return result
+}
+
+func TestUpDown(t *testing.T) {
+	db := openDB(t)
+	m := newMigrator(t, db, sourceWith(nil))
+
+	ran, err := m.Up(1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, versions(ran))
+	assert.Equal(t, []string{"notes"}, tables(t, db))
+
+	ran, err = m.Up(0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 3}, versions(ran))
+	assert.Equal(t, []string{"notes", "tags"}, tables(t, db))
+
+	ran, err = m.Up(0)
+	require.NoError(t, err)
+	assert.Empty(t, ran)
+
+	statuses, err := m.Status()
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.True(t, s.Applied, s.Version)
+		assert.False(t, s.Modified, s.Version)
+		assert.NotEmpty(t, s.AppliedAt, s.Version)
+	}
+
+	ran, err = m.Down(2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 2}, versions(ran))
+	assert.Equal(t, []string{"notes"}, tables(t, db))
+
+	statuses, err = m.Status()
+	require.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+	assert.False(t, statuses[2].Applied)
+
+	ran, err = m.Down(10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, versions(ran))
+	assert.Empty(t, tables(t, db))
+
+	// The round trip leaves a database that migrates up as a new one does.
+	ran, err = m.Up(0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, versions(ran))
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	db := openDB(t)
+	_, err := newMigrator(t, db, sourceWith(nil)).Up(2)
+	require.NoError(t, err)
+
+	edited := newMigrator(t, db, sourceWith(map[string]*fstest.MapFile{
+		"0001_notes.up.sql": file("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT);"),
+	}))
+
+	_, err = edited.Up(0)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+	_, err = edited.Down(1)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+
+	statuses, err := edited.Status()
+	require.NoError(t, err)
+	assert.True(t, statuses[0].Modified)
+	assert.False(t, statuses[1].Modified)
+
+	// Nothing ran: migration 3 is still pending.
+	assert.False(t, statuses[2].Applied)
+}
+
+func TestUnknownMigration(t *testing.T) {
+	db := openDB(t)
+	_, err := newMigrator(t, db, sourceWith(nil)).Up(0)
+	require.NoError(t, err)
+
+	missing := newMigrator(t, db, sourceWith(map[string]*fstest.MapFile{
+		"0003_title.up.sql":   nil,
+		"0003_title.down.sql": nil,
+	}))
+
+	_, err = missing.Up(0)
+	assert.ErrorIs(t, err, ErrUnknownMigration)
+	_, err = missing.Down(1)
+	assert.ErrorIs(t, err, ErrUnknownMigration)
+}
+
+// A migration that fails partway leaves neither its schema changes nor its
+// bookkeeping row behind, and the migrations before it stay applied.
+func TestFailedMigrationRollsBack(t *testing.T) {
+	db := openDB(t)
+	m := newMigrator(t, db, sourceWith(map[string]*fstest.MapFile{
+		"0002_tags.up.sql": file("CREATE TABLE tags (id INTEGER PRIMARY KEY); INSERT INTO missing VALUES (1);"),
+	}))
+
+	ran, err := m.Up(0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "0002_tags")
+	assert.Equal(t, []int{1}, versions(ran))
+	assert.Equal(t, []string{"notes"}, tables(t, db))
+
+	statuses, err := m.Status()
+	require.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+	assert.False(t, statuses[2].Applied)
+
+	// A failing down migration is rolled back the same way.
+	m = newMigrator(t, db, sourceWith(map[string]*fstest.MapFile{
+		"0001_notes.down.sql": file("DROP TABLE notes; DROP TABLE missing;"),
+	}))
+
+	_, err = m.Down(1)
+	require.Error(t, err)
+	assert.Equal(t, []string{"notes"}, tables(t, db))
+
+	statuses, err = m.Status()
+	require.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+}
+
+func TestDownWithoutDownFile(t *testing.T) {
+	db := openDB(t)
+	m := newMigrator(t, db, sourceWith(map[string]*fstest.MapFile{"0003_title.down.sql": nil}))
+
+	_, err := m.Up(0)
+	require.NoError(t, err)
+
+	ran, err := m.Down(1)
+	assert.ErrorContains(t, err, "no down file")
+	assert.Empty(t, ran)
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(sourceWith(nil))
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, versions(migrations))
+	assert.Equal(t, "title", migrations[2].Name)
+	assert.Equal(t, "DROP TABLE tags;", migrations[1].Down)
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"unexpected file": sourceWith(map[string]*fstest.MapFile{"notes.sql": file("")}),
+		"no up file":      sourceWith(map[string]*fstest.MapFile{"0003_title.up.sql": nil}),
+		"version zero":    sourceWith(map[string]*fstest.MapFile{"0000_init.up.sql": file("SELECT 1;")}),
+		"two names":       sourceWith(map[string]*fstest.MapFile{"0003_titles.down.sql": file("SELECT 1;")}),
+	} {
+		_, err := Load(fsys)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestDollarPlaceholders(t *testing.T) {
+	m := &Migrator{}
+	DollarPlaceholders()(m)
+
+	assert.Equal(t, "INSERT INTO t (a, b) VALUES ($1, $2);", m.bind("INSERT INTO t (a, b) VALUES (?, ?);"))
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+
+	up, down, err := Create(dir, "notes")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "0001_notes.up.sql"), up)
+	assert.Equal(t, filepath.Join(dir, "0001_notes.down.sql"), down)
+
+	data, err := os.ReadFile(up)
+	require.NoError(t, err)
+	assert.Equal(t, "-- 0001_notes (up)\n", string(data))
+
+	up, _, err = Create(dir, "tags")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "0002_tags.up.sql"), up)
+
+	_, _, err = Create(dir, "Bad Name")
+	assert.Error(t, err)
+}
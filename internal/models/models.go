@@ -1,5 +1,40 @@
 package models
 
+import (
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultNotesLimit = 20
+	MaxNotesLimit     = 100
+
+	MaxTagsPerNote = 20
+	MaxTagLength   = 50
+
+	TagModeAll = "all"
+	TagModeAny = "any"
+
+	// PersonalAccessTokenPrefix tells personal access tokens apart from JWTs.
+	PersonalAccessTokenPrefix = "pat_"
+	MaxTokenNameLength        = 100
+	DefaultTokenLifetimeDays  = 30
+	MaxTokenLifetimeDays      = 365
+
+	ScopeNotesRead  = "notes:read"
+	ScopeNotesWrite = "notes:write"
+	ScopeTagsRead   = "tags:read"
+	ScopeTagsWrite  = "tags:write"
+
+	MinPasswordLength = 4
+	MaxEmailLength    = 254
+)
+
+// Scopes lists every scope a personal access token can be granted.
+var Scopes = []string{ScopeNotesRead, ScopeNotesWrite, ScopeTagsRead, ScopeTagsWrite}
+
 type Validator interface {
 	Validate() (problems map[string]string)
 }
@@ -8,15 +43,140 @@ type User struct {
 	ID       int64  `json:"id"`
 	Username string `json:"username"`
 	Password string `json:"password"`
+	// Email is optional. Password reset links are sent to it.
+	Email string `json:"email,omitempty"`
+	// FailedLogins counts the failed sign-ins since the last successful
+	// one. While LockedUntil is in the future, sign-ins are refused.
+	FailedLogins int       `json:"-"`
+	LockedUntil  time.Time `json:"-"`
+	// TOTPSecret is set once two-factor setup has begun, but sign-ins only
+	// ask for a code once TOTPEnabled, after the user confirmed the setup
+	// with one.
+	TOTPSecret  string `json:"-"`
+	TOTPEnabled bool   `json:"-"`
+}
+
+// MFAChallenge is a sign-in that got past the password and waits for a
+// second factor. Attempts counts the wrong codes given for it.
+type MFAChallenge struct {
+	UserID    int64
+	Attempts  int
+	ExpiresAt time.Time
 }
 
 type Note struct {
-	ID        int    `json:"id"`
-	UserID    int    `json:"user_id"`
+	ID        int      `json:"id"`
+	UserID    int      `json:"user_id"`
+	Title     string   `json:"title"`
+	Content   string   `json:"content"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+	DeletedAt *string  `json:"deleted_at,omitempty"`
+	Version   int      `json:"version"`
+	Tags      []string `json:"tags"`
+}
+
+type Revision struct {
+	NoteID    int    `json:"note_id"`
+	Revision  int    `json:"revision"`
 	Title     string `json:"title"`
 	Content   string `json:"content"`
 	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+}
+
+type Tag struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type TagRename struct {
+	Name string `json:"name"`
+}
+
+type TagMerge struct {
+	Sources []string `json:"sources"`
+	Target  string   `json:"target"`
+}
+
+type NotesQuery struct {
+	Limit         int
+	Cursor        string
+	SortBy        string
+	Order         string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+	Tags          []string
+	TagMode       string
+	Trashed       bool
+}
+
+type NotesPage struct {
+	Notes      []Note `json:"notes"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int    `json:"total"`
+}
+
+type SearchQuery struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+type SearchResult struct {
+	Note
+	Rank           float64 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+// PersonalAccessToken lets scripts call the API on behalf of a user with a
+// limited set of scopes. Token is only set right after creation.
+type PersonalAccessToken struct {
+	ID         int64    `json:"id"`
+	UserID     int64    `json:"-"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  string   `json:"created_at"`
+	ExpiresAt  string   `json:"expires_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+	Token      string   `json:"token,omitempty"`
+}
+
+// PasswordChange replaces the password of a signed-in user, who proves
+// knowing the current one.
+type PasswordChange struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type PasswordForgot struct {
+	Username string `json:"username"`
+}
+
+// PasswordReset replaces a forgotten password with the token mailed to the
+// user.
+type PasswordReset struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// MFAVerify completes a sign-in with the token of its challenge and a code
+// from an authenticator app or a recovery code.
+type MFAVerify struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type TOTPCode struct {
+	Code string `json:"code"`
+}
+
+type PersonalAccessTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
 }
 
 func (u *User) Validate() map[string]string {
@@ -26,13 +186,102 @@ func (u *User) Validate() map[string]string {
 		problems["username"] = "Username cannot be empty"
 	}
 
-	if len(u.Password) < 4 {
-		problems["password"] = "Password cannot be less than 4 characters"
+	if problem := validatePassword(u.Password); problem != "" {
+		problems["password"] = problem
+	}
+
+	if u.Email != "" {
+		if addr, err := mail.ParseAddress(u.Email); err != nil || addr.Address != u.Email || len(u.Email) > MaxEmailLength {
+			problems["email"] = "Email must be a valid address"
+		}
+	}
+
+	return problems
+}
+
+func validatePassword(password string) string {
+	if len(password) < MinPasswordLength {
+		return "Password cannot be less than 4 characters"
+	}
+
+	return ""
+}
+
+func (p *PasswordChange) Validate() map[string]string {
+	problems := make(map[string]string)
+
+	if p.CurrentPassword == "" {
+		problems["current_password"] = "Current password cannot be empty"
+	}
+
+	if problem := validatePassword(p.NewPassword); problem != "" {
+		problems["new_password"] = problem
+	}
+
+	return problems
+}
+
+func (p *PasswordForgot) Validate() map[string]string {
+	problems := make(map[string]string)
+
+	if p.Username == "" {
+		problems["username"] = "Username cannot be empty"
+	}
+
+	return problems
+}
+
+func (p *PasswordReset) Validate() map[string]string {
+	problems := make(map[string]string)
+
+	if p.Token == "" {
+		problems["token"] = "Token cannot be empty"
+	}
+
+	if problem := validatePassword(p.NewPassword); problem != "" {
+		problems["new_password"] = problem
 	}
 
 	return problems
 }
 
+func (m *MFAVerify) Validate() map[string]string {
+	problems := make(map[string]string)
+
+	if m.MFAToken == "" {
+		problems["mfa_token"] = "MFA token cannot be empty"
+	}
+
+	if strings.TrimSpace(m.Code) == "" {
+		problems["code"] = "Code cannot be empty"
+	}
+
+	return problems
+}
+
+func (c *TOTPCode) Validate() map[string]string {
+	problems := make(map[string]string)
+
+	if strings.TrimSpace(c.Code) == "" {
+		problems["code"] = "Code cannot be empty"
+	}
+
+	return problems
+}
+
+// NotePatch lists the fields of a note a partial update changes. Nil fields
+// are left as they are.
+type NotePatch struct {
+	Title   *string
+	Content *string
+	Tags    []string
+}
+
+// Empty reports whether the patch changes nothing.
+func (p NotePatch) Empty() bool {
+	return p.Title == nil && p.Content == nil && p.Tags == nil
+}
+
 func (n *Note) Validate() map[string]string {
 	problems := make(map[string]string)
 
@@ -40,5 +289,169 @@ func (n *Note) Validate() map[string]string {
 		problems["title"] = "Title cannot be empty"
 	}
 
+	if len(n.Tags) > MaxTagsPerNote {
+		problems["tags"] = "A note cannot have more than 20 tags"
+	}
+
+	for _, tag := range n.Tags {
+		if problem := validateTag(tag); problem != "" {
+			problems["tags"] = problem
+			break
+		}
+	}
+
 	return problems
 }
+
+func (q *NotesQuery) Validate() map[string]string {
+	problems := make(map[string]string)
+
+	if q.Limit < 1 || q.Limit > MaxNotesLimit {
+		problems["limit"] = "Limit must be between 1 and 100"
+	}
+
+	switch q.SortBy {
+	case "created_at", "updated_at", "title":
+	case "deleted_at":
+		if !q.Trashed {
+			problems["sort"] = "Sort by deleted_at is only available for trashed notes"
+		}
+	default:
+		problems["sort"] = "Sort must be one of created_at, updated_at, title"
+	}
+
+	if q.Order != "asc" && q.Order != "desc" {
+		problems["order"] = "Order must be asc or desc"
+	}
+
+	if !q.CreatedAfter.IsZero() && !q.CreatedBefore.IsZero() && !q.CreatedAfter.Before(q.CreatedBefore) {
+		problems["created_after"] = "created_after must be earlier than created_before"
+	}
+
+	if !q.UpdatedAfter.IsZero() && !q.UpdatedBefore.IsZero() && !q.UpdatedAfter.Before(q.UpdatedBefore) {
+		problems["updated_after"] = "updated_after must be earlier than updated_before"
+	}
+
+	if q.TagMode != TagModeAll && q.TagMode != TagModeAny {
+		problems["tag_mode"] = "Tag mode must be all or any"
+	}
+
+	for _, tag := range q.Tags {
+		if problem := validateTag(tag); problem != "" {
+			problems["tag"] = problem
+			break
+		}
+	}
+
+	return problems
+}
+
+func (q *SearchQuery) Validate() map[string]string {
+	problems := make(map[string]string)
+
+	if q.Query == "" {
+		problems["q"] = "Search query cannot be empty"
+	}
+
+	if q.Limit < 1 || q.Limit > MaxNotesLimit {
+		problems["limit"] = "Limit must be between 1 and 100"
+	}
+
+	if q.Offset < 0 {
+		problems["offset"] = "Offset cannot be negative"
+	}
+
+	return problems
+}
+
+func (t *TagRename) Validate() map[string]string {
+	problems := make(map[string]string)
+
+	if problem := validateTag(t.Name); problem != "" {
+		problems["name"] = problem
+	}
+
+	return problems
+}
+
+func (t *TagMerge) Validate() map[string]string {
+	problems := make(map[string]string)
+
+	if len(t.Sources) == 0 {
+		problems["sources"] = "Sources cannot be empty"
+	}
+
+	for _, tag := range t.Sources {
+		if problem := validateTag(tag); problem != "" {
+			problems["sources"] = problem
+			break
+		}
+	}
+
+	if problem := validateTag(t.Target); problem != "" {
+		problems["target"] = problem
+	}
+
+	return problems
+}
+
+func (t *PersonalAccessTokenRequest) Validate() map[string]string {
+	problems := make(map[string]string)
+
+	if strings.TrimSpace(t.Name) == "" {
+		problems["name"] = "Name cannot be empty"
+	} else if len(t.Name) > MaxTokenNameLength {
+		problems["name"] = "Name cannot be longer than 100 characters"
+	}
+
+	if len(t.Scopes) == 0 {
+		problems["scopes"] = "Scopes cannot be empty"
+	}
+
+	for _, scope := range t.Scopes {
+		if !slices.Contains(Scopes, scope) {
+			problems["scopes"] = "Scope must be one of " + strings.Join(Scopes, ", ")
+			break
+		}
+	}
+
+	if t.ExpiresInDays < 1 || t.ExpiresInDays > MaxTokenLifetimeDays {
+		problems["expires_in_days"] = "Expiry must be between 1 and 365 days"
+	}
+
+	return problems
+}
+
+// NormalizeTags trims and lower-cases tag names and drops duplicates,
+// keeping the first occurrence. A nil slice stays nil.
+func NormalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
+
+func validateTag(tag string) string {
+	if tag == "" {
+		return "Tag cannot be empty"
+	}
+
+	if len(tag) > MaxTagLength {
+		return "Tag cannot be longer than 50 characters"
+	}
+
+	return ""
+}
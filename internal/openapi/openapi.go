@@ -0,0 +1,279 @@
+// Package openapi serves the OpenAPI document of the API along with a page
+// rendering it, and validates requests, and in development responses,
+// against it.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed openapi.yaml
+var document []byte
+
+//go:embed docs.html
+var docsPage []byte
+
+// resourceURL is the name the document is known by to the schema compiler.
+// References in its schemas resolve against it.
+const resourceURL = "openapi.json"
+
+var methods = []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete, http.MethodPatch}
+
+// Spec is the parsed OpenAPI document with the schemas of every operation
+// compiled.
+type Spec struct {
+	json       []byte
+	operations []*operation
+}
+
+// Route is a method and path template the document describes.
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Load parses the embedded document and compiles its schemas.
+func Load() (*Spec, error) {
+	const op = "openapi.Load"
+
+	var doc map[string]any
+	if err := yaml.Unmarshal(document, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The compiler wants numbers as json.Number, so the document is read
+	// again the way it expects.
+	resource, err := jsonschema.UnmarshalJSON(strings.NewReader(string(raw)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := jsonschema.NewCompiler()
+	c.DefaultDraft(jsonschema.Draft2020)
+	if err := c.AddResource(resourceURL, resource); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s := &Spec{json: raw}
+	l := loader{doc: doc, compiler: c}
+
+	paths, _ := doc["paths"].(map[string]any)
+	for path, item := range paths {
+		item, _ := item.(map[string]any)
+
+		for _, method := range methods {
+			node, ok := item[strings.ToLower(method)].(map[string]any)
+			if !ok {
+				continue
+			}
+
+			o, err := l.operation(method, path, item, node)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s %s: %w", op, method, path, err)
+			}
+			s.operations = append(s.operations, o)
+		}
+	}
+
+	return s, nil
+}
+
+// MustLoad is Load for callers that cannot run without the document, which
+// is embedded and so only fails to load when the build is broken.
+func MustLoad() *Spec {
+	s, err := Load()
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+// Routes lists the operations of the document.
+func (s *Spec) Routes() []Route {
+	routes := make([]Route, 0, len(s.operations))
+	for _, o := range s.operations {
+		routes = append(routes, Route{Method: o.method, Path: o.path})
+	}
+
+	slices.SortFunc(routes, func(a, b Route) int {
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+
+	return routes
+}
+
+// Handler serves the document as JSON.
+func (s *Spec) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(s.json)
+	}
+}
+
+// DocsHandler serves a page that renders the document served at specURL and
+// lets the operations be tried out.
+func DocsHandler(specURL string) http.HandlerFunc {
+	page := strings.ReplaceAll(string(docsPage), "{{SPEC_URL}}", specURL)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}
+}
+
+// loader builds operations out of the document, following its references.
+type loader struct {
+	doc      map[string]any
+	compiler *jsonschema.Compiler
+}
+
+func (l loader) operation(method, path string, item, node map[string]any) (*operation, error) {
+	o := &operation{
+		method:    method,
+		path:      path,
+		segments:  splitPath(path),
+		body:      make(map[string]*jsonschema.Schema),
+		responses: make(map[string]map[string]*jsonschema.Schema),
+	}
+
+	base := "/paths/" + escape(path)
+
+	// Parameters of the operation override those of the path with the same
+	// name and location.
+	params := make(map[string]*parameter)
+	for _, level := range []struct {
+		node map[string]any
+		ptr  string
+	}{{item, base}, {node, base + "/" + strings.ToLower(method)}} {
+		list, _ := level.node["parameters"].([]any)
+		for i := range list {
+			p, err := l.parameter(fmt.Sprintf("%s/parameters/%d", level.ptr, i))
+			if err != nil {
+				return nil, err
+			}
+			params[p.in+":"+p.name] = p
+		}
+	}
+	for _, p := range params {
+		o.params = append(o.params, p)
+	}
+	slices.SortFunc(o.params, func(a, b *parameter) int { return strings.Compare(a.name, b.name) })
+
+	ptr := base + "/" + strings.ToLower(method)
+
+	if _, ok := node["requestBody"]; ok {
+		body, bodyPtr := l.resolve(ptr + "/requestBody")
+		o.bodyRequired, _ = body["required"].(bool)
+
+		content, _ := body["content"].(map[string]any)
+		for media := range content {
+			sch, err := l.compiler.Compile(resourceURL + "#" + bodyPtr + "/content/" + escape(media) + "/schema")
+			if err != nil {
+				return nil, err
+			}
+			o.body[media] = sch
+		}
+	}
+
+	responses, _ := node["responses"].(map[string]any)
+	for status := range responses {
+		resp, respPtr := l.resolve(ptr + "/responses/" + escape(status))
+
+		o.responses[status] = make(map[string]*jsonschema.Schema)
+		content, _ := resp["content"].(map[string]any)
+		for media := range content {
+			sch, err := l.compiler.Compile(resourceURL + "#" + respPtr + "/content/" + escape(media) + "/schema")
+			if err != nil {
+				return nil, err
+			}
+			o.responses[status][media] = sch
+		}
+	}
+
+	return o, nil
+}
+
+func (l loader) parameter(ptr string) (*parameter, error) {
+	node, ptr := l.resolve(ptr)
+
+	p := &parameter{}
+	p.name, _ = node["name"].(string)
+	p.in, _ = node["in"].(string)
+	p.required, _ = node["required"].(bool)
+
+	if schema, ok := node["schema"].(map[string]any); ok {
+		p.typ, _ = schema["type"].(string)
+		if items, ok := schema["items"].(map[string]any); ok {
+			p.itemType, _ = items["type"].(string)
+		}
+	}
+
+	sch, err := l.compiler.Compile(resourceURL + "#" + ptr + "/schema")
+	if err != nil {
+		return nil, err
+	}
+	p.schema = sch
+
+	return p, nil
+}
+
+// resolve returns the object at the JSON pointer ptr, following a $ref to
+// another part of the document, along with the pointer it was found at.
+func (l loader) resolve(ptr string) (map[string]any, string) {
+	var node any = l.doc
+	for _, token := range strings.Split(strings.TrimPrefix(ptr, "/"), "/") {
+		token, _ = url.PathUnescape(token)
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		switch n := node.(type) {
+		case map[string]any:
+			node = n[token]
+		case []any:
+			var i int
+			fmt.Sscan(token, &i)
+			if i < len(n) {
+				node = n[i]
+			}
+		}
+	}
+
+	obj, _ := node.(map[string]any)
+	if ref, ok := obj["$ref"].(string); ok {
+		return l.resolve(strings.TrimPrefix(ref, "#"))
+	}
+
+	return obj, ptr
+}
+
+// escape turns a key into a JSON pointer token fit for a URL fragment.
+func escape(token string) string {
+	token = strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+	return url.PathEscape(token)
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+
+	return strings.Split(path, "/")
+}
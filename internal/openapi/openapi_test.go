@@ -0,0 +1,34 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notes-api/internal/diff"
+	"notes-api/internal/openapi"
+)
+
+// The document states the diff limit in words, so it has to follow the
+// constant by hand.
+func TestDiffLimitDocumented(t *testing.T) {
+	w := httptest.NewRecorder()
+	openapi.MustLoad().Handler()(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var doc struct {
+		Paths map[string]struct {
+			Get struct {
+				Description string `json:"description"`
+			} `json:"get"`
+		} `json:"paths"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&doc))
+
+	assert.Contains(t, doc.Paths["/notes/{id}/diff"].Get.Description, strconv.Itoa(diff.MaxLines)+" lines")
+}
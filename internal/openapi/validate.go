@@ -0,0 +1,413 @@
+package openapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"notes-api/internal/problem"
+	"notes-api/pkg/logger"
+)
+
+// bodyField names the request body in the errors of a problem when the
+// body as a whole is wrong rather than one of its members.
+const bodyField = "body"
+
+var printer = message.NewPrinter(language.English)
+
+type operation struct {
+	method   string
+	path     string
+	segments []string
+	params   []*parameter
+
+	body         map[string]*jsonschema.Schema
+	bodyRequired bool
+	// responses holds the schemas of the response bodies by status, as
+	// written in the document ("200", "4XX", "default"), and media type.
+	responses map[string]map[string]*jsonschema.Schema
+}
+
+type parameter struct {
+	name     string
+	in       string
+	required bool
+	// typ and itemType are the JSON types the string values of the
+	// parameter are converted to before they are checked against schema.
+	typ      string
+	itemType string
+	schema   *jsonschema.Schema
+}
+
+// Middleware rejects requests the document does not allow with a
+// validation problem listing what is wrong with their parameters and body.
+// Requests for routes the document does not describe pass untouched. It
+// runs ahead of authentication, so a malformed request is rejected as such
+// whoever sends it.
+//
+// With validateResponses, responses are buffered and checked as well, and
+// one the document does not allow is replaced with a 500. It is meant for
+// development, where a handler drifting from the document should be loud.
+func (s *Spec) Middleware(log *slog.Logger, validateResponses bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			o, pathParams := s.find(r)
+			if o == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			errs := o.validateParams(r, pathParams)
+
+			if len(o.body) > 0 {
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					problem.Write(w, r, problem.InvalidRequest, "Failed to read request body")
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+
+				bodyErrs, err := o.validateBody(r.Header.Get("Content-Type"), body)
+				if err != nil {
+					problem.Write(w, r, problem.InvalidRequest, "Failed to decode request body")
+					return
+				}
+				for field, msg := range bodyErrs {
+					errs[field] = msg
+				}
+			}
+
+			if len(errs) > 0 {
+				problem.WriteValidation(w, r, "Request does not match the API specification", errs)
+				return
+			}
+
+			if !validateResponses {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &recorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+
+			if err := o.validateResponse(rec.status, w.Header().Get("Content-Type"), rec.body.Bytes()); err != nil {
+				log.Error("response does not match the API specification",
+					slog.String("method", o.method),
+					slog.String("path", o.path),
+					slog.Int("status", rec.status),
+					logger.Err(err),
+				)
+
+				for key := range w.Header() {
+					w.Header().Del(key)
+				}
+				problem.Write(w, r, problem.Internal, "Response does not match the API specification: "+err.Error())
+				return
+			}
+
+			rec.flush()
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// find returns the operation of the document r is for, if any, along with
+// the values of its path parameters. Literal segments win over parameters,
+// so /notes/search is not taken for a note ID.
+func (s *Spec) find(r *http.Request) (*operation, map[string]string) {
+	segments := splitPath(r.URL.EscapedPath())
+
+	var (
+		best     *operation
+		bestVals map[string]string
+		bestLits = -1
+	)
+	for _, o := range s.operations {
+		if o.method != r.Method || len(o.segments) != len(segments) {
+			continue
+		}
+
+		vals := make(map[string]string)
+		lits := 0
+		matched := true
+		for i, seg := range o.segments {
+			if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+				v, err := url.PathUnescape(segments[i])
+				if err != nil || v == "" {
+					matched = false
+					break
+				}
+				vals[seg[1:len(seg)-1]] = v
+				continue
+			}
+
+			if seg != segments[i] {
+				matched = false
+				break
+			}
+			lits++
+		}
+
+		if matched && lits > bestLits {
+			best, bestVals, bestLits = o, vals, lits
+		}
+	}
+
+	return best, bestVals
+}
+
+func (o *operation) validateParams(r *http.Request, pathParams map[string]string) map[string]string {
+	errs := make(map[string]string)
+	query := r.URL.Query()
+
+	for _, p := range o.params {
+		var raw []string
+		switch p.in {
+		case "path":
+			if v, ok := pathParams[p.name]; ok {
+				raw = []string{v}
+			}
+		case "query":
+			raw = query[p.name]
+		default:
+			// Headers and cookies are left to the handlers.
+			continue
+		}
+
+		if len(raw) == 0 {
+			if p.required {
+				errs[p.name] = "Parameter is required"
+			}
+			continue
+		}
+
+		var value any
+		if p.typ == "array" {
+			items := make([]any, len(raw))
+			for i, v := range raw {
+				item, ok := convert(v, p.itemType)
+				if !ok {
+					errs[p.name] = typeErrors[p.itemType]
+					break
+				}
+				items[i] = item
+			}
+			value = items
+		} else {
+			v, ok := convert(raw[0], p.typ)
+			if !ok {
+				errs[p.name] = typeErrors[p.typ]
+			}
+			value = v
+		}
+		if _, failed := errs[p.name]; failed {
+			continue
+		}
+
+		if err := p.schema.Validate(value); err != nil {
+			errs[p.name] = describe(err)
+		}
+	}
+
+	return errs
+}
+
+// validateBody checks body against the schema of its media type. Handlers
+// decode JSON whatever the Content-Type says, so a body of an undocumented
+// media type is checked as JSON if the operation takes JSON, and left to
+// the handler otherwise. It fails only when the body is not JSON.
+func (o *operation) validateBody(contentType string, body []byte) (map[string]string, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		if o.bodyRequired {
+			return map[string]string{bodyField: "Request body is required"}, nil
+		}
+		return nil, nil
+	}
+
+	media, _, _ := mime.ParseMediaType(contentType)
+	schema, ok := o.body[media]
+	if !ok {
+		if schema, ok = o.body["application/json"]; !ok {
+			return nil, nil
+		}
+	}
+
+	value, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := schema.Validate(value); err != nil {
+		return fieldErrors(err), nil
+	}
+
+	return nil, nil
+}
+
+func (o *operation) validateResponse(status int, contentType string, body []byte) error {
+	content, ok := o.responses[strconv.Itoa(status)]
+	if !ok {
+		content, ok = o.responses[strconv.Itoa(status/100)+"XX"]
+	}
+	if !ok {
+		content, ok = o.responses["default"]
+	}
+	if !ok {
+		return fmt.Errorf("status %d is not documented", status)
+	}
+
+	if len(body) == 0 {
+		if len(content) > 0 {
+			return fmt.Errorf("status %d must have a body", status)
+		}
+		return nil
+	}
+
+	media, _, _ := mime.ParseMediaType(contentType)
+	schema, ok := content[media]
+	if !ok {
+		return fmt.Errorf("status %d does not document a %q body", status, media)
+	}
+
+	value, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("body is not JSON: %w", err)
+	}
+
+	if err := schema.Validate(value); err != nil {
+		var messages []string
+		for field, msg := range fieldErrors(err) {
+			messages = append(messages, field+": "+msg)
+		}
+		slices.Sort(messages)
+
+		return errors.New(strings.Join(messages, "; "))
+	}
+
+	return nil
+}
+
+// typeErrors explains a parameter value that is not of the JSON type its
+// schema expects.
+var typeErrors = map[string]string{
+	"integer": "Must be an integer",
+	"number":  "Must be a number",
+	"boolean": "Must be a boolean",
+}
+
+// convert turns the string value of a parameter into the JSON type its
+// schema expects. It reports whether the value is of that type.
+func convert(v, typ string) (any, bool) {
+	var (
+		value any
+		err   error
+	)
+	switch typ {
+	case "integer":
+		value, err = strconv.ParseInt(v, 10, 64)
+	case "number":
+		value, err = strconv.ParseFloat(v, 64)
+	case "boolean":
+		value, err = strconv.ParseBool(v)
+	default:
+		value = v
+	}
+
+	return value, err == nil
+}
+
+// fieldErrors flattens a validation error into a message per offending
+// location of the instance, named by its JSON pointer without the leading
+// slash.
+func fieldErrors(err error) map[string]string {
+	errs := make(map[string]string)
+
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		errs[bodyField] = err.Error()
+		return errs
+	}
+
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) > 0 {
+			for _, cause := range e.Causes {
+				walk(cause)
+			}
+			return
+		}
+
+		if required, ok := e.ErrorKind.(*kind.Required); ok {
+			for _, name := range required.Missing {
+				errs[strings.Join(append(e.InstanceLocation, name), "/")] = "Is required"
+			}
+			return
+		}
+
+		field := strings.Join(e.InstanceLocation, "/")
+		if field == "" {
+			field = bodyField
+		}
+		errs[field] = e.ErrorKind.LocalizedString(printer)
+	}
+	walk(verr)
+
+	return errs
+}
+
+// describe is fieldErrors for a value that is a field of its own, such as a
+// parameter.
+func describe(err error) string {
+	var messages []string
+	for _, msg := range fieldErrors(err) {
+		messages = append(messages, msg)
+	}
+	slices.Sort(messages)
+
+	return strings.Join(messages, "; ")
+}
+
+// recorder holds a response back until it has been validated. Headers go
+// straight to the underlying writer, since nothing is sent before flush.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	return r.body.Write(b)
+}
+
+func (r *recorder) flush() {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	r.ResponseWriter.WriteHeader(r.status)
+	r.ResponseWriter.Write(r.body.Bytes())
+}
@@ -0,0 +1,183 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notes-api/internal/openapi"
+	"notes-api/internal/problem"
+)
+
+func serve(t *testing.T, validateResponses bool, handler http.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+
+	spec, err := openapi.Load()
+	require.NoError(t, err)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	w := httptest.NewRecorder()
+	spec.Middleware(log, validateResponses)(handler).ServeHTTP(w, req)
+
+	return w
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) problem.Problem {
+	t.Helper()
+
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+	var p problem.Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&p))
+
+	return p
+}
+
+func unreachable(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler called for an invalid request")
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		errors []problem.FieldError
+	}{
+		{
+			name:   "query parameter of the wrong type",
+			method: http.MethodGet,
+			target: "/notes?limit=ten",
+			errors: []problem.FieldError{{Field: "limit", Detail: "Must be an integer"}},
+		},
+		{
+			name:   "query parameter out of range",
+			method: http.MethodGet,
+			target: "/notes?limit=500&order=sideways",
+			errors: []problem.FieldError{
+				{Field: "limit", Detail: "maximum: got 500, want 100"},
+				{Field: "order", Detail: "value must be one of 'asc', 'desc'"},
+			},
+		},
+		{
+			name:   "path parameter of the wrong type",
+			method: http.MethodGet,
+			target: "/notes/abc/revisions",
+			errors: []problem.FieldError{{Field: "id", Detail: "Must be an integer"}},
+		},
+		{
+			name:   "literal segment wins over a parameter",
+			method: http.MethodGet,
+			target: "/notes/search",
+			errors: []problem.FieldError{{Field: "q", Detail: "Parameter is required"}},
+		},
+		{
+			name:   "body members",
+			method: http.MethodPost,
+			target: "/notes",
+			body:   `{"content": 1, "tags": ["a", 2]}`,
+			errors: []problem.FieldError{
+				{Field: "content", Detail: "got number, want string"},
+				{Field: "tags/1", Detail: "got number, want string"},
+				{Field: "title", Detail: "Is required"},
+			},
+		},
+		{
+			name:   "missing body",
+			method: http.MethodPost,
+			target: "/auth/signin",
+			errors: []problem.FieldError{{Field: "body", Detail: "Request body is required"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			w := serve(t, false, unreachable(t), req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			p := decodeProblem(t, w)
+			assert.Equal(t, problem.ValidationFailed.Code, p.Code)
+			assert.Equal(t, tt.errors, p.Errors)
+		})
+	}
+}
+
+func TestRequestValidationMalformedBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/notes", strings.NewReader(`{"title":`))
+	w := serve(t, false, unreachable(t), req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, problem.InvalidRequest.Code, decodeProblem(t, w).Code)
+}
+
+func TestValidRequestReachesHandler(t *testing.T) {
+	body := `{"title": "Groceries", "tags": ["home"]}`
+
+	var got string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = string(b)
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/notes", strings.NewReader(body))
+	w := serve(t, false, handler, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, body, got, "the handler must still be able to read the body")
+}
+
+func TestUndocumentedRoutePasses(t *testing.T) {
+	called := false
+	handler := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	serve(t, true, handler, httptest.NewRequest(http.MethodGet, "/metrics?limit=ten", nil))
+
+	assert.True(t, called)
+}
+
+func TestResponseValidation(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"1"`)
+		w.Write([]byte(`{"id": "one"}`))
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		w := serve(t, false, handler, httptest.NewRequest(http.MethodGet, "/notes/1", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"id": "one"}`, w.Body.String())
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		w := serve(t, true, handler, httptest.NewRequest(http.MethodGet, "/notes/1", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Empty(t, w.Header().Get("ETag"), "headers of the rejected response must not leak")
+		p := decodeProblem(t, w)
+		assert.Equal(t, problem.Internal.Code, p.Code)
+		assert.Contains(t, p.Detail, "id: got string, want integer")
+	})
+
+	t.Run("conforming", func(t *testing.T) {
+		w := serve(t, true, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"1"`)
+			w.WriteHeader(http.StatusNotModified)
+		}, httptest.NewRequest(http.MethodGet, "/notes/1", nil))
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	})
+}
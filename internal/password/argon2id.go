@@ -0,0 +1,127 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	saltLength = 16
+	keyLength  = 32
+)
+
+// Argon2id hashes passwords with argon2id and encodes them in the PHC string
+// format, "$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>". Memory is in KiB.
+// Zero parameters take the values RFC 9106 recommends for memory-constrained
+// environments: 64 MiB, three passes and four lanes.
+type Argon2id struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (a Argon2id) withDefaults() Argon2id {
+	if a.Memory == 0 {
+		a.Memory = 64 * 1024
+	}
+	if a.Iterations == 0 {
+		a.Iterations = 3
+	}
+	if a.Parallelism == 0 {
+		a.Parallelism = 4
+	}
+
+	return a
+}
+
+func (a Argon2id) Hash(password string) (string, error) {
+	a = a.withDefaults()
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, keyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Iterations, a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a Argon2id) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (a Argon2id) Verify(encoded, password string) error {
+	p, err := parseArgon2id(encoded)
+	if err != nil {
+		return err
+	}
+
+	key := argon2.IDKey([]byte(password), p.salt, p.iterations, p.memory, p.parallelism, uint32(len(p.key)))
+	if subtle.ConstantTimeCompare(key, p.key) != 1 {
+		return ErrMismatch
+	}
+
+	return nil
+}
+
+func (a Argon2id) Outdated(encoded string) bool {
+	p, err := parseArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+
+	a = a.withDefaults()
+
+	return p.memory != a.Memory || p.iterations != a.Iterations || p.parallelism != a.Parallelism ||
+		len(p.salt) != saltLength || len(p.key) != keyLength
+}
+
+func parseArgon2id(encoded string) (argon2Params, error) {
+	var p argon2Params
+
+	// The leading "$" makes the first part empty.
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, fmt.Errorf("%w: malformed argon2id hash", ErrUnknownScheme)
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, fmt.Errorf("%w: unsupported argon2id version %q", ErrUnknownScheme, parts[2])
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return p, fmt.Errorf("%w: malformed argon2id parameters: %v", ErrUnknownScheme, err)
+	}
+	if p.iterations == 0 || p.parallelism == 0 {
+		return p, fmt.Errorf("%w: invalid argon2id parameters %q", ErrUnknownScheme, parts[3])
+	}
+
+	var err error
+	if p.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, fmt.Errorf("%w: malformed argon2id salt: %v", ErrUnknownScheme, err)
+	}
+	if p.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(p.key) == 0 {
+		return p, fmt.Errorf("%w: malformed argon2id hash", ErrUnknownScheme)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,71 @@
+// Package password hashes and verifies user passwords.
+//
+// A Hasher hashes new passwords with one scheme and verifies hashes of that
+// scheme and of any legacy ones, so that the scheme or its parameters can
+// change without resetting anyone's password: Verify reports when a hash is
+// outdated, and the caller, who has the password in hand at that moment,
+// stores a fresh hash in its place.
+package password
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrMismatch = errors.New("password does not match")
+var ErrUnknownScheme = errors.New("unknown password hash scheme")
+
+// Scheme is one way of hashing passwords. Recognizes reports whether an
+// encoded hash was made by the scheme, and Outdated whether it was made with
+// parameters other than the ones the scheme hashes with now.
+type Scheme interface {
+	Hash(password string) (string, error)
+	Recognizes(encoded string) bool
+	Verify(encoded, password string) error
+	Outdated(encoded string) bool
+}
+
+type Hasher struct {
+	current Scheme
+	legacy  []Scheme
+}
+
+// New returns a hasher that hashes with current and also verifies the
+// hashes of legacy.
+func New(current Scheme, legacy ...Scheme) *Hasher {
+	return &Hasher{current: current, legacy: legacy}
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	encoded, err := h.current.Hash(password)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return encoded, nil
+}
+
+// Verify checks password against encoded and reports whether encoded should
+// be replaced with a new hash, because it was made by a legacy scheme or
+// with outdated parameters. It returns ErrMismatch for a wrong password.
+func (h *Hasher) Verify(encoded, password string) (rehash bool, err error) {
+	if h.current.Recognizes(encoded) {
+		if err := h.current.Verify(encoded, password); err != nil {
+			return false, err
+		}
+
+		return h.current.Outdated(encoded), nil
+	}
+
+	for _, scheme := range h.legacy {
+		if scheme.Recognizes(encoded) {
+			if err := scheme.Verify(encoded, password); err != nil {
+				return false, err
+			}
+
+			return true, nil
+		}
+	}
+
+	return false, ErrUnknownScheme
+}
@@ -0,0 +1,87 @@
+package password_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"notes-api/internal/password"
+)
+
+// Cheap parameters keep the tests fast; their strength is not under test.
+var (
+	current  = password.Argon2id{Memory: 1024, Iterations: 1, Parallelism: 1}
+	upgraded = password.Argon2id{Memory: 2048, Iterations: 2, Parallelism: 1}
+	legacy   = password.Bcrypt{Cost: bcrypt.MinCost}
+)
+
+func TestArgon2id(t *testing.T) {
+	h := password.New(current, legacy)
+
+	encoded, err := h.Hash("correct horse")
+	require.NoError(t, err)
+	assert.Regexp(t, `^\$argon2id\$v=19\$m=1024,t=1,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`, encoded)
+
+	other, err := h.Hash("correct horse")
+	require.NoError(t, err)
+	assert.NotEqual(t, encoded, other, "hashes must be salted")
+
+	rehash, err := h.Verify(encoded, "correct horse")
+	require.NoError(t, err)
+	assert.False(t, rehash)
+
+	_, err = h.Verify(encoded, "battery staple")
+	assert.ErrorIs(t, err, password.ErrMismatch)
+}
+
+func TestRehash(t *testing.T) {
+	t.Run("changed parameters", func(t *testing.T) {
+		encoded, err := password.New(current).Hash("correct horse")
+		require.NoError(t, err)
+
+		rehash, err := password.New(upgraded).Verify(encoded, "correct horse")
+		require.NoError(t, err)
+		assert.True(t, rehash)
+	})
+
+	t.Run("legacy scheme", func(t *testing.T) {
+		encoded, err := password.New(legacy).Hash("correct horse")
+		require.NoError(t, err)
+
+		h := password.New(current, legacy)
+
+		rehash, err := h.Verify(encoded, "correct horse")
+		require.NoError(t, err)
+		assert.True(t, rehash)
+
+		_, err = h.Verify(encoded, "battery staple")
+		assert.ErrorIs(t, err, password.ErrMismatch)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		encoded, err := password.New(current).Hash("correct horse")
+		require.NoError(t, err)
+
+		rehash, err := password.New(upgraded).Verify(encoded, "battery staple")
+		assert.ErrorIs(t, err, password.ErrMismatch)
+		assert.False(t, rehash)
+	})
+}
+
+func TestUnknownScheme(t *testing.T) {
+	h := password.New(current, legacy)
+
+	for _, encoded := range []string{
+		"",
+		"plaintext",
+		"$argon2i$v=19$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$aGFzaA",
+		"$argon2id$v=16$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdHNhbHRzYWx0c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$",
+	} {
+		_, err := h.Verify(encoded, "correct horse")
+		assert.ErrorIs(t, err, password.ErrUnknownScheme, encoded)
+	}
+}
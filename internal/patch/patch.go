@@ -0,0 +1,375 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON values.
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+var ErrInvalidPatch = errors.New("invalid patch")
+var ErrTestFailed = errors.New("test operation failed")
+
+// Merge applies a JSON Merge Patch to doc.
+func Merge(doc, patch []byte) ([]byte, error) {
+	var target, p any
+
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+	}
+
+	return json.Marshal(mergeValue(target, p))
+}
+
+func mergeValue(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any)
+	}
+
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+
+		t[key] = mergeValue(t[key], value)
+	}
+
+	return t
+}
+
+// Apply applies the operations of a JSON Patch to doc, atomically: if any
+// operation fails, doc is left as it was and an error is returned.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var target any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+
+	var ops []map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+	}
+
+	for i, op := range ops {
+		var err error
+		target, err = applyOperation(target, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func applyOperation(doc any, op map[string]json.RawMessage) (any, error) {
+	var name string
+	if err := decodeMember(op, "op", &name); err != nil {
+		return nil, err
+	}
+
+	var path string
+	if err := decodeMember(op, "path", &path); err != nil {
+		return nil, err
+	}
+
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "add", "replace", "test":
+		raw, ok := op["value"]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s requires a value", ErrInvalidPatch, name)
+		}
+
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+		}
+
+		switch name {
+		case "add":
+			return add(doc, tokens, value)
+		case "replace":
+			if _, err := get(doc, tokens); err != nil {
+				return nil, err
+			}
+			if doc, _, err = remove(doc, tokens); err != nil {
+				return nil, err
+			}
+			return add(doc, tokens, value)
+		default:
+			current, err := get(doc, tokens)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("%w: value at %q differs", ErrTestFailed, path)
+			}
+			return doc, nil
+		}
+
+	case "remove":
+		doc, _, err = remove(doc, tokens)
+		return doc, err
+
+	case "move", "copy":
+		var from string
+		if err := decodeMember(op, "from", &from); err != nil {
+			return nil, err
+		}
+
+		fromTokens, err := parsePointer(from)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := get(doc, fromTokens)
+		if err != nil {
+			return nil, err
+		}
+
+		if name == "move" {
+			if path != from && strings.HasPrefix(path, from+"/") {
+				return nil, fmt.Errorf("%w: cannot move %q into one of its children", ErrInvalidPatch, from)
+			}
+
+			if doc, _, err = remove(doc, fromTokens); err != nil {
+				return nil, err
+			}
+		} else {
+			value, err = deepCopy(value)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return add(doc, tokens, value)
+	}
+
+	return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, name)
+}
+
+func decodeMember(op map[string]json.RawMessage, name string, dst *string) error {
+	raw, ok := op[name]
+	if !ok {
+		return fmt.Errorf("%w: missing %q", ErrInvalidPatch, name)
+	}
+
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return fmt.Errorf("%w: %q must be a string", ErrInvalidPatch, name)
+	}
+
+	return nil
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if allowEnd && token == "-" {
+		return length, nil
+	}
+
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+
+	limit := length - 1
+	if allowEnd {
+		limit = length
+	}
+
+	if index > limit {
+		return 0, fmt.Errorf("%w: array index %d out of bounds", ErrInvalidPatch, index)
+	}
+
+	return index, nil
+}
+
+func get(doc any, tokens []string) (any, error) {
+	for _, token := range tokens {
+		switch container := doc.(type) {
+		case map[string]any:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: path member %q not found", ErrInvalidPatch, token)
+			}
+			doc = value
+		case []any:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = container[index]
+		default:
+			return nil, fmt.Errorf("%w: cannot traverse into a scalar at %q", ErrInvalidPatch, token)
+		}
+	}
+
+	return doc, nil
+}
+
+func add(doc any, tokens []string, value any) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	token, rest := tokens[0], tokens[1:]
+
+	switch container := doc.(type) {
+	case map[string]any:
+		if len(rest) == 0 {
+			container[token] = value
+			return container, nil
+		}
+
+		child, ok := container[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: path member %q not found", ErrInvalidPatch, token)
+		}
+
+		child, err := add(child, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		container[token] = child
+
+		return container, nil
+
+	case []any:
+		if len(rest) == 0 {
+			index, err := arrayIndex(token, len(container), true)
+			if err != nil {
+				return nil, err
+			}
+
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+
+			return container, nil
+		}
+
+		index, err := arrayIndex(token, len(container), false)
+		if err != nil {
+			return nil, err
+		}
+
+		child, err := add(container[index], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = child
+
+		return container, nil
+	}
+
+	return nil, fmt.Errorf("%w: cannot add into a scalar at %q", ErrInvalidPatch, token)
+}
+
+func remove(doc any, tokens []string) (any, any, error) {
+	if len(tokens) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+
+	token, rest := tokens[0], tokens[1:]
+
+	switch container := doc.(type) {
+	case map[string]any:
+		child, ok := container[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: path member %q not found", ErrInvalidPatch, token)
+		}
+
+		if len(rest) == 0 {
+			delete(container, token)
+			return container, child, nil
+		}
+
+		child, removed, err := remove(child, rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		container[token] = child
+
+		return container, removed, nil
+
+	case []any:
+		index, err := arrayIndex(token, len(container), false)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(rest) == 0 {
+			removed := container[index]
+			return append(container[:index], container[index+1:]...), removed, nil
+		}
+
+		child, removed, err := remove(container[index], rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		container[index] = child
+
+		return container, removed, nil
+	}
+
+	return nil, nil, fmt.Errorf("%w: cannot remove from a scalar at %q", ErrInvalidPatch, token)
+}
+
+func deepCopy(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var copied any
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, err
+	}
+
+	return copied, nil
+}
@@ -0,0 +1,142 @@
+// Package problem writes error responses as RFC 7807 problem details
+// (application/problem+json). Every error the API returns has a stable code,
+// which clients should branch on rather than on the human-readable title and
+// detail.
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const ContentType = "application/problem+json"
+
+// typePrefix namespaces the codes into the problem type URIs. They identify
+// the problem and are not meant to be dereferenced.
+const typePrefix = "urn:notes-api:problem:"
+
+// Kind is a class of problem: what went wrong, regardless of the request it
+// went wrong for.
+type Kind struct {
+	Code   string
+	Title  string
+	Status int
+}
+
+var (
+	InvalidRequest    = Kind{"invalid_request", "Request body could not be read", http.StatusBadRequest}
+	ValidationFailed  = Kind{"validation_failed", "Request failed validation", http.StatusBadRequest}
+	InvalidID         = Kind{"invalid_id", "Invalid ID", http.StatusBadRequest}
+	InvalidRevision   = Kind{"invalid_revision", "Invalid revision", http.StatusBadRequest}
+	InvalidQuery      = Kind{"invalid_query", "Invalid search query", http.StatusBadRequest}
+	InvalidCursor     = Kind{"invalid_cursor", "Invalid cursor", http.StatusBadRequest}
+	InvalidPatch      = Kind{"invalid_patch", "Invalid patch", http.StatusBadRequest}
+	InvalidResetToken = Kind{"invalid_reset_token", "Invalid or expired reset token", http.StatusBadRequest}
+
+	Unauthorized = Kind{"unauthorized", "Authentication required", http.StatusUnauthorized}
+	Forbidden    = Kind{"insufficient_scope", "Token is missing a required scope", http.StatusForbidden}
+	NotFound     = Kind{"not_found", "Resource not found", http.StatusNotFound}
+
+	MethodNotAllowed     = Kind{"method_not_allowed", "Method not allowed", http.StatusMethodNotAllowed}
+	UserExists           = Kind{"user_exists", "User already exists", http.StatusConflict}
+	TokenExists          = Kind{"token_exists", "Token already exists", http.StatusConflict}
+	TagExists            = Kind{"tag_exists", "Tag already exists", http.StatusConflict}
+	PatchTestFailed      = Kind{"patch_test_failed", "Patch test operation failed", http.StatusConflict}
+	NoteConflict         = Kind{"note_conflict", "Note modified concurrently", http.StatusConflict}
+	MFAEnabled           = Kind{"mfa_enabled", "Two-factor sign-in already enabled", http.StatusConflict}
+	MFANotEnabled        = Kind{"mfa_not_enabled", "Two-factor sign-in not enabled", http.StatusConflict}
+	MFASetupRequired     = Kind{"mfa_setup_required", "Two-factor setup not started", http.StatusConflict}
+	PreconditionFailed   = Kind{"precondition_failed", "Precondition failed", http.StatusPreconditionFailed}
+	UnsupportedMediaType = Kind{"unsupported_media_type", "Unsupported media type", http.StatusUnsupportedMediaType}
+	UnpatchableNote      = Kind{"unpatchable_note", "Patched note is malformed", http.StatusUnprocessableEntity}
+	DiffTooLarge         = Kind{"diff_too_large", "Versions too far apart to compare", http.StatusUnprocessableEntity}
+	RateLimited          = Kind{"rate_limited", "Too many requests", http.StatusTooManyRequests}
+	AccountLocked        = Kind{"account_locked", "Account temporarily locked", http.StatusTooManyRequests}
+
+	ClientClosedRequest  = Kind{"client_closed_request", "Client closed request", StatusClientClosedRequest}
+	Internal             = Kind{"internal_error", "Internal server error", http.StatusInternalServerError}
+	MailUnavailable      = Kind{"mail_unavailable", "Mail delivery unavailable", http.StatusNotImplemented}
+	Timeout              = Kind{"timeout", "Request timed out", http.StatusServiceUnavailable}
+	RateLimitUnavailable = Kind{"rate_limit_unavailable", "Rate limiter unavailable", http.StatusServiceUnavailable}
+)
+
+// StatusClientClosedRequest is the non-standard status nginx logs for
+// requests the client gave up on before the response was written.
+const StatusClientClosedRequest = 499
+
+// FieldError is what is wrong with one field of the request.
+type FieldError struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+type Problem struct {
+	Type   string `json:"type"`
+	Code   string `json:"code"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Instance is the ID of the request, as found in the X-Request-Id
+	// header and the logs.
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// New describes an occurrence of kind for r.
+func New(r *http.Request, kind Kind, detail string) *Problem {
+	return &Problem{
+		Type:     typePrefix + kind.Code,
+		Code:     kind.Code,
+		Title:    kind.Title,
+		Status:   kind.Status,
+		Detail:   detail,
+		Instance: middleware.GetReqID(r.Context()),
+	}
+}
+
+// Write responds to r with a problem of kind. detail explains this
+// occurrence of it.
+func Write(w http.ResponseWriter, r *http.Request, kind Kind, detail string) {
+	New(r, kind, detail).Write(w)
+}
+
+// WriteValidation responds to r with the problems Validate found, one entry
+// per field in the errors member, sorted by field.
+func WriteValidation(w http.ResponseWriter, r *http.Request, detail string, errs map[string]string) {
+	p := New(r, ValidationFailed, detail)
+	for field, msg := range errs {
+		p.Errors = append(p.Errors, FieldError{Field: field, Detail: msg})
+	}
+	sort.Slice(p.Errors, func(i, j int) bool { return p.Errors[i].Field < p.Errors[j].Field })
+
+	p.Write(w)
+}
+
+func (p *Problem) Write(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// WriteContextError answers a request whose storage call failed because its
+// context ended: 499 when the client went away, 503 when the query timeout
+// ran out. It reports whether err was such an error, so the caller can fall
+// back to its own error handling otherwise. The request logger records the
+// status, so nothing is logged here.
+func WriteContextError(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case errors.Is(err, context.Canceled):
+		Write(w, r, ClientClosedRequest, "Request was canceled")
+	case errors.Is(err, context.DeadlineExceeded):
+		Write(w, r, Timeout, "Request timed out")
+	default:
+		return false
+	}
+
+	return true
+}
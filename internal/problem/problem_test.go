@@ -0,0 +1,68 @@
+package problem_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notes-api/internal/problem"
+)
+
+func TestWriteValidation(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/notes", nil)
+	r = r.WithContext(context.WithValue(r.Context(), middleware.RequestIDKey, "host/abc-000001"))
+	w := httptest.NewRecorder()
+
+	problem.WriteValidation(w, r, "Invalid note data", map[string]string{
+		"title":   "Title is required",
+		"content": "Content is required",
+	})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+	var got problem.Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, problem.Problem{
+		Type:     "urn:notes-api:problem:validation_failed",
+		Code:     "validation_failed",
+		Title:    problem.ValidationFailed.Title,
+		Status:   http.StatusBadRequest,
+		Detail:   "Invalid note data",
+		Instance: "host/abc-000001",
+		Errors: []problem.FieldError{
+			{Field: "content", Detail: "Content is required"},
+			{Field: "title", Detail: "Title is required"},
+		},
+	}, got)
+}
+
+func TestWriteContextError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{context.Canceled, problem.StatusClientClosedRequest, "client_closed_request"},
+		{fmt.Errorf("sqlite.Notes: %w", context.DeadlineExceeded), http.StatusServiceUnavailable, "timeout"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		require.True(t, problem.WriteContextError(w, httptest.NewRequest(http.MethodGet, "/", nil), tt.err))
+
+		var got problem.Problem
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+		assert.Equal(t, tt.status, w.Code)
+		assert.Equal(t, tt.code, got.Code)
+	}
+
+	assert.False(t, problem.WriteContextError(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), fmt.Errorf("boom")))
+}
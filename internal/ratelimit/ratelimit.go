@@ -0,0 +1,122 @@
+// Package ratelimit throttles clients with token buckets. A bucket holds as
+// many tokens as the limit allows requests per period and refills steadily
+// over that period; every request takes one token.
+//
+// A bucket is kept as the single instant at which it will be full again
+// (the generic cell rate algorithm), so a store only has to remember one
+// timestamp per key and can forget any key whose bucket is already full.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Store keeps the buckets of a limiter. Take removes a token from the
+// bucket under key, refilling at limit. Purge forgets the buckets that are
+// full at now and returns how many there were.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	Purge(ctx context.Context, now time.Time) (int64, error)
+}
+
+// Limit allows Requests requests per Period. A limit without requests or
+// without a period does not limit anything.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// Result is the outcome of taking a token. Reset is how long the bucket
+// takes to fill up again; RetryAfter, set when the request is not allowed,
+// is how long until it holds a token.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Take takes a token at now from the bucket that is full at fullAt, which
+// is zero for a bucket never used. It returns when the bucket is full
+// afterwards, which is fullAt itself if the request is not allowed.
+func (l Limit) Take(fullAt, now time.Time) (time.Time, Result) {
+	interval := l.Period / time.Duration(l.Requests)
+
+	if fullAt.Before(now) {
+		fullAt = now
+	}
+	next := fullAt.Add(interval)
+
+	if wait := next.Sub(now) - l.Period; wait > 0 {
+		return fullAt, Result{
+			Limit:      l.Requests,
+			Reset:      fullAt.Sub(now),
+			RetryAfter: wait,
+		}
+	}
+
+	return next, Result{
+		Allowed:   true,
+		Limit:     l.Requests,
+		Remaining: int((l.Period - next.Sub(now)) / interval),
+		Reset:     next.Sub(now),
+	}
+}
+
+// Seconds rounds d up to whole seconds, the resolution of the headers that
+// tell clients how long to wait.
+func Seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// Memory keeps buckets in process memory. Every process has buckets of its
+// own, so a client of several processes gets the limit from each of them.
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]time.Time)}
+}
+
+func (m *Memory) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fullAt, res := limit.Take(m.buckets[key], time.Now())
+	m.buckets[key] = fullAt
+
+	return res, nil
+}
+
+func (m *Memory) Purge(ctx context.Context, now time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var purged int64
+	for key, fullAt := range m.buckets {
+		if !fullAt.After(now) {
+			delete(m.buckets, key)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,76 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notes-api/internal/ratelimit"
+)
+
+func TestLimitTake(t *testing.T) {
+	limit := ratelimit.Limit{Requests: 3, Period: 3 * time.Second}
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var fullAt time.Time
+	var res ratelimit.Result
+	for want := 2; want >= 0; want-- {
+		fullAt, res = limit.Take(fullAt, now)
+		require.True(t, res.Allowed)
+		assert.Equal(t, want, res.Remaining)
+	}
+	assert.Equal(t, 3*time.Second, res.Reset)
+
+	denied, res := limit.Take(fullAt, now)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, fullAt, denied, "a denied request must not take a token")
+	assert.Equal(t, time.Second, res.RetryAfter)
+	assert.Equal(t, 0, res.Remaining)
+
+	// One token refills per second.
+	_, res = limit.Take(fullAt, now.Add(1500*time.Millisecond))
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	// A bucket that has been full for a while holds no more than the limit.
+	_, res = limit.Take(fullAt, now.Add(time.Hour))
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 2, res.Remaining)
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	limit := ratelimit.Limit{Requests: 2, Period: time.Hour}
+	m := ratelimit.NewMemory()
+
+	for range 2 {
+		res, err := m.Take(ctx, "ip:192.0.2.1", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+	}
+
+	res, err := m.Take(ctx, "ip:192.0.2.1", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.InDelta(t, 30*time.Minute, res.RetryAfter, float64(time.Second))
+
+	res, err = m.Take(ctx, "ip:192.0.2.2", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed, "buckets are per key")
+
+	purged, err := m.Purge(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	purged, err = m.Purge(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+
+	res, err = m.Take(ctx, "ip:192.0.2.1", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining)
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// Cursor is the position of the last note on a page. It is handed to clients
+// as an opaque string and is only valid for the sort it was issued for.
+type Cursor struct {
+	SortBy string `json:"s"`
+	Order  string `json:"o"`
+	Value  string `json:"v"`
+	ID     int    `json:"i"`
+}
+
+func EncodeCursor(c Cursor) (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor parses a cursor issued for the given sort, returning
+// ErrInvalidCursor when it is malformed or was issued for another one.
+func DecodeCursor(s, sortBy, order string) (Cursor, error) {
+	var c Cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	if c.SortBy != sortBy || c.Order != order {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
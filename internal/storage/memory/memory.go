@@ -0,0 +1,127 @@
+// Package memory keeps users and notes in process memory. It follows the
+// same rules as the database backends and suits tests and throwaway demo
+// instances; everything is lost when the process exits.
+package memory
+
+import (
+	"context"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+	"sync"
+	"time"
+)
+
+// timestampLayout matches the format the database backends hand out
+// timestamps in. Like theirs, it has a resolution of one second.
+const timestampLayout = "2006-01-02 15:04:05"
+
+type note struct {
+	models.Note
+	revisions []models.Revision
+}
+
+type refreshToken struct {
+	userID    int64
+	familyID  string
+	expiresAt time.Time
+	used      bool
+	revoked   bool
+}
+
+type resetToken struct {
+	userID    int64
+	expiresAt time.Time
+	used      bool
+}
+
+type personalToken struct {
+	models.PersonalAccessToken
+	hash      string
+	expiresAt time.Time
+}
+
+type Storage struct {
+	mu sync.RWMutex
+
+	users      map[string]*models.User
+	lastUserID int64
+
+	notes      map[int]*note
+	lastNoteID int
+
+	refreshTokens map[string]*refreshToken
+	// revokedTokens maps the jti of a revoked access token to its expiry.
+	revokedTokens map[string]time.Time
+
+	personalTokens map[int64]*personalToken
+	lastTokenID    int64
+
+	resetTokens map[string]*resetToken
+
+	// totpSteps maps a user ID to the time step of the last code the user
+	// gave, and recoveryCodes to the hashes of the user's recovery codes,
+	// true once spent.
+	totpSteps     map[int64]int64
+	recoveryCodes map[int64]map[string]bool
+	mfaChallenges map[string]*models.MFAChallenge
+}
+
+func New() *Storage {
+	return &Storage{
+		users:          make(map[string]*models.User),
+		notes:          make(map[int]*note),
+		refreshTokens:  make(map[string]*refreshToken),
+		revokedTokens:  make(map[string]time.Time),
+		personalTokens: make(map[int64]*personalToken),
+		resetTokens:    make(map[string]*resetToken),
+		totpSteps:      make(map[int64]int64),
+		recoveryCodes:  make(map[int64]map[string]bool),
+		mfaChallenges:  make(map[string]*models.MFAChallenge),
+	}
+}
+
+// Ping only fails once ctx is done; the storage itself is always reachable.
+func (s *Storage) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// PendingMigrations always returns zero, as there is no schema to migrate.
+func (s *Storage) PendingMigrations() (int, error) {
+	return 0, nil
+}
+
+// Close is a no-op; there is nothing to flush.
+func (s *Storage) Close() error {
+	return nil
+}
+
+func now() string {
+	return format(time.Now())
+}
+
+func format(t time.Time) string {
+	return t.UTC().Format(timestampLayout)
+}
+
+// liveNote returns the note with id unless it belongs to another user or is
+// in the trash. Callers must hold the lock.
+func (s *Storage) liveNote(id, userID int) (*note, error) {
+	n, ok := s.notes[id]
+	if !ok || n.UserID != userID || n.DeletedAt != nil {
+		return nil, storage.ErrNoteNotFound
+	}
+
+	return n, nil
+}
+
+// copyNote returns a copy of n that shares no memory with the store.
+func copyNote(n *note) models.Note {
+	c := n.Note
+	c.Tags = append([]string{}, n.Tags...)
+	if n.DeletedAt != nil {
+		deletedAt := *n.DeletedAt
+		c.DeletedAt = &deletedAt
+	}
+
+	return c
+}
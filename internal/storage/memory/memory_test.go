@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"notes-api/internal/app"
+	"notes-api/internal/storage/memory"
+	"notes-api/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) app.Storage {
+		return memory.New()
+	})
+}
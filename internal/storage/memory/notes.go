@@ -0,0 +1,308 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+	"slices"
+	"time"
+)
+
+func (s *Storage) CreateNote(ctx context.Context, userID int, title, content string, tags []string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastNoteID++
+	created := now()
+	s.notes[s.lastNoteID] = &note{Note: models.Note{
+		ID:        s.lastNoteID,
+		UserID:    userID,
+		Title:     title,
+		Content:   content,
+		CreatedAt: created,
+		UpdatedAt: created,
+		Version:   1,
+		Tags:      normalizeTags(tags),
+	}}
+
+	return int64(s.lastNoteID), nil
+}
+
+func (s *Storage) Note(ctx context.Context, id, userID int) (*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n, err := s.liveNote(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := copyNote(n)
+	return &result, nil
+}
+
+func (s *Storage) Notes(ctx context.Context, userID int, query models.NotesQuery) (*models.NotesPage, error) {
+	const op = "memory.Notes"
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, ok := sortValues[query.SortBy]; !ok {
+		return nil, fmt.Errorf("%s: unsupported sort key %q", op, query.SortBy)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matches []*note
+	for _, n := range s.notes {
+		if n.UserID == userID && (n.DeletedAt != nil) == query.Trashed && matchesQuery(n, query) {
+			matches = append(matches, n)
+		}
+	}
+
+	desc := query.Order == "desc"
+	compare := func(a, b *note) int {
+		c := cmp.Or(cmp.Compare(sortValue(a, query.SortBy), sortValue(b, query.SortBy)), cmp.Compare(a.ID, b.ID))
+		if desc {
+			return -c
+		}
+		return c
+	}
+	slices.SortFunc(matches, compare)
+
+	page := &models.NotesPage{Notes: []models.Note{}, Total: len(matches)}
+
+	if query.Cursor != "" {
+		c, err := storage.DecodeCursor(query.Cursor, query.SortBy, query.Order)
+		if err != nil {
+			return nil, err
+		}
+
+		// Skip every note at or before the cursor position.
+		matches = slices.DeleteFunc(matches, func(n *note) bool {
+			position := cmp.Or(cmp.Compare(sortValue(n, query.SortBy), c.Value), cmp.Compare(n.ID, c.ID))
+			if desc {
+				return position >= 0
+			}
+			return position <= 0
+		})
+	}
+
+	for i, n := range matches {
+		if i == query.Limit {
+			last := page.Notes[len(page.Notes)-1]
+
+			var err error
+			page.NextCursor, err = storage.EncodeCursor(storage.Cursor{
+				SortBy: query.SortBy,
+				Order:  query.Order,
+				Value:  sortValues[query.SortBy](last),
+				ID:     last.ID,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("%s: failed to encode cursor: %w", op, err)
+			}
+			break
+		}
+
+		page.Notes = append(page.Notes, copyNote(n))
+	}
+
+	return page, nil
+}
+
+var sortValues = map[string]func(note models.Note) string{
+	"created_at": func(note models.Note) string { return note.CreatedAt },
+	"updated_at": func(note models.Note) string { return note.UpdatedAt },
+	"title":      func(note models.Note) string { return note.Title },
+	"deleted_at": func(note models.Note) string {
+		if note.DeletedAt != nil {
+			return *note.DeletedAt
+		}
+		return ""
+	},
+}
+
+func sortValue(n *note, sortBy string) string {
+	return sortValues[sortBy](n.Note)
+}
+
+// matchesQuery applies the date range and tag filters of query to n.
+func matchesQuery(n *note, query models.NotesQuery) bool {
+	inRange := func(value string, after, before time.Time) bool {
+		return (after.IsZero() || value >= format(after)) && (before.IsZero() || value < format(before))
+	}
+
+	if !inRange(n.CreatedAt, query.CreatedAfter, query.CreatedBefore) || !inRange(n.UpdatedAt, query.UpdatedAfter, query.UpdatedBefore) {
+		return false
+	}
+
+	if len(query.Tags) == 0 {
+		return true
+	}
+
+	matched := 0
+	for _, tag := range query.Tags {
+		if slices.Contains(n.Tags, tag) {
+			matched++
+		}
+	}
+
+	if query.TagMode == models.TagModeAll {
+		return matched == len(query.Tags)
+	}
+
+	return matched > 0
+}
+
+// DeleteNote moves a note to the trash. A non-zero version makes the delete
+// conditional on the note still being at that version.
+func (s *Storage) DeleteNote(ctx context.Context, id, userID, version int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, err := s.liveNote(id, userID)
+	if err != nil {
+		return err
+	}
+
+	if version != 0 && n.Version != version {
+		return storage.ErrVersionMismatch
+	}
+
+	deletedAt := now()
+	n.DeletedAt = &deletedAt
+
+	return nil
+}
+
+func (s *Storage) RestoreNote(ctx context.Context, id, userID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.notes[id]
+	if !ok || n.UserID != userID || n.DeletedAt == nil {
+		return storage.ErrNoteNotFound
+	}
+
+	n.DeletedAt = nil
+
+	return nil
+}
+
+// PurgeNote permanently deletes a note, whether it is in the trash or not.
+// version works as in DeleteNote.
+func (s *Storage) PurgeNote(ctx context.Context, id, userID, version int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.notes[id]
+	if !ok || n.UserID != userID {
+		return storage.ErrNoteNotFound
+	}
+
+	if version != 0 && n.Version != version {
+		return storage.ErrVersionMismatch
+	}
+
+	delete(s.notes, id)
+
+	return nil
+}
+
+// PurgeTrash permanently deletes every note that was trashed before cutoff
+// and returns how many were removed.
+func (s *Storage) PurgeTrash(ctx context.Context, cutoff time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for id, n := range s.notes {
+		if n.DeletedAt != nil && *n.DeletedAt < format(cutoff) {
+			delete(s.notes, id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// UpdateNote overwrites the title and content of a note and returns its new
+// version. A nil tags slice leaves the tags untouched. A non-zero version
+// turns the update into a compare-and-swap.
+func (s *Storage) UpdateNote(ctx context.Context, id, userID int, title, content string, tags []string, version int) (int, error) {
+	note, err := s.PatchNote(ctx, id, userID, models.NotePatch{Title: &title, Content: &content, Tags: tags}, version)
+	if err != nil {
+		return 0, err
+	}
+
+	return note.Version, nil
+}
+
+// PatchNote updates only the fields set in patch and returns the note as it
+// is afterwards. version works as in UpdateNote.
+func (s *Storage) PatchNote(ctx context.Context, id, userID int, patch models.NotePatch, version int) (*models.Note, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, err := s.liveNote(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != 0 && n.Version != version {
+		return nil, storage.ErrVersionMismatch
+	}
+
+	n.recordRevision(patch.Title, patch.Content)
+
+	if patch.Title != nil {
+		n.Title = *patch.Title
+	}
+
+	if patch.Content != nil {
+		n.Content = *patch.Content
+	}
+
+	if patch.Tags != nil {
+		n.Tags = normalizeTags(patch.Tags)
+	}
+
+	n.UpdatedAt = now()
+	n.Version++
+
+	result := copyNote(n)
+	return &result, nil
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"notes-api/internal/storage"
+	"time"
+)
+
+// CreatePasswordResetToken stores the hash of a token that lets userID set
+// a new password until expiresAt.
+func (s *Storage) CreatePasswordResetToken(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resetTokens[tokenHash] = &resetToken{userID: userID, expiresAt: expiresAt}
+
+	return nil
+}
+
+// ResetPassword spends the reset token with tokenHash to replace the
+// password hash of its user, whom it returns. Like SetPassword, it revokes
+// every refresh token of the user; it also spends every other reset token
+// the user was sent. An unknown, spent or expired token yields
+// storage.ErrResetTokenNotFound.
+func (s *Storage) ResetPassword(ctx context.Context, tokenHash, hash string) (int64, error) {
+	const op = "memory.ResetPassword"
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.resetTokens[tokenHash]
+	if !ok || token.used || !token.expiresAt.After(time.Now()) {
+		return 0, storage.ErrResetTokenNotFound
+	}
+
+	for _, other := range s.resetTokens {
+		if other.userID == token.userID {
+			other.used = true
+		}
+	}
+
+	if !s.setPassword(token.userID, hash) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	return token.userID, nil
+}
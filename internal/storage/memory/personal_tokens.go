@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+	"slices"
+	"time"
+)
+
+func (s *Storage) CreatePersonalAccessToken(ctx context.Context, userID int64, name string, scopes []string, tokenHash string, expiresAt time.Time) (*models.PersonalAccessToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, token := range s.personalTokens {
+		if (token.UserID == userID && token.Name == name) || token.hash == tokenHash {
+			return nil, storage.ErrTokenAlreadyExists
+		}
+	}
+
+	s.lastTokenID++
+	token := &personalToken{
+		PersonalAccessToken: models.PersonalAccessToken{
+			ID:        s.lastTokenID,
+			UserID:    userID,
+			Name:      name,
+			Scopes:    slices.Clone(scopes),
+			CreatedAt: now(),
+			ExpiresAt: format(expiresAt),
+		},
+		hash:      tokenHash,
+		expiresAt: expiresAt,
+	}
+	s.personalTokens[token.ID] = token
+
+	result := copyPersonalToken(token)
+	return &result, nil
+}
+
+func (s *Storage) PersonalAccessTokens(ctx context.Context, userID int64) ([]models.PersonalAccessToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	current := time.Now()
+
+	tokens := []models.PersonalAccessToken{}
+	for _, token := range s.personalTokens {
+		if token.UserID == userID && token.expiresAt.After(current) {
+			tokens = append(tokens, copyPersonalToken(token))
+		}
+	}
+
+	slices.SortFunc(tokens, func(a, b models.PersonalAccessToken) int {
+		return cmp.Or(cmp.Compare(b.CreatedAt, a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+
+	return tokens, nil
+}
+
+func (s *Storage) DeletePersonalAccessToken(ctx context.Context, userID, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.personalTokens[id]
+	if !ok || token.UserID != userID {
+		return storage.ErrTokenNotFound
+	}
+
+	delete(s.personalTokens, id)
+
+	return nil
+}
+
+// AuthenticatePersonalAccessToken looks up an unexpired token by its hash and
+// records that it was just used.
+func (s *Storage) AuthenticatePersonalAccessToken(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, token := range s.personalTokens {
+		if token.hash != tokenHash || !token.expiresAt.After(time.Now()) {
+			continue
+		}
+
+		lastUsedAt := now()
+		token.LastUsedAt = &lastUsedAt
+
+		result := copyPersonalToken(token)
+		return &result, nil
+	}
+
+	return nil, storage.ErrTokenNotFound
+}
+
+func copyPersonalToken(token *personalToken) models.PersonalAccessToken {
+	c := token.PersonalAccessToken
+	c.Scopes = slices.Clone(token.Scopes)
+	if token.LastUsedAt != nil {
+		lastUsedAt := *token.LastUsedAt
+		c.LastUsedAt = &lastUsedAt
+	}
+
+	return c
+}
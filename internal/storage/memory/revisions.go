@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"context"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+	"slices"
+	"time"
+)
+
+func (s *Storage) NoteRevisions(ctx context.Context, noteID, userID int) ([]models.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n, err := s.liveNote(noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := slices.Clone(n.revisions)
+	if revisions == nil {
+		revisions = []models.Revision{}
+	}
+	slices.Reverse(revisions)
+
+	return revisions, nil
+}
+
+func (s *Storage) NoteRevision(ctx context.Context, noteID, userID, revision int) (*models.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n, err := s.liveNote(noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range n.revisions {
+		if r.Revision == revision {
+			return &r, nil
+		}
+	}
+
+	return nil, storage.ErrRevisionNotFound
+}
+
+// RestoreRevision makes an old revision the current version of a note. The
+// version it replaces is kept as a new revision, so a restore can be undone.
+func (s *Storage) RestoreRevision(ctx context.Context, noteID, userID, revision int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, err := s.liveNote(noteID, userID)
+	if err != nil {
+		return err
+	}
+
+	i := slices.IndexFunc(n.revisions, func(r models.Revision) bool { return r.Revision == revision })
+	if i < 0 {
+		return storage.ErrRevisionNotFound
+	}
+
+	title, content := n.revisions[i].Title, n.revisions[i].Content
+	n.recordRevision(&title, &content)
+
+	n.Title, n.Content = title, content
+	n.UpdatedAt = now()
+	n.Version++
+
+	return nil
+}
+
+// PruneRevisions deletes revisions beyond the newest maxPerNote of each note
+// and revisions created before cutoff. A zero maxPerNote or cutoff disables
+// the respective rule.
+func (s *Storage) PruneRevisions(ctx context.Context, maxPerNote int, cutoff time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pruned int64
+	for _, n := range s.notes {
+		before := len(n.revisions)
+
+		if maxPerNote > 0 && len(n.revisions) > maxPerNote {
+			n.revisions = slices.Clone(n.revisions[len(n.revisions)-maxPerNote:])
+		}
+
+		if !cutoff.IsZero() {
+			n.revisions = slices.DeleteFunc(n.revisions, func(r models.Revision) bool {
+				return r.CreatedAt < format(cutoff)
+			})
+		}
+
+		pruned += int64(before - len(n.revisions))
+	}
+
+	return pruned, nil
+}
+
+// recordRevision saves the current title and content of n as its next
+// revision, unless they are identical to the new title and content.
+func (n *note) recordRevision(title, content *string) {
+	if (title == nil || *title == n.Title) && (content == nil || *content == n.Content) {
+		return
+	}
+
+	next := 1
+	if len(n.revisions) > 0 {
+		next = n.revisions[len(n.revisions)-1].Revision + 1
+	}
+
+	n.revisions = append(n.revisions, models.Revision{
+		NoteID:    n.ID,
+		Revision:  next,
+		Title:     n.Title,
+		Content:   n.Content,
+		CreatedAt: n.UpdatedAt,
+	})
+}
@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+	"slices"
+	"strings"
+)
+
+const (
+	highlightOpen  = "<mark>"
+	highlightClose = "</mark>"
+
+	// snippetWords is how many words of content a snippet shows.
+	snippetWords = 24
+)
+
+// SearchNotes finds notes containing every word of the query in their title
+// or content, ignoring case. It is much simpler than the full-text search of
+// the database backends: there is no query syntax, and words match anywhere,
+// not only at word boundaries.
+func (s *Storage) SearchNotes(ctx context.Context, userID int, query models.SearchQuery) ([]models.SearchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	terms := strings.Fields(strings.ToLower(query.Query))
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("%w: empty query", storage.ErrInvalidSearchQuery)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []models.SearchResult
+	for _, n := range s.notes {
+		if n.UserID != userID || n.DeletedAt != nil {
+			continue
+		}
+
+		title, content := strings.ToLower(n.Title), strings.ToLower(n.Content)
+
+		// Title matches weigh more than content matches. Lower ranks are
+		// better, as with bm25.
+		var rank float64
+		matches := true
+		for _, term := range terms {
+			inTitle, inContent := strings.Count(title, term), strings.Count(content, term)
+			if inTitle+inContent == 0 {
+				matches = false
+				break
+			}
+
+			rank -= float64(10*inTitle + inContent)
+		}
+
+		if !matches {
+			continue
+		}
+
+		results = append(results, models.SearchResult{
+			Note:           copyNote(n),
+			Rank:           rank,
+			TitleHighlight: highlight(n.Title, terms),
+			Snippet:        highlight(snippet(n.Content, terms), terms),
+		})
+	}
+
+	slices.SortFunc(results, func(a, b models.SearchResult) int {
+		return cmp.Or(cmp.Compare(a.Rank, b.Rank), cmp.Compare(a.ID, b.ID))
+	})
+
+	page := []models.SearchResult{}
+	if query.Offset < len(results) {
+		page = append(page, results[query.Offset:min(query.Offset+query.Limit, len(results))]...)
+	}
+
+	return page, nil
+}
+
+// highlight wraps every occurrence of terms in text with highlight marks.
+func highlight(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// Offsets into lower would not line up with text.
+		return text
+	}
+
+	// marked[i] is set for every byte of text inside a match.
+	marked := make([]bool, len(text))
+	for _, term := range terms {
+		for i := 0; ; {
+			j := strings.Index(lower[i:], term)
+			if j < 0 {
+				break
+			}
+
+			for k := i + j; k < i+j+len(term); k++ {
+				marked[k] = true
+			}
+			i += j + len(term)
+		}
+	}
+
+	var sb strings.Builder
+	for i := range len(text) {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			sb.WriteString(highlightOpen)
+		}
+
+		sb.WriteByte(text[i])
+
+		if marked[i] && (i == len(text)-1 || !marked[i+1]) {
+			sb.WriteString(highlightClose)
+		}
+	}
+
+	return sb.String()
+}
+
+// snippet cuts content down to snippetWords words around the first match.
+func snippet(content string, terms []string) string {
+	words := strings.Fields(content)
+	if len(words) <= snippetWords {
+		return content
+	}
+
+	start := slices.IndexFunc(words, func(word string) bool {
+		word = strings.ToLower(word)
+		return slices.ContainsFunc(terms, func(term string) bool { return strings.Contains(word, term) })
+	})
+	start = max(0, min(start-snippetWords/4, len(words)-snippetWords))
+
+	text := strings.Join(words[start:start+snippetWords], " ")
+	if start > 0 {
+		text = "…" + text
+	}
+	if start+snippetWords < len(words) {
+		text += "…"
+	}
+
+	return text
+}
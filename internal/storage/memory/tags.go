@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"context"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+	"slices"
+	"strings"
+)
+
+// Tags are not stored on their own: a user has a tag for as long as one of
+// their notes, trashed or not, carries it. That matches the database
+// backends, which delete tags no note uses anymore.
+
+func (s *Storage) Tags(ctx context.Context, userID int) ([]models.Tag, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, n := range s.notes {
+		if n.UserID != userID || n.DeletedAt != nil {
+			continue
+		}
+
+		for _, tag := range n.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := []models.Tag{}
+	for name, count := range counts {
+		tags = append(tags, models.Tag{Name: name, Count: count})
+	}
+
+	slices.SortFunc(tags, func(a, b models.Tag) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return tags, nil
+}
+
+func (s *Storage) RenameTag(ctx context.Context, userID int, name, newName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.tagExists(userID, name) {
+		return storage.ErrTagNotFound
+	}
+
+	if name != newName && s.tagExists(userID, newName) {
+		return storage.ErrTagAlreadyExists
+	}
+
+	for _, n := range s.taggedNotes(userID, name) {
+		n.Tags = normalizeTags(replaceTag(n.Tags, name, newName))
+	}
+
+	return nil
+}
+
+// MergeTags moves every note tagged with one of sources onto target and
+// removes the source tags.
+func (s *Storage) MergeTags(ctx context.Context, userID int, sources []string, target string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Check every source before changing anything, so that a missing one
+	// leaves all notes as they were.
+	for _, source := range sources {
+		if source != target && !s.tagExists(userID, source) {
+			return storage.ErrTagNotFound
+		}
+	}
+
+	for _, source := range sources {
+		if source == target {
+			continue
+		}
+
+		for _, n := range s.taggedNotes(userID, source) {
+			n.Tags = normalizeTags(replaceTag(n.Tags, source, target))
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) tagExists(userID int, name string) bool {
+	for _, n := range s.notes {
+		if n.UserID == userID && slices.Contains(n.Tags, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Storage) taggedNotes(userID int, name string) []*note {
+	var notes []*note
+	for _, n := range s.notes {
+		if n.UserID == userID && slices.Contains(n.Tags, name) {
+			notes = append(notes, n)
+		}
+	}
+
+	return notes
+}
+
+func replaceTag(tags []string, old, new string) []string {
+	replaced := slices.Clone(tags)
+	for i, tag := range replaced {
+		if tag == old {
+			replaced[i] = new
+		}
+	}
+
+	return replaced
+}
+
+// normalizeTags returns a sorted copy of tags without duplicates, the order
+// the database backends return tags in.
+func normalizeTags(tags []string) []string {
+	normalized := slices.Clone(tags)
+	if normalized == nil {
+		normalized = []string{}
+	}
+
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
@@ -0,0 +1,177 @@
+package memory
+
+import (
+	"context"
+	"notes-api/internal/storage"
+	"time"
+)
+
+// CreateRefreshToken stores the hash of a refresh token issued to userID.
+// Tokens obtained by rotating one another share a family.
+func (s *Storage) CreateRefreshToken(ctx context.Context, userID int64, familyID, tokenHash string, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refreshTokens[tokenHash] = &refreshToken{userID: userID, familyID: familyID, expiresAt: expiresAt}
+
+	return nil
+}
+
+// RotateRefreshToken spends the refresh token with tokenHash and stores
+// newHash in its place, returning the user the tokens belong to. Presenting a
+// token that was already spent revokes its whole family and returns
+// storage.ErrRefreshTokenReused.
+func (s *Storage) RotateRefreshToken(ctx context.Context, tokenHash, newHash string, expiresAt time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.refreshTokens[tokenHash]
+	if !ok {
+		return 0, storage.ErrRefreshTokenNotFound
+	}
+
+	if token.used {
+		s.revokeTokenFamily(token.familyID)
+		return 0, storage.ErrRefreshTokenReused
+	}
+
+	if token.revoked || !token.expiresAt.After(time.Now()) {
+		return 0, storage.ErrRefreshTokenNotFound
+	}
+
+	token.used = true
+	s.refreshTokens[newHash] = &refreshToken{userID: token.userID, familyID: token.familyID, expiresAt: expiresAt}
+
+	return token.userID, nil
+}
+
+// RevokeRefreshToken revokes the family of a refresh token of userID, ending
+// the session it belongs to. Unknown tokens are ignored.
+func (s *Storage) RevokeRefreshToken(ctx context.Context, userID int64, tokenHash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token, ok := s.refreshTokens[tokenHash]; ok && token.userID == userID {
+		s.revokeTokenFamily(token.familyID)
+	}
+
+	return nil
+}
+
+// RevokeUserRefreshTokens revokes every refresh token of userID.
+func (s *Storage) RevokeUserRefreshTokens(ctx context.Context, userID int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, token := range s.refreshTokens {
+		if token.userID == userID {
+			token.revoked = true
+		}
+	}
+
+	return nil
+}
+
+// RevokeAccessToken adds the jti of an access token to the denylist until
+// the token expires anyway.
+func (s *Storage) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.revokedTokens[jti]; !ok {
+		s.revokedTokens[jti] = expiresAt
+	}
+
+	return nil
+}
+
+func (s *Storage) AccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.revokedTokens[jti]
+	return ok, nil
+}
+
+// PurgeExpiredTokens deletes refresh tokens, denylist entries, personal
+// access tokens, password reset tokens and sign-in challenges that expired
+// before now.
+func (s *Storage) PurgeExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+
+	for hash, token := range s.refreshTokens {
+		if !token.expiresAt.After(now) {
+			delete(s.refreshTokens, hash)
+			purged++
+		}
+	}
+
+	for jti, expiresAt := range s.revokedTokens {
+		if !expiresAt.After(now) {
+			delete(s.revokedTokens, jti)
+			purged++
+		}
+	}
+
+	for id, token := range s.personalTokens {
+		if !token.expiresAt.After(now) {
+			delete(s.personalTokens, id)
+			purged++
+		}
+	}
+
+	for hash, token := range s.resetTokens {
+		if !token.expiresAt.After(now) {
+			delete(s.resetTokens, hash)
+			purged++
+		}
+	}
+
+	for hash, challenge := range s.mfaChallenges {
+		if !challenge.ExpiresAt.After(now) {
+			delete(s.mfaChallenges, hash)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func (s *Storage) revokeTokenFamily(familyID string) {
+	for _, token := range s.refreshTokens {
+		if token.familyID == familyID {
+			token.revoked = true
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+	"time"
+)
+
+// SetTOTPSecret begins two-factor setup for userID with secret, replacing
+// the secret of any setup the user did not confirm. It does not enable two
+// factor sign-ins; EnableTOTP does.
+func (s *Storage) SetTOTPSecret(ctx context.Context, userID int64, secret string) error {
+	const op = "memory.SetTOTPSecret"
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.userByID(userID)
+	if user == nil {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	user.TOTPSecret = secret
+	user.TOTPEnabled = false
+	delete(s.totpSteps, userID)
+
+	return nil
+}
+
+// EnableTOTP turns on two-factor sign-ins for userID and replaces the
+// recovery codes of the user with the ones with codeHashes.
+func (s *Storage) EnableTOTP(ctx context.Context, userID int64, codeHashes []string) error {
+	const op = "memory.EnableTOTP"
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.userByID(userID)
+	if user == nil || user.TOTPSecret == "" {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	user.TOTPEnabled = true
+
+	codes := make(map[string]bool, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes[hash] = false
+	}
+	s.recoveryCodes[userID] = codes
+
+	return nil
+}
+
+// DisableTOTP turns off two-factor sign-ins for userID and forgets its
+// secret, recovery codes and pending challenges.
+func (s *Storage) DisableTOTP(ctx context.Context, userID int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user := s.userByID(userID); user != nil {
+		user.TOTPSecret = ""
+		user.TOTPEnabled = false
+	}
+
+	delete(s.totpSteps, userID)
+	delete(s.recoveryCodes, userID)
+
+	for hash, challenge := range s.mfaChallenges {
+		if challenge.UserID == userID {
+			delete(s.mfaChallenges, hash)
+		}
+	}
+
+	return nil
+}
+
+// UseTOTPStep records that userID gave the code of time step step. It
+// reports false if a code of that step or a later one was given before, in
+// which case the code must be refused as a replay.
+func (s *Storage) UseTOTPStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.userByID(userID) == nil || s.totpSteps[userID] >= step {
+		return false, nil
+	}
+
+	s.totpSteps[userID] = step
+
+	return true, nil
+}
+
+// UseRecoveryCode spends the recovery code of userID with codeHash. An
+// unknown or spent code yields storage.ErrRecoveryCodeNotFound.
+func (s *Storage) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	const op = "memory.UseRecoveryCode"
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	used, ok := s.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return fmt.Errorf("%s: %w", op, storage.ErrRecoveryCodeNotFound)
+	}
+
+	s.recoveryCodes[userID][codeHash] = true
+
+	return nil
+}
+
+// CreateMFAChallenge stores the hash of a token that lets userID finish
+// signing in with a second factor until expiresAt.
+func (s *Storage) CreateMFAChallenge(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mfaChallenges[tokenHash] = &models.MFAChallenge{
+		UserID:    userID,
+		ExpiresAt: expiresAt.UTC().Truncate(time.Second),
+	}
+
+	return nil
+}
+
+// MFAChallenge returns the challenge with tokenHash. An unknown or expired
+// one yields storage.ErrMFAChallengeNotFound.
+func (s *Storage) MFAChallenge(ctx context.Context, tokenHash string) (*models.MFAChallenge, error) {
+	const op = "memory.MFAChallenge"
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	challenge, ok := s.mfaChallenges[tokenHash]
+	if !ok || !challenge.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrMFAChallengeNotFound)
+	}
+
+	c := *challenge
+	return &c, nil
+}
+
+// RecordMFAChallengeFailure counts a wrong code given for the challenge
+// with tokenHash and returns how many there have been.
+func (s *Storage) RecordMFAChallengeFailure(ctx context.Context, tokenHash string) (int, error) {
+	const op = "memory.RecordMFAChallengeFailure"
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	challenge, ok := s.mfaChallenges[tokenHash]
+	if !ok {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrMFAChallengeNotFound)
+	}
+
+	challenge.Attempts++
+
+	return challenge.Attempts, nil
+}
+
+// DeleteMFAChallenge spends the challenge with tokenHash. Of concurrent
+// calls, only one succeeds; the others yield
+// storage.ErrMFAChallengeNotFound.
+func (s *Storage) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+	const op = "memory.DeleteMFAChallenge"
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.mfaChallenges[tokenHash]; !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrMFAChallengeNotFound)
+	}
+
+	delete(s.mfaChallenges, tokenHash)
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+	"time"
+)
+
+// CreateUser stores a new user. An empty email is stored as none.
+func (s *Storage) CreateUser(ctx context.Context, username, password, email string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[username]; ok {
+		return 0, storage.ErrUserAlreadyExists
+	}
+
+	s.lastUserID++
+	s.users[username] = &models.User{ID: s.lastUserID, Username: username, Password: password, Email: email}
+
+	return s.lastUserID, nil
+}
+
+func (s *Storage) UserExists(ctx context.Context, username string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.users[username]
+	return ok, nil
+}
+
+func (s *Storage) User(ctx context.Context, username string) (*models.User, error) {
+	const op = "memory.User"
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[username]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	u := *user
+	return &u, nil
+}
+
+func (s *Storage) UserByID(ctx context.Context, id int64) (*models.User, error) {
+	const op = "memory.UserByID"
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user := s.userByID(id)
+	if user == nil {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	u := *user
+	return &u, nil
+}
+
+// SetPassword replaces the password hash of userID and signs the user out
+// everywhere by revoking every refresh token.
+func (s *Storage) SetPassword(ctx context.Context, userID int64, hash string) error {
+	const op = "memory.SetPassword"
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.setPassword(userID, hash) {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// setPassword replaces the password hash of userID, lifts any lockout and
+// revokes every refresh token of the user. It must be called with s.mu held
+// and reports whether the user exists.
+func (s *Storage) setPassword(userID int64, hash string) bool {
+	user := s.userByID(userID)
+	if user == nil {
+		return false
+	}
+
+	user.Password = hash
+	user.FailedLogins = 0
+	user.LockedUntil = time.Time{}
+
+	for _, token := range s.refreshTokens {
+		if token.userID == userID {
+			token.revoked = true
+		}
+	}
+
+	return true
+}
+
+// UpdatePassword replaces the password hash of userID.
+func (s *Storage) UpdatePassword(ctx context.Context, userID int64, hash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user := s.userByID(userID); user != nil {
+		user.Password = hash
+	}
+
+	return nil
+}
+
+// RecordFailedLogin counts a failed sign-in of userID and returns how many
+// there have been since the last successful one.
+func (s *Storage) RecordFailedLogin(ctx context.Context, userID int64) (int, error) {
+	const op = "memory.RecordFailedLogin"
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.userByID(userID)
+	if user == nil {
+		return 0, fmt.Errorf("%s: user not found", op)
+	}
+
+	user.FailedLogins++
+	return user.FailedLogins, nil
+}
+
+// LockUser refuses sign-ins of userID until until.
+func (s *Storage) LockUser(ctx context.Context, userID int64, until time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user := s.userByID(userID); user != nil {
+		user.LockedUntil = until.UTC().Truncate(time.Second)
+	}
+
+	return nil
+}
+
+// ResetFailedLogins forgets the failed sign-ins of userID and lifts its
+// lockout.
+func (s *Storage) ResetFailedLogins(ctx context.Context, userID int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user := s.userByID(userID); user != nil {
+		user.FailedLogins = 0
+		user.LockedUntil = time.Time{}
+	}
+
+	return nil
+}
+
+// userByID must be called with s.mu held.
+func (s *Storage) userByID(id int64) *models.User {
+	for _, user := range s.users {
+		if user.ID == id {
+			return user
+		}
+	}
+
+	return nil
+}
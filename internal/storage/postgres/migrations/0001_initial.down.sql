@@ -0,0 +1,8 @@
+DROP TABLE personal_access_tokens;
+DROP TABLE revoked_tokens;
+DROP TABLE refresh_tokens;
+DROP TABLE note_revisions;
+DROP TABLE note_tags;
+DROP TABLE tags;
+DROP TABLE notes;
+DROP TABLE users;
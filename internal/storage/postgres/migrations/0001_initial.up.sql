@@ -0,0 +1,93 @@
+-- PostgreSQL support started with the schema SQLite reached in its eighth
+-- migration, so that history is folded into this one.
+CREATE TABLE users (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    username TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL
+);
+
+CREATE TABLE notes (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    content TEXT,
+    created_at TIMESTAMPTZ(0) NOT NULL DEFAULT current_timestamp,
+    updated_at TIMESTAMPTZ(0) NOT NULL DEFAULT current_timestamp,
+    deleted_at TIMESTAMPTZ(0),
+    version INTEGER NOT NULL DEFAULT 1,
+    -- Title matches weigh more than content matches.
+    search TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', title), 'A') ||
+        setweight(to_tsvector('simple', coalesce(content, '')), 'B')
+    ) STORED
+);
+
+CREATE INDEX idx_notes_user_created ON notes(user_id, created_at, id);
+CREATE INDEX idx_notes_user_updated ON notes(user_id, updated_at, id);
+CREATE INDEX idx_notes_user_title ON notes(user_id, title, id);
+CREATE INDEX idx_notes_deleted_at ON notes(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_notes_search ON notes USING GIN (search);
+
+CREATE TABLE tags (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE note_tags (
+    note_id BIGINT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    tag_id BIGINT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (note_id, tag_id)
+);
+
+CREATE INDEX idx_note_tags_tag_id ON note_tags(tag_id);
+
+CREATE TABLE note_revisions (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    note_id BIGINT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT,
+    created_at TIMESTAMPTZ(0) NOT NULL,
+    UNIQUE (note_id, revision)
+);
+
+CREATE INDEX idx_note_revisions_created_at ON note_revisions(created_at);
+
+CREATE TABLE refresh_tokens (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ(0) NOT NULL DEFAULT current_timestamp,
+    expires_at TIMESTAMPTZ(0) NOT NULL,
+    used_at TIMESTAMPTZ(0),
+    revoked_at TIMESTAMPTZ(0)
+);
+
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
+
+-- Denylist of access tokens revoked before they expire.
+CREATE TABLE revoked_tokens (
+    jti TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ(0) NOT NULL
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+CREATE TABLE personal_access_tokens (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    scopes TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ(0) NOT NULL DEFAULT current_timestamp,
+    expires_at TIMESTAMPTZ(0) NOT NULL,
+    last_used_at TIMESTAMPTZ(0),
+    UNIQUE (user_id, name)
+);
+
+CREATE INDEX idx_personal_access_tokens_expires_at ON personal_access_tokens(expires_at);
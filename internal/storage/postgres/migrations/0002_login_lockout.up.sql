@@ -0,0 +1,3 @@
+-- Consecutive failed sign-ins, and until when they lock the account out.
+ALTER TABLE users ADD COLUMN failed_logins INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN locked_until TIMESTAMPTZ(0);
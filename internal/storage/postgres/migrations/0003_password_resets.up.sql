@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN email TEXT;
+
+CREATE TABLE password_reset_tokens (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ(0) NOT NULL DEFAULT current_timestamp,
+    expires_at TIMESTAMPTZ(0) NOT NULL,
+    used_at TIMESTAMPTZ(0)
+);
+
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);
+CREATE INDEX idx_password_reset_tokens_expires_at ON password_reset_tokens(expires_at);
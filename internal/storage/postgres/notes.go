@@ -0,0 +1,545 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+	"strings"
+	"time"
+)
+
+var sortColumns = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"title":      "title",
+	"deleted_at": "deleted_at",
+}
+
+func (s *Storage) CreateNote(ctx context.Context, userID int, title, content string, tags []string) (int64, error) {
+	const op = "postgres.CreateNote"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO notes (user_id, title, content)
+		VALUES ($1, $2, $3)
+		RETURNING id;
+	`, userID, title, content).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	if err := setNoteTags(ctx, tx, userID, id, tags); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) Note(ctx context.Context, id, userID int) (*models.Note, error) {
+	const op = "postgres.Note"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		SELECT id, user_id, title, content, created_at, updated_at, version
+		FROM notes
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var note models.Note
+	err = stmt.QueryRowContext(ctx, id, userID).Scan(&note.ID, &note.UserID, &note.Title, &note.Content,
+		timestamp{&note.CreatedAt}, timestamp{&note.UpdatedAt}, &note.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNoteNotFound
+		}
+
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	if err := s.attachTags(ctx, &note); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &note, nil
+}
+
+func (s *Storage) Notes(ctx context.Context, userID int, query models.NotesQuery) (*models.NotesPage, error) {
+	const op = "postgres.Notes"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	column, ok := sortColumns[query.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported sort key %q", op, query.SortBy)
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.Order == "desc" {
+		direction, comparison = "DESC", "<"
+	}
+
+	var p placeholders
+	where := []string{"user_id = " + p.add(userID), "deleted_at IS NULL"}
+	if query.Trashed {
+		where[1] = "deleted_at IS NOT NULL"
+	}
+
+	addRange := func(column string, after, before time.Time) {
+		if !after.IsZero() {
+			where = append(where, column+" >= "+p.add(after))
+		}
+
+		if !before.IsZero() {
+			where = append(where, column+" < "+p.add(before))
+		}
+	}
+	addRange("created_at", query.CreatedAfter, query.CreatedBefore)
+	addRange("updated_at", query.UpdatedAfter, query.UpdatedBefore)
+
+	if len(query.Tags) > 0 {
+		filter := `id IN (
+			SELECT nt.note_id
+			FROM note_tags nt
+			JOIN tags t ON t.id = nt.tag_id
+			WHERE t.user_id = ` + p.add(userID) + ` AND t.name = ANY(` + p.add(query.Tags) + `)
+			GROUP BY nt.note_id`
+
+		if query.TagMode == models.TagModeAll {
+			filter += " HAVING COUNT(*) = " + p.add(len(query.Tags))
+		}
+
+		where = append(where, filter+")")
+	}
+
+	var total int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM notes WHERE "+strings.Join(where, " AND ")+";", p.args...).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to count notes: %w", op, err)
+	}
+
+	if query.Cursor != "" {
+		c, err := storage.DecodeCursor(query.Cursor, query.SortBy, query.Order)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := cursorValue(c)
+		if err != nil {
+			return nil, err
+		}
+
+		v, id := p.add(value), p.add(c.ID)
+		where = append(where, fmt.Sprintf("(%[1]s %[2]s %[3]s OR (%[1]s = %[3]s AND id %[2]s %[4]s))", column, comparison, v, id))
+	}
+
+	// One extra row tells us whether another page exists.
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id, user_id, title, content, created_at, updated_at, deleted_at, version
+		FROM notes
+		WHERE %s
+		ORDER BY %s %s, id %s
+		LIMIT %s;
+	`, strings.Join(where, " AND "), column, direction, direction, p.add(query.Limit+1)), p.args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	notes := make([]models.Note, 0, query.Limit+1)
+	for rows.Next() {
+		var note models.Note
+		err := rows.Scan(&note.ID, &note.UserID, &note.Title, &note.Content,
+			timestamp{&note.CreatedAt}, timestamp{&note.UpdatedAt}, nullTimestamp{&note.DeletedAt}, &note.Version)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+
+		notes = append(notes, note)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	page := &models.NotesPage{Notes: notes, Total: total}
+
+	refs := make([]*models.Note, len(notes))
+	for i := range notes {
+		refs[i] = &notes[i]
+	}
+
+	if err := s.attachTags(ctx, refs...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(notes) > query.Limit {
+		page.Notes = notes[:query.Limit]
+		last := page.Notes[len(page.Notes)-1]
+
+		page.NextCursor, err = storage.EncodeCursor(storage.Cursor{
+			SortBy: query.SortBy,
+			Order:  query.Order,
+			Value:  sortValue(last, query.SortBy),
+			ID:     last.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to encode cursor: %w", op, err)
+		}
+	}
+
+	storage.ReturnedRows(ctx, len(page.Notes))
+
+	return page, nil
+}
+
+func sortValue(note models.Note, sortBy string) string {
+	switch sortBy {
+	case "updated_at":
+		return note.UpdatedAt
+	case "title":
+		return note.Title
+	case "deleted_at":
+		if note.DeletedAt != nil {
+			return *note.DeletedAt
+		}
+		return ""
+	default:
+		return note.CreatedAt
+	}
+}
+
+// cursorValue converts the sort value of a cursor back into something
+// comparable with its column. Cursors carry timestamps as text, which has to
+// become a time again to compare with a timestamptz column.
+func cursorValue(c storage.Cursor) (any, error) {
+	if c.SortBy == "title" {
+		return c.Value, nil
+	}
+
+	t, err := time.ParseInLocation(timestampLayout, c.Value, time.UTC)
+	if err != nil {
+		return nil, storage.ErrInvalidCursor
+	}
+
+	return t, nil
+}
+
+// DeleteNote moves a note to the trash. Trashed notes are hidden from every
+// read except the trash listing until they are restored or purged. A non-zero
+// version makes the delete conditional on the note still being at that
+// version.
+func (s *Storage) DeleteNote(ctx context.Context, id, userID, version int) error {
+	const op = "postgres.DeleteNote"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		UPDATE notes
+		SET deleted_at = current_timestamp
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND ($3 = 0 OR version = $3);
+	`)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, id, userID, version)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	storage.AffectedRows(ctx, rowsAffected)
+
+	if rowsAffected == 0 {
+		return writeConflict(ctx, s.db, id, userID, false)
+	}
+
+	return nil
+}
+
+func (s *Storage) RestoreNote(ctx context.Context, id, userID int) error {
+	const op = "postgres.RestoreNote"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		UPDATE notes
+		SET deleted_at = NULL
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL;
+	`)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	storage.AffectedRows(ctx, rowsAffected)
+
+	if rowsAffected == 0 {
+		return storage.ErrNoteNotFound
+	}
+
+	return nil
+}
+
+// PurgeNote permanently deletes a note, whether it is in the trash or not.
+// version works as in DeleteNote.
+func (s *Storage) PurgeNote(ctx context.Context, id, userID, version int) error {
+	const op = "postgres.PurgeNote"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		DELETE FROM notes
+		WHERE id = $1 AND user_id = $2 AND ($3 = 0 OR version = $3);
+	`, id, userID, version)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	storage.AffectedRows(ctx, rowsAffected)
+
+	if rowsAffected == 0 {
+		return writeConflict(ctx, tx, id, userID, true)
+	}
+
+	if err := deleteUnusedTags(ctx, tx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// PurgeTrash permanently deletes every note that was trashed before cutoff
+// and returns how many were removed.
+func (s *Storage) PurgeTrash(ctx context.Context, cutoff time.Time) (int64, error) {
+	const op = "postgres.PurgeTrash"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		DELETE FROM notes
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1;
+	`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM tags
+		WHERE NOT EXISTS (
+			SELECT 1
+			FROM note_tags
+			WHERE tag_id = tags.id);
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to delete unused tags: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	storage.AffectedRows(ctx, purged)
+
+	return purged, nil
+}
+
+// UpdateNote overwrites the title and content of a note and returns its new
+// version. A nil tags slice leaves the tags untouched; an empty one removes
+// them all. A non-zero version turns the update into a compare-and-swap that
+// fails with storage.ErrVersionMismatch when the note has moved on.
+func (s *Storage) UpdateNote(ctx context.Context, id, userID int, title, content string, tags []string, version int) (int, error) {
+	const op = "postgres.UpdateNote"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := recordRevision(ctx, tx, id, userID, &title, &content); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var newVersion int
+	err = tx.QueryRowContext(ctx, `
+		UPDATE notes
+		SET title = $1, content = $2, updated_at = current_timestamp, version = version + 1
+		WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL AND ($5 = 0 OR version = $5)
+		RETURNING version;
+	`, title, content, id, userID, version).Scan(&newVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, writeConflict(ctx, tx, id, userID, false)
+		}
+
+		return 0, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	if tags != nil {
+		if err := setNoteTags(ctx, tx, userID, int64(id), tags); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return newVersion, nil
+}
+
+// PatchNote updates only the fields set in patch and returns the note as it
+// is afterwards. Like UpdateNote, a non-zero version makes the write
+// conditional on the note still being at that version.
+func (s *Storage) PatchNote(ctx context.Context, id, userID int, patch models.NotePatch, version int) (*models.Note, error) {
+	const op = "postgres.PatchNote"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := recordRevision(ctx, tx, id, userID, patch.Title, patch.Content); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var p placeholders
+	sets := []string{"updated_at = current_timestamp", "version = version + 1"}
+
+	if patch.Title != nil {
+		sets = append(sets, "title = "+p.add(*patch.Title))
+	}
+
+	if patch.Content != nil {
+		sets = append(sets, "content = "+p.add(*patch.Content))
+	}
+
+	where := fmt.Sprintf("id = %s AND user_id = %s AND deleted_at IS NULL AND (%[3]s = 0 OR version = %[3]s)",
+		p.add(id), p.add(userID), p.add(version))
+
+	var newVersion int
+	err = tx.QueryRowContext(ctx, `
+		UPDATE notes
+		SET `+strings.Join(sets, ", ")+`
+		WHERE `+where+`
+		RETURNING version;
+	`, p.args...).Scan(&newVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, writeConflict(ctx, tx, id, userID, false)
+		}
+
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	if patch.Tags != nil {
+		if err := setNoteTags(ctx, tx, userID, int64(id), patch.Tags); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	note, err := s.Note(ctx, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return note, nil
+}
+
+// writeConflict explains why a conditional write matched no row: either the
+// note does not exist for this user or it is at another version.
+func writeConflict(ctx context.Context, q querier, id, userID int, includeTrashed bool) error {
+	var exists bool
+	err := q.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM notes
+			WHERE id = $1 AND user_id = $2 AND ($3 OR deleted_at IS NULL));
+	`, id, userID, includeTrashed).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check note: %w", err)
+	}
+
+	if !exists {
+		return storage.ErrNoteNotFound
+	}
+
+	return storage.ErrVersionMismatch
+}
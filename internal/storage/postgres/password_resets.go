@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"notes-api/internal/storage"
+	"time"
+)
+
+// CreatePasswordResetToken stores the hash of a token that lets userID set
+// a new password until expiresAt.
+func (s *Storage) CreatePasswordResetToken(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error {
+	const op = "postgres.CreatePasswordResetToken"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3);
+	`, userID, tokenHash, expiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// ResetPassword spends the reset token with tokenHash to replace the
+// password hash of its user, whom it returns. Like SetPassword, it revokes
+// every refresh token of the user; it also spends every other reset token
+// the user was sent. An unknown, spent or expired token yields
+// storage.ErrResetTokenNotFound.
+func (s *Storage) ResetPassword(ctx context.Context, tokenHash, hash string) (int64, error) {
+	const op = "postgres.ResetPassword"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var userID int64
+	err = tx.QueryRowContext(ctx, `
+		UPDATE password_reset_tokens
+		SET used_at = current_timestamp
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+		RETURNING user_id;
+	`, tokenHash, time.Now()).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, storage.ErrResetTokenNotFound
+		}
+
+		return 0, fmt.Errorf("%s: failed to spend token: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE password_reset_tokens
+		SET used_at = current_timestamp
+		WHERE user_id = $1 AND used_at IS NULL;
+	`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to spend other tokens: %w", op, err)
+	}
+
+	if err := setPassword(ctx, tx, userID, hash); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return userID, nil
+}
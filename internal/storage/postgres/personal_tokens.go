@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+	"strings"
+	"time"
+)
+
+// CreatePersonalAccessToken stores the hash of a new personal access token.
+// Scopes are stored space-separated, the way OAuth writes them.
+func (s *Storage) CreatePersonalAccessToken(ctx context.Context, userID int64, name string, scopes []string, tokenHash string, expiresAt time.Time) (*models.PersonalAccessToken, error) {
+	const op = "postgres.CreatePersonalAccessToken"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		INSERT INTO personal_access_tokens (user_id, name, scopes, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, expires_at;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	token := models.PersonalAccessToken{UserID: userID, Name: name, Scopes: scopes}
+	err = stmt.QueryRowContext(ctx, userID, name, strings.Join(scopes, " "), tokenHash, expiresAt).
+		Scan(&token.ID, timestamp{&token.CreatedAt}, timestamp{&token.ExpiresAt})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, storage.ErrTokenAlreadyExists
+		}
+
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return &token, nil
+}
+
+func (s *Storage) PersonalAccessTokens(ctx context.Context, userID int64) ([]models.PersonalAccessToken, error) {
+	const op = "postgres.PersonalAccessTokens"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		SELECT id, user_id, name, scopes, created_at, expires_at, last_used_at
+		FROM personal_access_tokens
+		WHERE user_id = $1 AND expires_at > current_timestamp
+		ORDER BY created_at DESC, id DESC;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	tokens := []models.PersonalAccessToken{}
+	for rows.Next() {
+		token, err := scanPersonalAccessToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+
+		tokens = append(tokens, *token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	storage.ReturnedRows(ctx, len(tokens))
+
+	return tokens, nil
+}
+
+func (s *Storage) DeletePersonalAccessToken(ctx context.Context, userID, id int64) error {
+	const op = "postgres.DeletePersonalAccessToken"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		DELETE FROM personal_access_tokens
+		WHERE id = $1 AND user_id = $2;
+	`)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	storage.AffectedRows(ctx, rowsAffected)
+
+	if rowsAffected == 0 {
+		return storage.ErrTokenNotFound
+	}
+
+	return nil
+}
+
+// AuthenticatePersonalAccessToken looks up an unexpired token by its hash and
+// records that it was just used.
+func (s *Storage) AuthenticatePersonalAccessToken(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	const op = "postgres.AuthenticatePersonalAccessToken"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		UPDATE personal_access_tokens
+		SET last_used_at = current_timestamp
+		WHERE token_hash = $1 AND expires_at > current_timestamp
+		RETURNING id, user_id, name, scopes, created_at, expires_at, last_used_at;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	token, err := scanPersonalAccessToken(stmt.QueryRowContext(ctx, tokenHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrTokenNotFound
+		}
+
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return token, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPersonalAccessToken(row scanner) (*models.PersonalAccessToken, error) {
+	var (
+		token  models.PersonalAccessToken
+		scopes string
+	)
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &scopes,
+		timestamp{&token.CreatedAt}, timestamp{&token.ExpiresAt}, nullTimestamp{&token.LastUsedAt})
+	if err != nil {
+		return nil, err
+	}
+
+	token.Scopes = strings.Fields(scopes)
+
+	return &token, nil
+}
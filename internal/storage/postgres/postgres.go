@@ -0,0 +1,238 @@
+// Package postgres stores users and notes in a PostgreSQL database, which
+// several replicas of the API can share.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"notes-api/internal/migrate"
+	"notes-api/internal/storage"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("notes-api/internal/storage/postgres")
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// timestampLayout is the format the API uses for timestamps, the one SQLite
+// uses for current_timestamp.
+const timestampLayout = "2006-01-02 15:04:05"
+
+// uniqueViolation is the SQLSTATE of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type Storage struct {
+	db *sql.DB
+	// queryTimeout bounds how long a single storage call may take. Zero
+	// leaves it to the context of the caller.
+	queryTimeout time.Duration
+	observer     storage.Observer
+}
+
+// New connects to the database at dsn and migrates it to the latest schema.
+func New(dsn string, queryTimeout time.Duration) (*Storage, error) {
+	const op = "postgres.New"
+
+	s, err := Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	s.queryTimeout = queryTimeout
+
+	migrator, err := s.Migrator()
+	if err != nil {
+		s.db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := migrator.Up(0); err != nil {
+		s.db.Close()
+		return nil, fmt.Errorf("%s: failed to migrate database: %w", op, err)
+	}
+
+	return s, nil
+}
+
+// Open connects to the database at dsn without changing its schema.
+func Open(dsn string) (*Storage, error) {
+	const op = "postgres.Open"
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to open database: %w", op, err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: failed to connect to database: %w", op, err)
+	}
+
+	return &Storage{db: db}, nil
+}
+
+// Close closes the connection pool.
+func (s *Storage) Close() error {
+	const op = "postgres.Close"
+
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("%s: failed to close database: %w", op, err)
+	}
+
+	return nil
+}
+
+// Ping checks that the database accepts connections.
+func (s *Storage) Ping(ctx context.Context) error {
+	const op = "postgres.Ping"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("%s: failed to ping database: %w", op, err)
+	}
+
+	return nil
+}
+
+// PendingMigrations returns how many of the embedded migrations have not
+// been applied to the database, or were changed after they were.
+func (s *Storage) PendingMigrations() (int, error) {
+	const op = "postgres.PendingMigrations"
+
+	migrator, err := s.Migrator()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		if !status.Applied || status.Modified {
+			pending++
+		}
+	}
+
+	return pending, nil
+}
+
+// SetObserver makes the storage report the duration of every operation to
+// observer. It must be called before the storage is used.
+func (s *Storage) SetObserver(observer storage.Observer) {
+	s.observer = observer
+}
+
+// DB returns the connection pool, for instrumentation.
+func (s *Storage) DB() *sql.DB {
+	return s.db
+}
+
+// queryContext derives the context a storage call runs under from the one
+// of its caller and starts a span for op in it. Cancelling it ends the span
+// and reports the duration of op to the observer, if there is one.
+func (s *Storage) queryContext(ctx context.Context, op string) (context.Context, context.CancelFunc) {
+	ctx, span := tracer.Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL, semconv.DBOperationName(op)),
+	)
+
+	var cancel context.CancelFunc
+	if s.queryTimeout <= 0 {
+		ctx, cancel = context.WithCancel(ctx)
+	} else {
+		ctx, cancel = context.WithTimeout(ctx, s.queryTimeout)
+	}
+
+	start := time.Now()
+	return ctx, func() {
+		cancel()
+		span.End()
+
+		if s.observer != nil {
+			s.observer.ObserveOp("postgres", storage.OpName(op), time.Since(start))
+		}
+	}
+}
+
+// Migrator returns a migrator for the schema migrations embedded in the
+// binary.
+func (s *Storage) Migrator() (*migrate.Migrator, error) {
+	files, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migrations: %w", err)
+	}
+
+	return migrate.New(s.db, files, migrate.DollarPlaceholders())
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+// timestamp scans a timestamptz column into a string in timestampLayout, the
+// form the models carry timestamps in.
+type timestamp struct {
+	dst *string
+}
+
+func (t timestamp) Scan(src any) error {
+	v, ok := src.(time.Time)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into a timestamp", src)
+	}
+
+	*t.dst = v.UTC().Format(timestampLayout)
+	return nil
+}
+
+// nullTimestamp is timestamp for nullable columns.
+type nullTimestamp struct {
+	dst **string
+}
+
+func (t nullTimestamp) Scan(src any) error {
+	if src == nil {
+		*t.dst = nil
+		return nil
+	}
+
+	var s string
+	if err := (timestamp{&s}).Scan(src); err != nil {
+		return err
+	}
+
+	*t.dst = &s
+	return nil
+}
+
+// placeholders collects query arguments and hands out their $n placeholders,
+// for queries assembled from optional parts.
+type placeholders struct {
+	args []any
+}
+
+func (p *placeholders) add(v any) string {
+	p.args = append(p.args, v)
+	return fmt.Sprintf("$%d", len(p.args))
+}
@@ -0,0 +1,40 @@
+package postgres_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"notes-api/internal/app"
+	"notes-api/internal/storage/postgres"
+	"notes-api/internal/storage/storagetest"
+)
+
+// TestConformance needs a database it may wipe, given by
+// NOTES_TEST_POSTGRES_DSN.
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv("NOTES_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("NOTES_TEST_POSTGRES_DSN is not set")
+	}
+
+	storagetest.Run(t, func(t *testing.T) app.Storage {
+		db, err := sql.Open("pgx", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		if _, err := db.Exec("DROP SCHEMA public CASCADE; CREATE SCHEMA public;"); err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := postgres.New(dsn, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+
+		return s
+	})
+}
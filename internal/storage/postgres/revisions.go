@@ -0,0 +1,236 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+	"time"
+)
+
+func (s *Storage) NoteRevisions(ctx context.Context, noteID, userID int) ([]models.Revision, error) {
+	const op = "postgres.NoteRevisions"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	if err := checkNoteOwner(ctx, s.db, noteID, userID); err != nil {
+		return nil, err
+	}
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		SELECT revision, title, content, created_at
+		FROM note_revisions
+		WHERE note_id = $1
+		ORDER BY revision DESC;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	revisions := []models.Revision{}
+	for rows.Next() {
+		revision := models.Revision{NoteID: noteID}
+		if err := rows.Scan(&revision.Revision, &revision.Title, &revision.Content, timestamp{&revision.CreatedAt}); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	storage.ReturnedRows(ctx, len(revisions))
+
+	return revisions, nil
+}
+
+func (s *Storage) NoteRevision(ctx context.Context, noteID, userID, revision int) (*models.Revision, error) {
+	const op = "postgres.NoteRevision"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	if err := checkNoteOwner(ctx, s.db, noteID, userID); err != nil {
+		return nil, err
+	}
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		SELECT revision, title, content, created_at
+		FROM note_revisions
+		WHERE note_id = $1 AND revision = $2;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	result := models.Revision{NoteID: noteID}
+	err = stmt.QueryRowContext(ctx, noteID, revision).Scan(&result.Revision, &result.Title, &result.Content, timestamp{&result.CreatedAt})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrRevisionNotFound
+		}
+
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return &result, nil
+}
+
+// RestoreRevision makes an old revision the current version of a note. The
+// version it replaces is kept as a new revision, so a restore can be undone.
+func (s *Storage) RestoreRevision(ctx context.Context, noteID, userID, revision int) error {
+	const op = "postgres.RestoreRevision"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var title, content string
+	err = tx.QueryRowContext(ctx, `
+		SELECT r.title, r.content
+		FROM note_revisions r
+		JOIN notes n ON n.id = r.note_id
+		WHERE r.note_id = $1 AND r.revision = $2 AND n.user_id = $3 AND n.deleted_at IS NULL;
+	`, noteID, revision, userID).Scan(&title, &content)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if err := checkNoteOwner(ctx, tx, noteID, userID); err != nil {
+				return err
+			}
+
+			return storage.ErrRevisionNotFound
+		}
+
+		return fmt.Errorf("%s: failed to find revision: %w", op, err)
+	}
+
+	if err := recordRevision(ctx, tx, noteID, userID, &title, &content); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE notes
+		SET title = $1, content = $2, updated_at = current_timestamp, version = version + 1
+		WHERE id = $3 AND user_id = $4;
+	`, title, content, noteID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to update note: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// PruneRevisions deletes revisions beyond the newest maxPerNote of each note
+// and revisions created before cutoff. A zero maxPerNote or cutoff disables
+// the respective rule.
+func (s *Storage) PruneRevisions(ctx context.Context, maxPerNote int, cutoff time.Time) (int64, error) {
+	const op = "postgres.PruneRevisions"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	var pruned int64
+
+	if maxPerNote > 0 {
+		res, err := s.db.ExecContext(ctx, `
+			DELETE FROM note_revisions
+			WHERE id IN (
+				SELECT id
+				FROM (
+					SELECT id, ROW_NUMBER() OVER (PARTITION BY note_id ORDER BY revision DESC) AS position
+					FROM note_revisions) ranked
+				WHERE position > $1);
+		`, maxPerNote)
+		if err != nil {
+			return 0, fmt.Errorf("%s: failed to prune by count: %w", op, err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+		}
+		pruned += n
+	}
+
+	if !cutoff.IsZero() {
+		res, err := s.db.ExecContext(ctx, `
+			DELETE FROM note_revisions
+			WHERE created_at < $1;
+		`, cutoff)
+		if err != nil {
+			return 0, fmt.Errorf("%s: failed to prune by age: %w", op, err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+		}
+		pruned += n
+	}
+
+	storage.AffectedRows(ctx, pruned)
+
+	return pruned, nil
+}
+
+// recordRevision saves the current title and content of a note as its next
+// revision, unless they are identical to the new title and content.
+func recordRevision(ctx context.Context, tx *sql.Tx, noteID, userID int, title, content *string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO note_revisions (note_id, revision, title, content, created_at)
+		SELECT id,
+			COALESCE((SELECT MAX(revision) FROM note_revisions WHERE note_id = notes.id), 0) + 1,
+			title, content, updated_at
+		FROM notes
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+			AND (title <> COALESCE($3, title) OR content IS DISTINCT FROM COALESCE($4, content));
+	`, noteID, userID, title, content)
+	if err != nil {
+		return fmt.Errorf("failed to record revision: %w", err)
+	}
+
+	return nil
+}
+
+// checkNoteOwner returns storage.ErrNoteNotFound unless userID owns a live
+// note with the given id.
+func checkNoteOwner(ctx context.Context, q querier, noteID, userID int) error {
+	var exists bool
+	err := q.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM notes
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL);
+	`, noteID, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check note owner: %w", err)
+	}
+
+	if !exists {
+		return storage.ErrNoteNotFound
+	}
+
+	return nil
+}
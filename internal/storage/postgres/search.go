@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+)
+
+// ts_headline options that mark matches the way the SQLite backend does: the
+// whole title is highlighted and the content is cut down to a snippet.
+const (
+	titleHighlightOptions = "StartSel=<mark>, StopSel=</mark>, HighlightAll=true"
+	snippetOptions        = "StartSel=<mark>, StopSel=</mark>, MaxWords=24, MinWords=12"
+)
+
+// SearchNotes matches notes against the search column kept up to date by the
+// database. Queries use web search syntax, so unlike FTS5 expressions they
+// are never rejected as invalid. Rank is negated so that, as with SQLite,
+// better matches have lower ranks.
+func (s *Storage) SearchNotes(ctx context.Context, userID int, query models.SearchQuery) ([]models.SearchResult, error) {
+	const op = "postgres.SearchNotes"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	// Title matches weigh more than content matches.
+	stmt, err := s.db.PrepareContext(ctx, `
+		SELECT n.id, n.user_id, n.title, n.content, n.created_at, n.updated_at, n.version,
+			-ts_rank_cd('{0.1, 0.1, 0.1, 1.0}', n.search, q) AS rank,
+			ts_headline('simple', n.title, q, $1),
+			ts_headline('simple', coalesce(n.content, ''), q, $2)
+		FROM notes n, websearch_to_tsquery('simple', $3) q
+		WHERE n.search @@ q AND n.user_id = $4 AND n.deleted_at IS NULL
+		ORDER BY rank, n.id
+		LIMIT $5 OFFSET $6;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, titleHighlightOptions, snippetOptions, query.Query, userID, query.Limit, query.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	results := make([]models.SearchResult, 0, query.Limit)
+	for rows.Next() {
+		var result models.SearchResult
+		err := rows.Scan(
+			&result.ID, &result.UserID, &result.Title, &result.Content,
+			timestamp{&result.CreatedAt}, timestamp{&result.UpdatedAt}, &result.Version,
+			&result.Rank, &result.TitleHighlight, &result.Snippet,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	notes := make([]*models.Note, len(results))
+	for i := range results {
+		notes[i] = &results[i].Note
+	}
+
+	if err := s.attachTags(ctx, notes...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	storage.ReturnedRows(ctx, len(results))
+
+	return results, nil
+}
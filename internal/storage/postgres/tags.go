@@ -0,0 +1,249 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+)
+
+func (s *Storage) Tags(ctx context.Context, userID int) ([]models.Tag, error) {
+	const op = "postgres.Tags"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		SELECT t.name, COUNT(nt.note_id)
+		FROM tags t
+		JOIN note_tags nt ON nt.tag_id = t.id
+		JOIN notes n ON n.id = nt.note_id AND n.deleted_at IS NULL
+		WHERE t.user_id = $1
+		GROUP BY t.id
+		ORDER BY t.name;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	storage.ReturnedRows(ctx, len(tags))
+
+	return tags, nil
+}
+
+func (s *Storage) RenameTag(ctx context.Context, userID int, name, newName string) error {
+	const op = "postgres.RenameTag"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE tags
+		SET name = $1
+		WHERE user_id = $2 AND name = $3;
+	`, newName, userID, name)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrTagAlreadyExists
+		}
+
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrTagNotFound
+	}
+
+	return nil
+}
+
+// MergeTags moves every note tagged with one of sources onto target and
+// removes the source tags. target is created when it does not exist yet.
+func (s *Storage) MergeTags(ctx context.Context, userID int, sources []string, target string) error {
+	const op = "postgres.MergeTags"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	targetID, err := ensureTag(ctx, tx, userID, target)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, source := range sources {
+		if source == target {
+			continue
+		}
+
+		var sourceID int64
+		err := tx.QueryRowContext(ctx, "SELECT id FROM tags WHERE user_id = $1 AND name = $2;", userID, source).Scan(&sourceID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return storage.ErrTagNotFound
+			}
+
+			return fmt.Errorf("%s: failed to find tag: %w", op, err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO note_tags (note_id, tag_id)
+			SELECT note_id, $1
+			FROM note_tags
+			WHERE tag_id = $2
+			ON CONFLICT DO NOTHING;
+		`, targetID, sourceID)
+		if err != nil {
+			return fmt.Errorf("%s: failed to move notes: %w", op, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM tags WHERE id = $1;", sourceID); err != nil {
+			return fmt.Errorf("%s: failed to delete tag: %w", op, err)
+		}
+	}
+
+	if err := deleteUnusedTags(ctx, tx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// setNoteTags replaces the tags of a note with tags, creating missing tags
+// and dropping ones no note uses anymore.
+func setNoteTags(ctx context.Context, tx *sql.Tx, userID int, noteID int64, tags []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM note_tags WHERE note_id = $1;", noteID); err != nil {
+		return fmt.Errorf("failed to clear note tags: %w", err)
+	}
+
+	for _, tag := range tags {
+		tagID, err := ensureTag(ctx, tx, userID, tag)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO note_tags (note_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;", noteID, tagID)
+		if err != nil {
+			return fmt.Errorf("failed to tag note: %w", err)
+		}
+	}
+
+	return deleteUnusedTags(ctx, tx, userID)
+}
+
+func ensureTag(ctx context.Context, tx *sql.Tx, userID int, name string) (int64, error) {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO tags (user_id, name)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, name) DO NOTHING;
+	`, userID, name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	var id int64
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM tags WHERE user_id = $1 AND name = $2;", userID, name).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to find tag: %w", err)
+	}
+
+	return id, nil
+}
+
+func deleteUnusedTags(ctx context.Context, tx *sql.Tx, userID int) error {
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM tags
+		WHERE user_id = $1 AND NOT EXISTS (
+			SELECT 1
+			FROM note_tags
+			WHERE tag_id = tags.id);
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete unused tags: %w", err)
+	}
+
+	return nil
+}
+
+// attachTags loads the tags of every note in notes in a single query.
+func (s *Storage) attachTags(ctx context.Context, notes ...*models.Note) error {
+	if len(notes) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(notes))
+	byID := make(map[int]*models.Note, len(notes))
+	for i, note := range notes {
+		note.Tags = []string{}
+		ids[i] = int64(note.ID)
+		byID[note.ID] = note
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT nt.note_id, t.name
+		FROM note_tags nt
+		JOIN tags t ON t.id = nt.tag_id
+		WHERE nt.note_id = ANY($1)
+		ORDER BY t.name;
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to query note tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			noteID int
+			name   string
+		)
+		if err := rows.Scan(&noteID, &name); err != nil {
+			return fmt.Errorf("failed to scan note tag: %w", err)
+		}
+
+		if note, ok := byID[noteID]; ok {
+			note.Tags = append(note.Tags, name)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate note tags: %w", err)
+	}
+
+	return nil
+}
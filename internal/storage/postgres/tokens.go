@@ -0,0 +1,280 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"notes-api/internal/storage"
+	"time"
+)
+
+// CreateRefreshToken stores the hash of a refresh token issued to userID.
+// Tokens obtained by rotating one another share a family.
+func (s *Storage) CreateRefreshToken(ctx context.Context, userID int64, familyID, tokenHash string, expiresAt time.Time) error {
+	const op = "postgres.CreateRefreshToken"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4);
+	`)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, userID, familyID, tokenHash, expiresAt); err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// RotateRefreshToken spends the refresh token with tokenHash and stores
+// newHash in its place, returning the user the tokens belong to. Presenting a
+// token that was already spent revokes its whole family and returns
+// storage.ErrRefreshTokenReused, since either the client or an attacker holds a
+// stolen copy.
+func (s *Storage) RotateRefreshToken(ctx context.Context, tokenHash, newHash string, expiresAt time.Time) (int64, error) {
+	const op = "postgres.RotateRefreshToken"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var (
+		userID   int64
+		familyID string
+	)
+	err = tx.QueryRowContext(ctx, `
+		UPDATE refresh_tokens
+		SET used_at = current_timestamp
+		WHERE token_hash = $1 AND used_at IS NULL AND revoked_at IS NULL AND expires_at > current_timestamp
+		RETURNING user_id, family_id;
+	`, tokenHash).Scan(&userID, &familyID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s: failed to spend token: %w", op, err)
+		}
+
+		var used bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT family_id, used_at IS NOT NULL
+			FROM refresh_tokens
+			WHERE token_hash = $1;
+		`, tokenHash).Scan(&familyID, &used)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return 0, storage.ErrRefreshTokenNotFound
+			}
+
+			return 0, fmt.Errorf("%s: failed to find token: %w", op, err)
+		}
+
+		if !used {
+			return 0, storage.ErrRefreshTokenNotFound
+		}
+
+		if err := revokeTokenFamily(ctx, tx, familyID); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+		}
+
+		return 0, storage.ErrRefreshTokenReused
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4);
+	`, userID, familyID, newHash, expiresAt)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to store token: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// RevokeRefreshToken revokes the family of a refresh token of userID, ending
+// the session it belongs to. Unknown tokens are ignored.
+func (s *Storage) RevokeRefreshToken(ctx context.Context, userID int64, tokenHash string) error {
+	const op = "postgres.RevokeRefreshToken"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var familyID string
+	err = tx.QueryRowContext(ctx, `
+		SELECT family_id
+		FROM refresh_tokens
+		WHERE token_hash = $1 AND user_id = $2;
+	`, tokenHash, userID).Scan(&familyID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+
+		return fmt.Errorf("%s: failed to find token: %w", op, err)
+	}
+
+	if err := revokeTokenFamily(ctx, tx, familyID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeUserRefreshTokens revokes every refresh token of userID.
+func (s *Storage) RevokeUserRefreshTokens(ctx context.Context, userID int64) error {
+	const op = "postgres.RevokeUserRefreshTokens"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = current_timestamp
+		WHERE user_id = $1 AND revoked_at IS NULL;
+	`)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, userID); err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeAccessToken adds the jti of an access token to the denylist until
+// the token expires anyway.
+func (s *Storage) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	const op = "postgres.RevokeAccessToken"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING;
+	`)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, jti, expiresAt); err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) AccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	const op = "postgres.AccessTokenRevoked"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM revoked_tokens
+			WHERE jti = $1);
+	`)
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var revoked bool
+	if err := stmt.QueryRowContext(ctx, jti).Scan(&revoked); err != nil {
+		return false, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return revoked, nil
+}
+
+// PurgeExpiredTokens deletes refresh tokens, denylist entries, personal
+// access tokens, password reset tokens and sign-in challenges that expired
+// before now and so no longer need to be remembered.
+func (s *Storage) PurgeExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	const op = "postgres.PurgeExpiredTokens"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var purged int64
+	for _, query := range []string{
+		"DELETE FROM refresh_tokens WHERE expires_at <= $1;",
+		"DELETE FROM revoked_tokens WHERE expires_at <= $1;",
+		"DELETE FROM personal_access_tokens WHERE expires_at <= $1;",
+		"DELETE FROM password_reset_tokens WHERE expires_at <= $1;",
+		"DELETE FROM mfa_challenges WHERE expires_at <= $1;",
+	} {
+		res, err := tx.ExecContext(ctx, query, now)
+		if err != nil {
+			return 0, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+		}
+		purged += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	storage.AffectedRows(ctx, purged)
+
+	return purged, nil
+}
+
+func revokeTokenFamily(ctx context.Context, tx *sql.Tx, familyID string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = current_timestamp
+		WHERE family_id = $1 AND revoked_at IS NULL;
+	`, familyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
+
+	return nil
+}
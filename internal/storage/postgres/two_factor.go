@@ -0,0 +1,260 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+	"time"
+)
+
+// SetTOTPSecret begins two-factor setup for userID with secret, replacing
+// the secret of any setup the user did not confirm. It does not enable two
+// factor sign-ins; EnableTOTP does.
+func (s *Storage) SetTOTPSecret(ctx context.Context, userID int64, secret string) error {
+	const op = "postgres.SetTOTPSecret"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE users
+		SET totp_secret = $1, totp_enabled = false, totp_last_step = 0
+		WHERE id = $2;
+	`, secret, userID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	} else if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// EnableTOTP turns on two-factor sign-ins for userID and replaces the
+// recovery codes of the user with the ones with codeHashes.
+func (s *Storage) EnableTOTP(ctx context.Context, userID int64, codeHashes []string) error {
+	const op = "postgres.EnableTOTP"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE users SET totp_enabled = true WHERE id = $1 AND totp_secret IS NOT NULL;", userID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to enable two-factor: %w", op, err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	} else if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = $1;", userID); err != nil {
+		return fmt.Errorf("%s: failed to delete recovery codes: %w", op, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2);")
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for _, hash := range codeHashes {
+		if _, err := stmt.ExecContext(ctx, userID, hash); err != nil {
+			return fmt.Errorf("%s: failed to store recovery code: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// DisableTOTP turns off two-factor sign-ins for userID and forgets its
+// secret, recovery codes and pending challenges.
+func (s *Storage) DisableTOTP(ctx context.Context, userID int64) error {
+	const op = "postgres.DisableTOTP"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"UPDATE users SET totp_secret = NULL, totp_enabled = false, totp_last_step = 0 WHERE id = $1;",
+		"DELETE FROM recovery_codes WHERE user_id = $1;",
+		"DELETE FROM mfa_challenges WHERE user_id = $1;",
+	} {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep records that userID gave the code of time step step. It
+// reports false if a code of that step or a later one was given before, in
+// which case the code must be refused as a replay.
+func (s *Storage) UseTOTPStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	const op = "postgres.UseTOTPStep"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, "UPDATE users SET totp_last_step = $1 WHERE id = $2 AND totp_last_step < $1;", step, userID)
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	return n > 0, nil
+}
+
+// UseRecoveryCode spends the recovery code of userID with codeHash. An
+// unknown or spent code yields storage.ErrRecoveryCodeNotFound.
+func (s *Storage) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	const op = "postgres.UseRecoveryCode"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE recovery_codes
+		SET used_at = current_timestamp
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+	`, userID, codeHash)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	} else if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrRecoveryCodeNotFound)
+	}
+
+	return nil
+}
+
+// CreateMFAChallenge stores the hash of a token that lets userID finish
+// signing in with a second factor until expiresAt.
+func (s *Storage) CreateMFAChallenge(ctx context.Context, userID int64, tokenHash string, expiresAt time.Time) error {
+	const op = "postgres.CreateMFAChallenge"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO mfa_challenges (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3);
+	`, userID, tokenHash, expiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// MFAChallenge returns the challenge with tokenHash. An unknown or expired
+// one yields storage.ErrMFAChallengeNotFound.
+func (s *Storage) MFAChallenge(ctx context.Context, tokenHash string) (*models.MFAChallenge, error) {
+	const op = "postgres.MFAChallenge"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	var challenge models.MFAChallenge
+	err := s.db.QueryRowContext(ctx, `
+		SELECT user_id, attempts, expires_at
+		FROM mfa_challenges
+		WHERE token_hash = $1 AND expires_at > $2;
+	`, tokenHash, time.Now()).Scan(&challenge.UserID, &challenge.Attempts, &challenge.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrMFAChallengeNotFound)
+		}
+
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	challenge.ExpiresAt = challenge.ExpiresAt.UTC()
+
+	return &challenge, nil
+}
+
+// RecordMFAChallengeFailure counts a wrong code given for the challenge
+// with tokenHash and returns how many there have been.
+func (s *Storage) RecordMFAChallengeFailure(ctx context.Context, tokenHash string) (int, error) {
+	const op = "postgres.RecordMFAChallengeFailure"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	var attempts int
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE mfa_challenges
+		SET attempts = attempts + 1
+		WHERE token_hash = $1
+		RETURNING attempts;
+	`, tokenHash).Scan(&attempts)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrMFAChallengeNotFound)
+		}
+
+		return 0, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return attempts, nil
+}
+
+// DeleteMFAChallenge spends the challenge with tokenHash. Of concurrent
+// calls, only one succeeds; the others yield
+// storage.ErrMFAChallengeNotFound.
+func (s *Storage) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+	const op = "postgres.DeleteMFAChallenge"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM mfa_challenges WHERE token_hash = $1;", tokenHash)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	} else if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrMFAChallengeNotFound)
+	}
+
+	return nil
+}
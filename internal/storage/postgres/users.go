@@ -0,0 +1,247 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+	"time"
+)
+
+// CreateUser stores a new user. An empty email is stored as none.
+func (s *Storage) CreateUser(ctx context.Context, username, password, email string) (int64, error) {
+	const op = "postgres.CreateUser"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		INSERT INTO users (username, password, email)
+		VALUES ($1, $2, NULLIF($3, ''))
+		RETURNING id;
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var id int64
+	if err := stmt.QueryRowContext(ctx, username, password, email).Scan(&id); err != nil {
+		if isUniqueViolation(err) {
+			return 0, storage.ErrUserAlreadyExists
+		}
+
+		return 0, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) UserExists(ctx context.Context, username string) (bool, error) {
+	const op = "postgres.UserExists"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM users
+			WHERE username = $1);
+	`)
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var exists bool
+	err = stmt.QueryRowContext(ctx, username).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return exists, nil
+}
+
+func (s *Storage) User(ctx context.Context, username string) (*models.User, error) {
+	const op = "postgres.User"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	user, err := scanUser(s.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE username = $1;", username))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return user, nil
+}
+
+func (s *Storage) UserByID(ctx context.Context, id int64) (*models.User, error) {
+	const op = "postgres.UserByID"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	user, err := scanUser(s.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1;", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return user, nil
+}
+
+const userColumns = "id, username, password, email, failed_logins, locked_until, totp_secret, totp_enabled"
+
+func scanUser(row scanner) (*models.User, error) {
+	var (
+		user        models.User
+		email       sql.NullString
+		totpSecret  sql.NullString
+		lockedUntil sql.NullTime
+	)
+	err := row.Scan(&user.ID, &user.Username, &user.Password, &email, &user.FailedLogins, &lockedUntil, &totpSecret, &user.TOTPEnabled)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Email = email.String
+	user.TOTPSecret = totpSecret.String
+
+	if lockedUntil.Valid {
+		user.LockedUntil = lockedUntil.Time.UTC()
+	}
+
+	return &user, nil
+}
+
+// SetPassword replaces the password hash of userID and signs the user out
+// everywhere by revoking every refresh token.
+func (s *Storage) SetPassword(ctx context.Context, userID int64, hash string) error {
+	const op = "postgres.SetPassword"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := setPassword(ctx, tx, userID, hash); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// setPassword replaces the password hash of userID, lifts any lockout and
+// revokes every refresh token of the user.
+func setPassword(ctx context.Context, tx *sql.Tx, userID int64, hash string) error {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET password = $1, failed_logins = 0, locked_until = NULL
+		WHERE id = $2;
+	`, hash, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if n == 0 {
+		return storage.ErrUserNotFound
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = current_timestamp
+		WHERE user_id = $1 AND revoked_at IS NULL;
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePassword replaces the password hash of userID.
+func (s *Storage) UpdatePassword(ctx context.Context, userID int64, hash string) error {
+	const op = "postgres.UpdatePassword"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE users SET password = $1 WHERE id = $2;", hash, userID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// RecordFailedLogin counts a failed sign-in of userID and returns how many
+// there have been since the last successful one.
+func (s *Storage) RecordFailedLogin(ctx context.Context, userID int64) (int, error) {
+	const op = "postgres.RecordFailedLogin"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	var failures int
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE users
+		SET failed_logins = failed_logins + 1
+		WHERE id = $1
+		RETURNING failed_logins;
+	`, userID).Scan(&failures)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return failures, nil
+}
+
+// LockUser refuses sign-ins of userID until until.
+func (s *Storage) LockUser(ctx context.Context, userID int64, until time.Time) error {
+	const op = "postgres.LockUser"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE users SET locked_until = $1 WHERE id = $2;", until, userID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// ResetFailedLogins forgets the failed sign-ins of userID and lifts its
+// lockout.
+func (s *Storage) ResetFailedLogins(ctx context.Context, userID int64) error {
+	const op = "postgres.ResetFailedLogins"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE users SET failed_logins = 0, locked_until = NULL WHERE id = $1;", userID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,5 @@
+DROP INDEX idx_notes_user_title;
+DROP INDEX idx_notes_user_updated;
+DROP INDEX idx_notes_user_created;
+
+CREATE INDEX idx_notes_user_id ON notes(user_id);
@@ -0,0 +1,7 @@
+-- Keyset pagination walks (user_id, sort column, id), which also covers
+-- lookups by user_id alone.
+DROP INDEX IF EXISTS idx_notes_user_id;
+
+CREATE INDEX idx_notes_user_created ON notes(user_id, created_at, id);
+CREATE INDEX idx_notes_user_updated ON notes(user_id, updated_at, id);
+CREATE INDEX idx_notes_user_title ON notes(user_id, title, id);
@@ -0,0 +1,4 @@
+DROP TRIGGER notes_fts_update;
+DROP TRIGGER notes_fts_delete;
+DROP TRIGGER notes_fts_insert;
+DROP TABLE notes_fts;
@@ -0,0 +1,4 @@
+-- Notes in the trash become regular notes again rather than being lost.
+DROP INDEX idx_notes_deleted_at;
+
+ALTER TABLE notes DROP COLUMN deleted_at;
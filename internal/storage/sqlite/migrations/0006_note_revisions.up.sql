@@ -0,0 +1,12 @@
+CREATE TABLE note_revisions (
+    id INTEGER PRIMARY KEY,
+    note_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT,
+    created_at TEXT NOT NULL,
+    UNIQUE (note_id, revision),
+    FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_note_revisions_created_at ON note_revisions(created_at);
@@ -0,0 +1,14 @@
+CREATE TABLE personal_access_tokens (
+    id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    scopes TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TEXT NOT NULL DEFAULT current_timestamp,
+    expires_at TEXT NOT NULL,
+    last_used_at TEXT,
+    UNIQUE (user_id, name),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_personal_access_tokens_expires_at ON personal_access_tokens(expires_at);
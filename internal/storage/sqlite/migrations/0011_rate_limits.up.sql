@@ -0,0 +1,9 @@
+-- Token buckets of the rate limiter, shared by every process using the
+-- database. full_at is when a bucket is full again, in Unix nanoseconds;
+-- rows past it are equivalent to none.
+CREATE TABLE rate_limits (
+    key TEXT PRIMARY KEY,
+    full_at INTEGER NOT NULL
+);
+
+CREATE INDEX idx_rate_limits_full_at ON rate_limits(full_at);
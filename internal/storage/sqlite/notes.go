@@ -0,0 +1,569 @@
+//go:build sqlite_fts5
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+	"strings"
+	"time"
+)
+
+// timestampLayout matches the format SQLite uses for current_timestamp.
+const timestampLayout = "2006-01-02 15:04:05"
+
+var sortColumns = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"title":      "title",
+	"deleted_at": "deleted_at",
+}
+
+func (s *Storage) CreateNote(ctx context.Context, userID int, title, content string, tags []string) (int64, error) {
+	const op = "sqlite.CreateNote"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO notes (user_id, title, content)
+		VALUES (?, ?, ?);
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, userID, title, content)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get last insert id: %w", op, err)
+	}
+
+	if err := setNoteTags(ctx, tx, userID, id, tags); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) Note(ctx context.Context, id, userID int) (*models.Note, error) {
+	const op = "sqlite.Note"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		SELECT id, user_id, title, content, created_at, updated_at, version
+		FROM notes
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	row, err := stmt.QueryContext(ctx, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+	defer row.Close()
+
+	var note models.Note
+	if !row.Next() {
+		if err := row.Err(); err != nil {
+			return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+		}
+
+		return nil, storage.ErrNoteNotFound
+	}
+
+	if err := row.Scan(&note.ID, &note.UserID, &note.Title, &note.Content, &note.CreatedAt, &note.UpdatedAt, &note.Version); err != nil {
+		return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+	}
+
+	if err := s.attachTags(ctx, &note); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &note, nil
+}
+
+func (s *Storage) Notes(ctx context.Context, userID int, query models.NotesQuery) (*models.NotesPage, error) {
+	const op = "sqlite.Notes"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	column, ok := sortColumns[query.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported sort key %q", op, query.SortBy)
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.Order == "desc" {
+		direction, comparison = "DESC", "<"
+	}
+
+	where := []string{"user_id = ?", "deleted_at IS NULL"}
+	if query.Trashed {
+		where[1] = "deleted_at IS NOT NULL"
+	}
+	args := []any{userID}
+
+	addRange := func(column string, after, before time.Time) {
+		if !after.IsZero() {
+			where = append(where, column+" >= ?")
+			args = append(args, after.UTC().Format(timestampLayout))
+		}
+
+		if !before.IsZero() {
+			where = append(where, column+" < ?")
+			args = append(args, before.UTC().Format(timestampLayout))
+		}
+	}
+	addRange("created_at", query.CreatedAfter, query.CreatedBefore)
+	addRange("updated_at", query.UpdatedAfter, query.UpdatedBefore)
+
+	if len(query.Tags) > 0 {
+		filter := `id IN (
+			SELECT nt.note_id
+			FROM note_tags nt
+			JOIN tags t ON t.id = nt.tag_id
+			WHERE t.user_id = ? AND t.name IN (?` + strings.Repeat(", ?", len(query.Tags)-1) + `)
+			GROUP BY nt.note_id`
+		args = append(args, userID)
+		for _, tag := range query.Tags {
+			args = append(args, tag)
+		}
+
+		if query.TagMode == models.TagModeAll {
+			filter += " HAVING COUNT(*) = ?"
+			args = append(args, len(query.Tags))
+		}
+
+		where = append(where, filter+")")
+	}
+
+	var total int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM notes WHERE "+strings.Join(where, " AND ")+";", args...).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to count notes: %w", op, err)
+	}
+
+	if query.Cursor != "" {
+		c, err := storage.DecodeCursor(query.Cursor, query.SortBy, query.Order)
+		if err != nil {
+			return nil, err
+		}
+
+		where = append(where, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", column, comparison))
+		args = append(args, c.Value, c.Value, c.ID)
+	}
+
+	stmt, err := s.db.PrepareContext(ctx, fmt.Sprintf(`
+		SELECT id, user_id, title, content, created_at, updated_at, deleted_at, version
+		FROM notes
+		WHERE %s
+		ORDER BY %s %s, id %s
+		LIMIT ?;
+	`, strings.Join(where, " AND "), column, direction, direction))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	// One extra row tells us whether another page exists.
+	rows, err := stmt.QueryContext(ctx, append(args, query.Limit+1)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	notes := make([]models.Note, 0, query.Limit+1)
+	for rows.Next() {
+		var note models.Note
+		if err := rows.Scan(&note.ID, &note.UserID, &note.Title, &note.Content, &note.CreatedAt, &note.UpdatedAt, &note.DeletedAt, &note.Version); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+
+		notes = append(notes, note)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	page := &models.NotesPage{Notes: notes, Total: total}
+
+	refs := make([]*models.Note, len(notes))
+	for i := range notes {
+		refs[i] = &notes[i]
+	}
+
+	if err := s.attachTags(ctx, refs...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(notes) > query.Limit {
+		page.Notes = notes[:query.Limit]
+		last := page.Notes[len(page.Notes)-1]
+
+		page.NextCursor, err = storage.EncodeCursor(storage.Cursor{
+			SortBy: query.SortBy,
+			Order:  query.Order,
+			Value:  sortValue(last, query.SortBy),
+			ID:     last.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to encode cursor: %w", op, err)
+		}
+	}
+
+	storage.ReturnedRows(ctx, len(page.Notes))
+
+	return page, nil
+}
+
+func sortValue(note models.Note, sortBy string) string {
+	switch sortBy {
+	case "updated_at":
+		return note.UpdatedAt
+	case "title":
+		return note.Title
+	case "deleted_at":
+		if note.DeletedAt != nil {
+			return *note.DeletedAt
+		}
+		return ""
+	default:
+		return note.CreatedAt
+	}
+}
+
+// DeleteNote moves a note to the trash. Trashed notes are hidden from every
+// read except the trash listing until they are restored or purged. A non-zero
+// version makes the delete conditional on the note still being at that
+// version.
+func (s *Storage) DeleteNote(ctx context.Context, id, userID, version int) error {
+	const op = "sqlite.DeleteNote"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		UPDATE notes
+		SET deleted_at = current_timestamp
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?);
+	`)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, id, userID, version, version)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	storage.AffectedRows(ctx, rowsAffected)
+
+	if rowsAffected == 0 {
+		return writeConflict(ctx, s.db, id, userID, false)
+	}
+
+	return nil
+}
+
+func (s *Storage) RestoreNote(ctx context.Context, id, userID int) error {
+	const op = "sqlite.RestoreNote"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		UPDATE notes
+		SET deleted_at = NULL
+		WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL;
+	`)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	storage.AffectedRows(ctx, rowsAffected)
+
+	if rowsAffected == 0 {
+		return storage.ErrNoteNotFound
+	}
+
+	return nil
+}
+
+// PurgeNote permanently deletes a note, whether it is in the trash or not.
+// version works as in DeleteNote.
+func (s *Storage) PurgeNote(ctx context.Context, id, userID, version int) error {
+	const op = "sqlite.PurgeNote"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		DELETE FROM notes
+		WHERE id = ? AND user_id = ? AND (? = 0 OR version = ?);
+	`)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, id, userID, version, version)
+	if err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	storage.AffectedRows(ctx, rowsAffected)
+
+	if rowsAffected == 0 {
+		return writeConflict(ctx, tx, id, userID, true)
+	}
+
+	if err := deleteUnusedTags(ctx, tx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// PurgeTrash permanently deletes every note that was trashed before cutoff
+// and returns how many were removed.
+func (s *Storage) PurgeTrash(ctx context.Context, cutoff time.Time) (int64, error) {
+	const op = "sqlite.PurgeTrash"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		DELETE FROM notes
+		WHERE deleted_at IS NOT NULL AND deleted_at < ?;
+	`, cutoff.UTC().Format(timestampLayout))
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM tags
+		WHERE NOT EXISTS (
+			SELECT 1
+			FROM note_tags
+			WHERE tag_id = tags.id);
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to delete unused tags: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	storage.AffectedRows(ctx, purged)
+
+	return purged, nil
+}
+
+// UpdateNote overwrites the title and content of a note and returns its new
+// version. A nil tags slice leaves the tags untouched; an empty one removes
+// them all. A non-zero version turns the update into a compare-and-swap that
+// fails with storage.ErrVersionMismatch when the note has moved on.
+func (s *Storage) UpdateNote(ctx context.Context, id, userID int, title, content string, tags []string, version int) (int, error) {
+	const op = "sqlite.UpdateNote"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := recordRevision(ctx, tx, id, userID, &title, &content); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		UPDATE notes
+		SET title = ?, content = ?, updated_at = current_timestamp, version = version + 1
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)
+		RETURNING version;
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var newVersion int
+	if err := stmt.QueryRowContext(ctx, title, content, id, userID, version, version).Scan(&newVersion); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, writeConflict(ctx, tx, id, userID, false)
+		}
+
+		return 0, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	if tags != nil {
+		if err := setNoteTags(ctx, tx, userID, int64(id), tags); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return newVersion, nil
+}
+
+// PatchNote updates only the fields set in patch and returns the note as it
+// is afterwards. Like UpdateNote, a non-zero version makes the write
+// conditional on the note still being at that version.
+func (s *Storage) PatchNote(ctx context.Context, id, userID int, patch models.NotePatch, version int) (*models.Note, error) {
+	const op = "sqlite.PatchNote"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := recordRevision(ctx, tx, id, userID, patch.Title, patch.Content); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sets := []string{"updated_at = current_timestamp", "version = version + 1"}
+	var args []any
+
+	if patch.Title != nil {
+		sets = append(sets, "title = ?")
+		args = append(args, *patch.Title)
+	}
+
+	if patch.Content != nil {
+		sets = append(sets, "content = ?")
+		args = append(args, *patch.Content)
+	}
+
+	args = append(args, id, userID, version, version)
+
+	var newVersion int
+	err = tx.QueryRowContext(ctx, `
+		UPDATE notes
+		SET `+strings.Join(sets, ", ")+`
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)
+		RETURNING version;
+	`, args...).Scan(&newVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, writeConflict(ctx, tx, id, userID, false)
+		}
+
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	if patch.Tags != nil {
+		if err := setNoteTags(ctx, tx, userID, int64(id), patch.Tags); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	note, err := s.Note(ctx, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return note, nil
+}
+
+// writeConflict explains why a conditional write matched no row: either the
+// note does not exist for this user or it is at another version.
+func writeConflict(ctx context.Context, q querier, id, userID int, includeTrashed bool) error {
+	var exists bool
+	err := q.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM notes
+			WHERE id = ? AND user_id = ? AND (? OR deleted_at IS NULL));
+	`, id, userID, includeTrashed).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check note: %w", err)
+	}
+
+	if !exists {
+		return storage.ErrNoteNotFound
+	}
+
+	return storage.ErrVersionMismatch
+}
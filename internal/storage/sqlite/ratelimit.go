@@ -0,0 +1,90 @@
+//go:build sqlite_fts5
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"notes-api/internal/ratelimit"
+	"notes-api/internal/storage"
+	"time"
+)
+
+// RateLimits keeps the buckets of the rate limiter in the database, so that
+// every process serving it draws from the same ones.
+type RateLimits struct {
+	s *Storage
+}
+
+var _ ratelimit.Store = RateLimits{}
+
+func (s *Storage) RateLimits() RateLimits {
+	return RateLimits{s: s}
+}
+
+func (r RateLimits) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	const op = "sqlite.RateLimits.Take"
+
+	ctx, cancel := r.s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := r.s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var fullAt time.Time
+
+	var nanos int64
+	err = tx.QueryRowContext(ctx, "SELECT full_at FROM rate_limits WHERE key = ?;", key).Scan(&nanos)
+	switch {
+	case err == nil:
+		fullAt = time.Unix(0, nanos)
+	case !errors.Is(err, sql.ErrNoRows):
+		return ratelimit.Result{}, fmt.Errorf("%s: failed to read bucket: %w", op, err)
+	}
+
+	next, res := limit.Take(fullAt, time.Now())
+	if !res.Allowed {
+		return res, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO rate_limits (key, full_at)
+		VALUES (?, ?)
+		ON CONFLICT (key) DO UPDATE SET full_at = excluded.full_at;
+	`, key, next.UnixNano())
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("%s: failed to update bucket: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ratelimit.Result{}, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return res, nil
+}
+
+func (r RateLimits) Purge(ctx context.Context, now time.Time) (int64, error) {
+	const op = "sqlite.RateLimits.Purge"
+
+	ctx, cancel := r.s.queryContext(ctx, op)
+	defer cancel()
+
+	res, err := r.s.db.ExecContext(ctx, "DELETE FROM rate_limits WHERE full_at <= ?;", now.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	storage.AffectedRows(ctx, purged)
+
+	return purged, nil
+}
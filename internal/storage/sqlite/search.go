@@ -0,0 +1,111 @@
+//go:build sqlite_fts5
+
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	highlightOpen  = "<mark>"
+	highlightClose = "</mark>"
+)
+
+func (s *Storage) SearchNotes(ctx context.Context, userID int, query models.SearchQuery) ([]models.SearchResult, error) {
+	const op = "sqlite.SearchNotes"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	// Title matches weigh more than content matches.
+	stmt, err := s.db.PrepareContext(ctx, `
+		SELECT n.id, n.user_id, n.title, n.content, n.created_at, n.updated_at, n.version,
+			bm25(notes_fts, 10.0, 1.0) AS rank,
+			highlight(notes_fts, 0, ?, ?),
+			snippet(notes_fts, 1, ?, ?, '…', 24)
+		FROM notes_fts
+		JOIN notes n ON n.id = notes_fts.rowid
+		WHERE notes_fts MATCH ? AND n.user_id = ? AND n.deleted_at IS NULL
+		ORDER BY rank, n.id
+		LIMIT ? OFFSET ?;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx,
+		highlightOpen, highlightClose,
+		highlightOpen, highlightClose,
+		query.Query, userID, query.Limit, query.Offset,
+	)
+	if err != nil {
+		if isSearchSyntaxError(err) {
+			return nil, fmt.Errorf("%w: %s", storage.ErrInvalidSearchQuery, err)
+		}
+
+		return nil, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+	defer rows.Close()
+
+	results := make([]models.SearchResult, 0, query.Limit)
+	for rows.Next() {
+		var result models.SearchResult
+		err := rows.Scan(
+			&result.ID, &result.UserID, &result.Title, &result.Content, &result.CreatedAt, &result.UpdatedAt, &result.Version,
+			&result.Rank, &result.TitleHighlight, &result.Snippet,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		if isSearchSyntaxError(err) {
+			return nil, fmt.Errorf("%w: %s", storage.ErrInvalidSearchQuery, err)
+		}
+
+		return nil, fmt.Errorf("%s: failed to iterate rows: %w", op, err)
+	}
+
+	notes := make([]*models.Note, len(results))
+	for i := range results {
+		notes[i] = &results[i].Note
+	}
+
+	if err := s.attachTags(ctx, notes...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	storage.ReturnedRows(ctx, len(results))
+
+	return results, nil
+}
+
+// isSearchSyntaxError reports whether err comes from FTS5 rejecting the
+// MATCH expression rather than from the database itself.
+func isSearchSyntaxError(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.Code != sqlite3.ErrError {
+		return false
+	}
+
+	msg := sqliteErr.Error()
+
+	for _, prefix := range []string{"fts5:", "no such column", "unterminated string", "unknown special query"} {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
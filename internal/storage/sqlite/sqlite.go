@@ -0,0 +1,196 @@
+//go:build sqlite_fts5
+
+// Package sqlite stores users and notes in a SQLite database file. Its search
+// index needs FTS5, which go-sqlite3 compiles in only with the sqlite_fts5
+// build tag, so the package is built only with that tag.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"notes-api/internal/migrate"
+	"notes-api/internal/storage"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("notes-api/internal/storage/sqlite")
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type Storage struct {
+	db *sql.DB
+	// queryTimeout bounds how long a single storage call may take. Zero
+	// leaves it to the context of the caller.
+	queryTimeout time.Duration
+	observer     storage.Observer
+}
+
+// New opens the database at storagePath and migrates it to the latest
+// schema.
+func New(storagePath string, queryTimeout time.Duration) (*Storage, error) {
+	const op = "sqlite.New"
+
+	s, err := Open(storagePath)
+	if err != nil {
+		return nil, err
+	}
+	s.queryTimeout = queryTimeout
+
+	migrator, err := s.Migrator()
+	if err != nil {
+		s.db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := migrator.Up(0); err != nil {
+		s.db.Close()
+		return nil, fmt.Errorf("%s: failed to migrate database: %w", op, err)
+	}
+
+	return s, nil
+}
+
+// Open connects to the database at storagePath without changing its schema.
+func Open(storagePath string) (*Storage, error) {
+	const op = "sqlite.Open"
+
+	// Transactions take the write lock up front. A deferred transaction that
+	// upgrades from reading to writing fails right away with "database is
+	// locked" instead of waiting for a concurrent writer.
+	db, err := sql.Open("sqlite3", storagePath+"?_txlock=immediate")
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to open database: %w", op, err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: failed to connect to database: %w", op, err)
+	}
+
+	_, err = db.Exec("PRAGMA foreign_keys = ON;")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: failed to enable foreign keys: %w", op, err)
+	}
+
+	return &Storage{db: db}, nil
+}
+
+// Close checkpoints the write-ahead log, if there is one, into the database
+// file and closes the database.
+func (s *Storage) Close() error {
+	const op = "sqlite.Close"
+
+	if _, err := s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE);"); err != nil {
+		s.db.Close()
+		return fmt.Errorf("%s: failed to checkpoint database: %w", op, err)
+	}
+
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("%s: failed to close database: %w", op, err)
+	}
+
+	return nil
+}
+
+// Ping checks that the database accepts connections.
+func (s *Storage) Ping(ctx context.Context) error {
+	const op = "sqlite.Ping"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("%s: failed to ping database: %w", op, err)
+	}
+
+	return nil
+}
+
+// PendingMigrations returns how many of the embedded migrations have not
+// been applied to the database, or were changed after they were.
+func (s *Storage) PendingMigrations() (int, error) {
+	const op = "sqlite.PendingMigrations"
+
+	migrator, err := s.Migrator()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		if !status.Applied || status.Modified {
+			pending++
+		}
+	}
+
+	return pending, nil
+}
+
+// SetObserver makes the storage report the duration of every operation to
+// observer. It must be called before the storage is used.
+func (s *Storage) SetObserver(observer storage.Observer) {
+	s.observer = observer
+}
+
+// DB returns the connection pool, for instrumentation.
+func (s *Storage) DB() *sql.DB {
+	return s.db
+}
+
+// queryContext derives the context a storage call runs under from the one
+// of its caller and starts a span for op in it. Cancelling it ends the span
+// and reports the duration of op to the observer, if there is one.
+func (s *Storage) queryContext(ctx context.Context, op string) (context.Context, context.CancelFunc) {
+	ctx, span := tracer.Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNameSQLite, semconv.DBOperationName(op)),
+	)
+
+	var cancel context.CancelFunc
+	if s.queryTimeout <= 0 {
+		ctx, cancel = context.WithCancel(ctx)
+	} else {
+		ctx, cancel = context.WithTimeout(ctx, s.queryTimeout)
+	}
+
+	start := time.Now()
+	return ctx, func() {
+		cancel()
+		span.End()
+
+		if s.observer != nil {
+			s.observer.ObserveOp("sqlite", storage.OpName(op), time.Since(start))
+		}
+	}
+}
+
+// Migrator returns a migrator for the schema migrations embedded in the
+// binary.
+func (s *Storage) Migrator() (*migrate.Migrator, error) {
+	files, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migrations: %w", err)
+	}
+
+	return migrate.New(s.db, files)
+}
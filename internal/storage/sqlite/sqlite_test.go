@@ -0,0 +1,94 @@
+//go:build sqlite_fts5
+
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notes-api/internal/app"
+	"notes-api/internal/ratelimit"
+	"notes-api/internal/storage"
+	"notes-api/internal/storage/sqlite"
+	"notes-api/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) app.Storage {
+		s, err := sqlite.New(filepath.Join(t.TempDir(), "notes.db"), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+
+		return s
+	})
+}
+
+// TestRateLimitsShared checks that two processes using the same database
+// draw from the same buckets.
+func TestRateLimitsShared(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "notes.db")
+	limit := ratelimit.Limit{Requests: 2, Period: time.Hour}
+
+	var stores []ratelimit.Store
+	for range 2 {
+		s, err := sqlite.New(path, 0)
+		require.NoError(t, err)
+		t.Cleanup(func() { s.Close() })
+
+		stores = append(stores, s.RateLimits())
+	}
+
+	for _, store := range stores {
+		res, err := store.Take(ctx, "ip:192.0.2.1", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+	}
+
+	res, err := stores[0].Take(ctx, "ip:192.0.2.1", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Positive(t, res.RetryAfter)
+
+	purged, err := stores[1].Purge(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	purged, err = stores[1].Purge(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	res, err = stores[0].Take(ctx, "ip:192.0.2.1", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+}
+
+type observer struct {
+	driver, op string
+}
+
+func (o *observer) ObserveOp(driver, op string, _ time.Duration) {
+	o.driver, o.op = driver, op
+}
+
+// TestObserver checks that operations are reported under the names every
+// backend shares, with the driver apart.
+func TestObserver(t *testing.T) {
+	s, err := sqlite.New(filepath.Join(t.TempDir(), "notes.db"), 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	var o observer
+	s.SetObserver(&o)
+
+	_, err = s.Note(context.Background(), 1, 1)
+	require.ErrorIs(t, err, storage.ErrNoteNotFound)
+	assert.Equal(t, observer{driver: "sqlite", op: "storage.Note"}, o)
+}
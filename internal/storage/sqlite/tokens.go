@@ -0,0 +1,284 @@
+//go:build sqlite_fts5
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"notes-api/internal/storage"
+	"time"
+)
+
+// CreateRefreshToken stores the hash of a refresh token issued to userID.
+// Tokens obtained by rotating one another share a family.
+func (s *Storage) CreateRefreshToken(ctx context.Context, userID int64, familyID, tokenHash string, expiresAt time.Time) error {
+	const op = "sqlite.CreateRefreshToken"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES (?, ?, ?, ?);
+	`)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, userID, familyID, tokenHash, expiresAt.UTC().Format(timestampLayout)); err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// RotateRefreshToken spends the refresh token with tokenHash and stores
+// newHash in its place, returning the user the tokens belong to. Presenting a
+// token that was already spent revokes its whole family and returns
+// storage.ErrRefreshTokenReused, since either the client or an attacker holds a
+// stolen copy.
+func (s *Storage) RotateRefreshToken(ctx context.Context, tokenHash, newHash string, expiresAt time.Time) (int64, error) {
+	const op = "sqlite.RotateRefreshToken"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var (
+		userID   int64
+		familyID string
+	)
+	err = tx.QueryRowContext(ctx, `
+		UPDATE refresh_tokens
+		SET used_at = current_timestamp
+		WHERE token_hash = ? AND used_at IS NULL AND revoked_at IS NULL AND expires_at > ?
+		RETURNING user_id, family_id;
+	`, tokenHash, time.Now().UTC().Format(timestampLayout)).Scan(&userID, &familyID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s: failed to spend token: %w", op, err)
+		}
+
+		var used bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT family_id, used_at IS NOT NULL
+			FROM refresh_tokens
+			WHERE token_hash = ?;
+		`, tokenHash).Scan(&familyID, &used)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return 0, storage.ErrRefreshTokenNotFound
+			}
+
+			return 0, fmt.Errorf("%s: failed to find token: %w", op, err)
+		}
+
+		if !used {
+			return 0, storage.ErrRefreshTokenNotFound
+		}
+
+		if err := revokeTokenFamily(ctx, tx, familyID); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+		}
+
+		return 0, storage.ErrRefreshTokenReused
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES (?, ?, ?, ?);
+	`, userID, familyID, newHash, expiresAt.UTC().Format(timestampLayout))
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to store token: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// RevokeRefreshToken revokes the family of a refresh token of userID, ending
+// the session it belongs to. Unknown tokens are ignored.
+func (s *Storage) RevokeRefreshToken(ctx context.Context, userID int64, tokenHash string) error {
+	const op = "sqlite.RevokeRefreshToken"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var familyID string
+	err = tx.QueryRowContext(ctx, `
+		SELECT family_id
+		FROM refresh_tokens
+		WHERE token_hash = ? AND user_id = ?;
+	`, tokenHash, userID).Scan(&familyID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+
+		return fmt.Errorf("%s: failed to find token: %w", op, err)
+	}
+
+	if err := revokeTokenFamily(ctx, tx, familyID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeUserRefreshTokens revokes every refresh token of userID.
+func (s *Storage) RevokeUserRefreshTokens(ctx context.Context, userID int64) error {
+	const op = "sqlite.RevokeUserRefreshTokens"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = current_timestamp
+		WHERE user_id = ? AND revoked_at IS NULL;
+	`)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, userID); err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeAccessToken adds the jti of an access token to the denylist until
+// the token expires anyway.
+func (s *Storage) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	const op = "sqlite.RevokeAccessToken"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES (?, ?)
+		ON CONFLICT (jti) DO NOTHING;
+	`)
+	if err != nil {
+		return fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, jti, expiresAt.UTC().Format(timestampLayout)); err != nil {
+		return fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) AccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	const op = "sqlite.AccessTokenRevoked"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	stmt, err := s.db.PrepareContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM revoked_tokens
+			WHERE jti = ?);
+	`)
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to prepare statement: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var revoked bool
+	if err := stmt.QueryRowContext(ctx, jti).Scan(&revoked); err != nil {
+		return false, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+	}
+
+	return revoked, nil
+}
+
+// PurgeExpiredTokens deletes refresh tokens, denylist entries, personal
+// access tokens, password reset tokens and sign-in challenges that expired
+// before now and so no longer need to be remembered.
+func (s *Storage) PurgeExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	const op = "sqlite.PurgeExpiredTokens"
+
+	ctx, cancel := s.queryContext(ctx, op)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	cutoff := now.UTC().Format(timestampLayout)
+
+	var purged int64
+	for _, query := range []string{
+		"DELETE FROM refresh_tokens WHERE expires_at <= ?;",
+		"DELETE FROM revoked_tokens WHERE expires_at <= ?;",
+		"DELETE FROM personal_access_tokens WHERE expires_at <= ?;",
+		"DELETE FROM password_reset_tokens WHERE expires_at <= ?;",
+		"DELETE FROM mfa_challenges WHERE expires_at <= ?;",
+	} {
+		res, err := tx.ExecContext(ctx, query, cutoff)
+		if err != nil {
+			return 0, fmt.Errorf("%s: failed to execute statement: %w", op, err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+		}
+		purged += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	storage.AffectedRows(ctx, purged)
+
+	return purged, nil
+}
+
+func revokeTokenFamily(ctx context.Context, tx *sql.Tx, familyID string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = current_timestamp
+		WHERE family_id = ? AND revoked_at IS NULL;
+	`, familyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
+
+	return nil
+}
@@ -1,76 +1,45 @@
+// Package storage defines what storage backends have in common: the errors
+// handlers map to HTTP responses, the pagination cursor format and the hook
+// for timing operations. The backends themselves live in subpackages.
 package storage
 
 import (
-	"database/sql"
 	"errors"
-	"fmt"
-
-	_ "github.com/mattn/go-sqlite3"
+	"strings"
+	"time"
 )
 
-var ErrUserAlreadyExists = errors.New("user already exists")
-var ErrNoteNotFound = errors.New("note not found")
-
-type Storage struct {
-	db *sql.DB
+// Observer is told how long every storage operation took. op names the
+// operation the same way for every backend, such as "storage.CreateNote",
+// and driver the backend that ran it.
+type Observer interface {
+	ObserveOp(driver, op string, duration time.Duration)
 }
 
-func New(storagePath string) (*Storage, error) {
-	const op = "storage.New"
-
-	db, err := sql.Open("sqlite3", storagePath)
-	if err != nil {
-		return nil, fmt.Errorf("%s: failed to open database: %w", op, err)
-	}
-
-	if err := db.Ping(); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("%s: failed to connect to database: %w", op, err)
-	}
-
-	_, err = db.Exec("PRAGMA foreign_keys = ON;")
-	if err != nil {
-		db.Close()
-		return nil, fmt.Errorf("%s: failed to enable foreign keys: %w", op, err)
-	}
-
-	_, err = db.Exec(`
-        CREATE TABLE IF NOT EXISTS users (
-            id INTEGER PRIMARY KEY,
-            username TEXT NOT NULL UNIQUE,
-            password TEXT NOT NULL
-        );`)
-	if err != nil {
-		db.Close()
-		return nil, fmt.Errorf("%s: failed to create users table: %w", op, err)
-	}
-
-	_, err = db.Exec(`
-        CREATE TABLE IF NOT EXISTS notes (
-            id INTEGER PRIMARY KEY,
-            user_id INTEGER NOT NULL,
-            title TEXT NOT NULL,
-            content TEXT,
-            created_at TEXT NOT NULL DEFAULT current_timestamp,
-            updated_at TEXT NOT NULL DEFAULT current_timestamp,
-            FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
-        );`)
-	if err != nil {
-		db.Close()
-		return nil, fmt.Errorf("%s: failed to create notes table: %w", op, err)
-	}
-
-	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_notes_user_id ON notes(user_id);")
-	if err != nil {
-		db.Close()
-		return nil, fmt.Errorf("%s: failed to create index: %w", op, err)
-	}
-
-	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_notes_id_user_id ON notes(id, user_id);")
-	if err != nil {
-		db.Close()
-		return nil, fmt.Errorf("%s: failed to create index: %w", op, err)
-	}
-
-	return &Storage{db: db}, nil
+// OpName turns the name a backend prefixes its errors with, such as
+// "sqlite.CreateNote", into the one Observer is given, "storage.CreateNote".
+func OpName(op string) string {
+	_, method, _ := strings.Cut(op, ".")
+	return "storage." + method
 }
+
+var ErrUserAlreadyExists = errors.New("user already exists")
+var ErrUserNotFound = errors.New("user not found")
+var ErrNoteNotFound = errors.New("note not found")
+var ErrInvalidCursor = errors.New("invalid cursor")
+var ErrInvalidSearchQuery = errors.New("invalid search query")
+var ErrTagNotFound = errors.New("tag not found")
+var ErrTagAlreadyExists = errors.New("tag already exists")
+var ErrRevisionNotFound = errors.New("revision not found")
+var ErrVersionMismatch = errors.New("note version mismatch")
+var ErrRefreshTokenNotFound = errors.New("refresh token not found")
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+var ErrTokenNotFound = errors.New("personal access token not found")
+var ErrTokenAlreadyExists = errors.New("personal access token already exists")
+var ErrResetTokenNotFound = errors.New("password reset token not found")
+var ErrMFAChallengeNotFound = errors.New("mfa challenge not found")
+var ErrRecoveryCodeNotFound = errors.New("recovery code not found")
+
+// ErrNoSQLite is returned for the sqlite driver by binaries built without the
+// sqlite_fts5 tag, which the sqlite backend needs for its search index.
+var ErrNoSQLite = errors.New("sqlite storage is not compiled in, rebuild with -tags sqlite_fts5")
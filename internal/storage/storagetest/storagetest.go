@@ -0,0 +1,769 @@
+// Package storagetest is a conformance suite for storage backends. Every
+// backend runs it, so that handlers see the same sentinel errors and
+// ownership rules whichever one is configured.
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notes-api/internal/app"
+	"notes-api/internal/models"
+	"notes-api/internal/storage"
+)
+
+// Run runs the suite. open must return an empty, migrated storage for every
+// call.
+func Run(t *testing.T, open func(t *testing.T) app.Storage) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s app.Storage)
+	}{
+		{"Users", testUsers},
+		{"FailedLogins", testFailedLogins},
+		{"SetPassword", testSetPassword},
+		{"PasswordResets", testPasswordResets},
+		{"TwoFactor", testTwoFactor},
+		{"MFAChallenges", testMFAChallenges},
+		{"NoteOwnership", testNoteOwnership},
+		{"NoteVersions", testNoteVersions},
+		{"Trash", testTrash},
+		{"Pagination", testPagination},
+		{"Tags", testTags},
+		{"Search", testSearch},
+		{"Revisions", testRevisions},
+		{"RefreshTokens", testRefreshTokens},
+		{"PersonalAccessTokens", testPersonalAccessTokens},
+		{"ConcurrentWrites", testConcurrentWrites},
+		{"ContextErrors", testContextErrors},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, open(t))
+		})
+	}
+}
+
+func createUser(t *testing.T, s app.Storage, username string) int {
+	t.Helper()
+
+	id, err := s.CreateUser(t.Context(), username, "hash", "")
+	require.NoError(t, err)
+
+	return int(id)
+}
+
+func testUsers(t *testing.T, s app.Storage) {
+	ctx := t.Context()
+
+	id, err := s.CreateUser(ctx, "alice", "hash", "alice@example.com")
+	require.NoError(t, err)
+
+	_, err = s.CreateUser(ctx, "alice", "other", "")
+	assert.ErrorIs(t, err, storage.ErrUserAlreadyExists)
+
+	exists, err := s.UserExists(ctx, "alice")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = s.UserExists(ctx, "bob")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	user, err := s.User(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, id, user.ID)
+	assert.Equal(t, "hash", user.Password)
+	assert.Equal(t, "alice@example.com", user.Email)
+
+	_, err = s.User(ctx, "bob")
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+
+	user, err = s.UserByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Username)
+	assert.Equal(t, "alice@example.com", user.Email)
+
+	_, err = s.UserByID(ctx, id+1)
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+
+	bob, err := s.CreateUser(ctx, "bob", "hash", "")
+	require.NoError(t, err)
+
+	user, err = s.UserByID(ctx, bob)
+	require.NoError(t, err)
+	assert.Empty(t, user.Email)
+
+	require.NoError(t, s.UpdatePassword(ctx, id, "rehashed"))
+
+	user, err = s.User(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "rehashed", user.Password)
+}
+
+func testFailedLogins(t *testing.T, s app.Storage) {
+	ctx := t.Context()
+
+	alice := int64(createUser(t, s, "alice"))
+	createUser(t, s, "bob")
+
+	for want := 1; want <= 3; want++ {
+		failures, err := s.RecordFailedLogin(ctx, alice)
+		require.NoError(t, err)
+		assert.Equal(t, want, failures)
+	}
+
+	until := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	require.NoError(t, s.LockUser(ctx, alice, until))
+
+	user, err := s.User(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 3, user.FailedLogins)
+	assert.True(t, until.Equal(user.LockedUntil), "locked until %v, want %v", user.LockedUntil, until)
+
+	user, err = s.User(ctx, "bob")
+	require.NoError(t, err)
+	assert.Zero(t, user.FailedLogins)
+	assert.True(t, user.LockedUntil.IsZero())
+
+	require.NoError(t, s.ResetFailedLogins(ctx, alice))
+
+	user, err = s.User(ctx, "alice")
+	require.NoError(t, err)
+	assert.Zero(t, user.FailedLogins)
+	assert.True(t, user.LockedUntil.IsZero())
+}
+
+func testSetPassword(t *testing.T, s app.Storage) {
+	ctx := t.Context()
+
+	alice := int64(createUser(t, s, "alice"))
+	bob := int64(createUser(t, s, "bob"))
+	expiresAt := time.Now().Add(time.Hour)
+
+	require.NoError(t, s.CreateRefreshToken(ctx, alice, "alice", "alice", expiresAt))
+	require.NoError(t, s.CreateRefreshToken(ctx, bob, "bob", "bob", expiresAt))
+	_, err := s.RecordFailedLogin(ctx, alice)
+	require.NoError(t, err)
+	require.NoError(t, s.LockUser(ctx, alice, expiresAt))
+
+	require.NoError(t, s.SetPassword(ctx, alice, "changed"))
+
+	user, err := s.UserByID(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, "changed", user.Password)
+	assert.Zero(t, user.FailedLogins)
+	assert.True(t, user.LockedUntil.IsZero())
+
+	// Every session of alice ends; bob's are left alone.
+	_, err = s.RotateRefreshToken(ctx, "alice", "alice2", expiresAt)
+	assert.ErrorIs(t, err, storage.ErrRefreshTokenNotFound)
+
+	_, err = s.RotateRefreshToken(ctx, "bob", "bob2", expiresAt)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, s.SetPassword(ctx, bob+1, "changed"), storage.ErrUserNotFound)
+}
+
+func testPasswordResets(t *testing.T, s app.Storage) {
+	ctx := t.Context()
+
+	alice := int64(createUser(t, s, "alice"))
+	bob := int64(createUser(t, s, "bob"))
+	now := time.Now()
+
+	require.NoError(t, s.CreatePasswordResetToken(ctx, alice, "first", now.Add(time.Hour)))
+	require.NoError(t, s.CreatePasswordResetToken(ctx, alice, "second", now.Add(time.Hour)))
+	require.NoError(t, s.CreatePasswordResetToken(ctx, alice, "expired", now.Add(-time.Minute)))
+	require.NoError(t, s.CreatePasswordResetToken(ctx, bob, "bob", now.Add(time.Hour)))
+	require.NoError(t, s.CreateRefreshToken(ctx, alice, "family", "refresh", now.Add(time.Hour)))
+
+	_, err := s.ResetPassword(ctx, "unknown", "reset")
+	assert.ErrorIs(t, err, storage.ErrResetTokenNotFound)
+
+	_, err = s.ResetPassword(ctx, "expired", "reset")
+	assert.ErrorIs(t, err, storage.ErrResetTokenNotFound)
+
+	userID, err := s.ResetPassword(ctx, "first", "reset")
+	require.NoError(t, err)
+	assert.Equal(t, alice, userID)
+
+	user, err := s.UserByID(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, "reset", user.Password)
+
+	_, err = s.RotateRefreshToken(ctx, "refresh", "rotated", now.Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrRefreshTokenNotFound)
+
+	// A token is spent by its use, and so are the others alice was sent.
+	_, err = s.ResetPassword(ctx, "first", "again")
+	assert.ErrorIs(t, err, storage.ErrResetTokenNotFound)
+
+	_, err = s.ResetPassword(ctx, "second", "again")
+	assert.ErrorIs(t, err, storage.ErrResetTokenNotFound)
+
+	userID, err = s.ResetPassword(ctx, "bob", "reset")
+	require.NoError(t, err)
+	assert.Equal(t, bob, userID)
+
+	user, err = s.UserByID(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, "reset", user.Password)
+}
+
+func testTwoFactor(t *testing.T, s app.Storage) {
+	ctx := t.Context()
+
+	alice := int64(createUser(t, s, "alice"))
+	bob := int64(createUser(t, s, "bob"))
+
+	require.NoError(t, s.SetTOTPSecret(ctx, alice, "SECRET"))
+
+	user, err := s.UserByID(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, "SECRET", user.TOTPSecret)
+	assert.False(t, user.TOTPEnabled)
+
+	// Enabling needs a secret to enable.
+	assert.ErrorIs(t, s.EnableTOTP(ctx, bob, []string{"code"}), storage.ErrUserNotFound)
+	assert.ErrorIs(t, s.SetTOTPSecret(ctx, bob+1, "SECRET"), storage.ErrUserNotFound)
+
+	require.NoError(t, s.EnableTOTP(ctx, alice, []string{"first", "second"}))
+
+	user, err = s.User(ctx, "alice")
+	require.NoError(t, err)
+	assert.True(t, user.TOTPEnabled)
+
+	ok, err := s.UseTOTPStep(ctx, alice, 100)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	for _, step := range []int64{100, 99} {
+		ok, err = s.UseTOTPStep(ctx, alice, step)
+		require.NoError(t, err)
+		assert.False(t, ok, "step %d was replayed", step)
+	}
+
+	ok, err = s.UseTOTPStep(ctx, alice, 101)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, s.UseRecoveryCode(ctx, alice, "first"))
+	assert.ErrorIs(t, s.UseRecoveryCode(ctx, alice, "first"), storage.ErrRecoveryCodeNotFound)
+	assert.ErrorIs(t, s.UseRecoveryCode(ctx, bob, "second"), storage.ErrRecoveryCodeNotFound)
+
+	// Enabling again replaces the recovery codes.
+	require.NoError(t, s.EnableTOTP(ctx, alice, []string{"third"}))
+	assert.ErrorIs(t, s.UseRecoveryCode(ctx, alice, "second"), storage.ErrRecoveryCodeNotFound)
+	require.NoError(t, s.UseRecoveryCode(ctx, alice, "third"))
+
+	require.NoError(t, s.EnableTOTP(ctx, alice, []string{"fourth"}))
+	require.NoError(t, s.CreateMFAChallenge(ctx, alice, "challenge", time.Now().Add(time.Hour)))
+	require.NoError(t, s.DisableTOTP(ctx, alice))
+
+	user, err = s.UserByID(ctx, alice)
+	require.NoError(t, err)
+	assert.Empty(t, user.TOTPSecret)
+	assert.False(t, user.TOTPEnabled)
+
+	assert.ErrorIs(t, s.UseRecoveryCode(ctx, alice, "fourth"), storage.ErrRecoveryCodeNotFound)
+
+	_, err = s.MFAChallenge(ctx, "challenge")
+	assert.ErrorIs(t, err, storage.ErrMFAChallengeNotFound)
+
+	// A new setup starts the replay protection over.
+	require.NoError(t, s.SetTOTPSecret(ctx, alice, "OTHER"))
+
+	ok, err = s.UseTOTPStep(ctx, alice, 50)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func testMFAChallenges(t *testing.T, s app.Storage) {
+	ctx := t.Context()
+
+	alice := int64(createUser(t, s, "alice"))
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	require.NoError(t, s.CreateMFAChallenge(ctx, alice, "challenge", expiresAt))
+	require.NoError(t, s.CreateMFAChallenge(ctx, alice, "expired", time.Now().Add(-time.Minute)))
+
+	challenge, err := s.MFAChallenge(ctx, "challenge")
+	require.NoError(t, err)
+	assert.Equal(t, alice, challenge.UserID)
+	assert.Zero(t, challenge.Attempts)
+	assert.True(t, expiresAt.Equal(challenge.ExpiresAt), "expires at %v, want %v", challenge.ExpiresAt, expiresAt)
+
+	_, err = s.MFAChallenge(ctx, "expired")
+	assert.ErrorIs(t, err, storage.ErrMFAChallengeNotFound)
+
+	_, err = s.MFAChallenge(ctx, "unknown")
+	assert.ErrorIs(t, err, storage.ErrMFAChallengeNotFound)
+
+	for want := 1; want <= 2; want++ {
+		attempts, err := s.RecordMFAChallengeFailure(ctx, "challenge")
+		require.NoError(t, err)
+		assert.Equal(t, want, attempts)
+	}
+
+	challenge, err = s.MFAChallenge(ctx, "challenge")
+	require.NoError(t, err)
+	assert.Equal(t, 2, challenge.Attempts)
+
+	_, err = s.RecordMFAChallengeFailure(ctx, "unknown")
+	assert.ErrorIs(t, err, storage.ErrMFAChallengeNotFound)
+
+	require.NoError(t, s.DeleteMFAChallenge(ctx, "challenge"))
+	assert.ErrorIs(t, s.DeleteMFAChallenge(ctx, "challenge"), storage.ErrMFAChallengeNotFound)
+
+	purged, err := s.PurgeExpiredTokens(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+}
+
+func testNoteOwnership(t *testing.T, s app.Storage) {
+	ctx := t.Context()
+
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+
+	id, err := s.CreateNote(ctx, alice, "title", "content", []string{"b", "a"})
+	require.NoError(t, err)
+
+	note, err := s.Note(ctx, int(id), alice)
+	require.NoError(t, err)
+	assert.Equal(t, "title", note.Title)
+	assert.Equal(t, "content", note.Content)
+	assert.Equal(t, 1, note.Version)
+	assert.Equal(t, []string{"a", "b"}, note.Tags)
+	assert.NotEmpty(t, note.CreatedAt)
+
+	_, err = s.Note(ctx, int(id), bob)
+	assert.ErrorIs(t, err, storage.ErrNoteNotFound)
+
+	_, err = s.Note(ctx, int(id)+1000, alice)
+	assert.ErrorIs(t, err, storage.ErrNoteNotFound)
+
+	_, err = s.UpdateNote(ctx, int(id), bob, "stolen", "", nil, 0)
+	assert.ErrorIs(t, err, storage.ErrNoteNotFound)
+
+	title := "stolen"
+	_, err = s.PatchNote(ctx, int(id), bob, models.NotePatch{Title: &title}, 0)
+	assert.ErrorIs(t, err, storage.ErrNoteNotFound)
+
+	assert.ErrorIs(t, s.DeleteNote(ctx, int(id), bob, 0), storage.ErrNoteNotFound)
+	assert.ErrorIs(t, s.PurgeNote(ctx, int(id), bob, 0), storage.ErrNoteNotFound)
+	assert.ErrorIs(t, s.RestoreNote(ctx, int(id), bob), storage.ErrNoteNotFound)
+
+	page, err := s.Notes(ctx, bob, models.NotesQuery{Limit: 10, SortBy: "created_at", Order: "asc"})
+	require.NoError(t, err)
+	assert.Empty(t, page.Notes)
+	assert.Zero(t, page.Total)
+}
+
+func testNoteVersions(t *testing.T, s app.Storage) {
+	ctx := t.Context()
+
+	alice := createUser(t, s, "alice")
+
+	id, err := s.CreateNote(ctx, alice, "title", "content", nil)
+	require.NoError(t, err)
+
+	version, err := s.UpdateNote(ctx, int(id), alice, "new title", "content", nil, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	_, err = s.UpdateNote(ctx, int(id), alice, "stale", "content", nil, 1)
+	assert.ErrorIs(t, err, storage.ErrVersionMismatch)
+
+	content := "patched"
+	note, err := s.PatchNote(ctx, int(id), alice, models.NotePatch{Content: &content, Tags: []string{"x"}}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "new title", note.Title)
+	assert.Equal(t, "patched", note.Content)
+	assert.Equal(t, []string{"x"}, note.Tags)
+	assert.Equal(t, 3, note.Version)
+
+	assert.ErrorIs(t, s.DeleteNote(ctx, int(id), alice, 2), storage.ErrVersionMismatch)
+	assert.ErrorIs(t, s.PurgeNote(ctx, int(id), alice, 2), storage.ErrVersionMismatch)
+	assert.NoError(t, s.PurgeNote(ctx, int(id), alice, 3))
+
+	_, err = s.Note(ctx, int(id), alice)
+	assert.ErrorIs(t, err, storage.ErrNoteNotFound)
+}
+
+func testTrash(t *testing.T, s app.Storage) {
+	ctx := t.Context()
+
+	alice := createUser(t, s, "alice")
+
+	id, err := s.CreateNote(ctx, alice, "title", "content", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteNote(ctx, int(id), alice, 0))
+	assert.ErrorIs(t, s.DeleteNote(ctx, int(id), alice, 0), storage.ErrNoteNotFound)
+
+	_, err = s.Note(ctx, int(id), alice)
+	assert.ErrorIs(t, err, storage.ErrNoteNotFound)
+
+	page, err := s.Notes(ctx, alice, models.NotesQuery{Limit: 10, SortBy: "deleted_at", Order: "desc", Trashed: true})
+	require.NoError(t, err)
+	require.Len(t, page.Notes, 1)
+	assert.NotNil(t, page.Notes[0].DeletedAt)
+
+	require.NoError(t, s.RestoreNote(ctx, int(id), alice))
+	assert.ErrorIs(t, s.RestoreNote(ctx, int(id), alice), storage.ErrNoteNotFound)
+
+	_, err = s.Note(ctx, int(id), alice)
+	assert.NoError(t, err)
+
+	require.NoError(t, s.DeleteNote(ctx, int(id), alice, 0))
+
+	purged, err := s.PurgeTrash(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	assert.ErrorIs(t, s.RestoreNote(ctx, int(id), alice), storage.ErrNoteNotFound)
+}
+
+func testPagination(t *testing.T, s app.Storage) {
+	ctx := t.Context()
+
+	alice := createUser(t, s, "alice")
+
+	titles := []string{"c", "a", "e", "b", "d"}
+	for _, title := range titles {
+		_, err := s.CreateNote(ctx, alice, title, "", nil)
+		require.NoError(t, err)
+	}
+
+	for _, sortBy := range []string{"title", "created_at"} {
+		query := models.NotesQuery{Limit: 2, SortBy: sortBy, Order: "asc"}
+
+		var seen []string
+		for {
+			page, err := s.Notes(ctx, alice, query)
+			require.NoError(t, err)
+			assert.Equal(t, len(titles), page.Total)
+
+			for _, note := range page.Notes {
+				seen = append(seen, note.Title)
+			}
+
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+
+		if sortBy == "title" {
+			assert.Equal(t, []string{"a", "b", "c", "d", "e"}, seen)
+		} else {
+			assert.Equal(t, titles, seen)
+		}
+	}
+
+	_, err := s.Notes(ctx, alice, models.NotesQuery{Limit: 2, SortBy: "title", Order: "asc", Cursor: "garbage"})
+	assert.ErrorIs(t, err, storage.ErrInvalidCursor)
+}
+
+func testTags(t *testing.T, s app.Storage) {
+	ctx := t.Context()
+
+	alice := createUser(t, s, "alice")
+
+	first, err := s.CreateNote(ctx, alice, "first", "", []string{"go", "db"})
+	require.NoError(t, err)
+	_, err = s.CreateNote(ctx, alice, "second", "", []string{"go"})
+	require.NoError(t, err)
+
+	tags, err := s.Tags(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Tag{{Name: "db", Count: 1}, {Name: "go", Count: 2}}, tags)
+
+	page, err := s.Notes(ctx, alice, models.NotesQuery{Limit: 10, SortBy: "title", Order: "asc", Tags: []string{"go", "db"}, TagMode: models.TagModeAll})
+	require.NoError(t, err)
+	require.Len(t, page.Notes, 1)
+	assert.Equal(t, int(first), page.Notes[0].ID)
+
+	assert.ErrorIs(t, s.RenameTag(ctx, alice, "go", "db"), storage.ErrTagAlreadyExists)
+	assert.ErrorIs(t, s.RenameTag(ctx, alice, "rust", "zig"), storage.ErrTagNotFound)
+	require.NoError(t, s.RenameTag(ctx, alice, "go", "golang"))
+
+	assert.ErrorIs(t, s.MergeTags(ctx, alice, []string{"rust"}, "golang"), storage.ErrTagNotFound)
+	require.NoError(t, s.MergeTags(ctx, alice, []string{"db", "golang"}, "all"))
+
+	tags, err = s.Tags(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Tag{{Name: "all", Count: 2}}, tags)
+
+	// Renames and merges change the tags, not the notes, so they leave the
+	// versions and the revision history alone.
+	note, err := s.Note(ctx, int(first), alice)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"all"}, note.Tags)
+	assert.Equal(t, 1, note.Version)
+
+	revisions, err := s.NoteRevisions(ctx, int(first), alice)
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+}
+
+func testSearch(t *testing.T, s app.Storage) {
+	ctx := t.Context()
+
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+
+	search := func(userID int, q string) []int {
+		t.Helper()
+
+		results, err := s.SearchNotes(ctx, userID, models.SearchQuery{Query: q, Limit: 10})
+		require.NoError(t, err)
+
+		ids := []int{}
+		for _, result := range results {
+			ids = append(ids, result.ID)
+		}
+
+		return ids
+	}
+
+	inContent, err := s.CreateNote(ctx, alice, "Weekend", "buy groceries and milk", nil)
+	require.NoError(t, err)
+	inTitle, err := s.CreateNote(ctx, alice, "Groceries", "eggs", []string{"home"})
+	require.NoError(t, err)
+	_, err = s.CreateNote(ctx, alice, "Work", "nothing to buy", nil)
+	require.NoError(t, err)
+	_, err = s.CreateNote(ctx, bob, "Groceries", "bread", nil)
+	require.NoError(t, err)
+
+	// Title matches rank first, and only the notes of the user are searched.
+	results, err := s.SearchNotes(ctx, alice, models.SearchQuery{Query: "groceries", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, int(inTitle), results[0].ID)
+	assert.Equal(t, int(inContent), results[1].ID)
+	assert.LessOrEqual(t, results[0].Rank, results[1].Rank)
+	assert.Equal(t, "<mark>Groceries</mark>", results[0].TitleHighlight)
+	assert.Contains(t, results[1].Snippet, "<mark>groceries</mark>")
+	assert.Equal(t, []string{"home"}, results[0].Tags)
+
+	results, err = s.SearchNotes(ctx, alice, models.SearchQuery{Query: "groceries", Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, int(inContent), results[0].ID)
+
+	// Every word has to match.
+	assert.Equal(t, []int{int(inContent)}, search(alice, "groceries milk"))
+	assert.Empty(t, search(alice, "groceries bread"))
+
+	// The index follows updates, the trash and restores.
+	_, err = s.UpdateNote(ctx, int(inContent), alice, "Weekend", "buy flowers", nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{int(inTitle)}, search(alice, "groceries"))
+	assert.Equal(t, []int{int(inContent)}, search(alice, "flowers"))
+
+	require.NoError(t, s.DeleteNote(ctx, int(inContent), alice, 0))
+	assert.Empty(t, search(alice, "flowers"))
+
+	require.NoError(t, s.RestoreNote(ctx, int(inContent), alice))
+	assert.Equal(t, []int{int(inContent)}, search(alice, "flowers"))
+
+	require.NoError(t, s.PurgeNote(ctx, int(inContent), alice, 0))
+	assert.Empty(t, search(alice, "flowers"))
+}
+
+func testRevisions(t *testing.T, s app.Storage) {
+	ctx := t.Context()
+
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+
+	id, err := s.CreateNote(ctx, alice, "v1", "one", nil)
+	require.NoError(t, err)
+
+	_, err = s.UpdateNote(ctx, int(id), alice, "v2", "two", nil, 0)
+	require.NoError(t, err)
+
+	// An update that changes nothing does not add a revision.
+	_, err = s.UpdateNote(ctx, int(id), alice, "v2", "two", nil, 0)
+	require.NoError(t, err)
+
+	revisions, err := s.NoteRevisions(ctx, int(id), alice)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "v1", revisions[0].Title)
+
+	_, err = s.NoteRevisions(ctx, int(id), bob)
+	assert.ErrorIs(t, err, storage.ErrNoteNotFound)
+
+	_, err = s.NoteRevision(ctx, int(id), alice, 99)
+	assert.ErrorIs(t, err, storage.ErrRevisionNotFound)
+
+	assert.ErrorIs(t, s.RestoreRevision(ctx, int(id), bob, 1), storage.ErrNoteNotFound)
+	require.NoError(t, s.RestoreRevision(ctx, int(id), alice, 1))
+
+	note, err := s.Note(ctx, int(id), alice)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", note.Title)
+	assert.Equal(t, "one", note.Content)
+
+	revisions, err = s.NoteRevisions(ctx, int(id), alice)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 2)
+
+	pruned, err := s.PruneRevisions(ctx, 1, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pruned)
+}
+
+func testRefreshTokens(t *testing.T, s app.Storage) {
+	ctx := t.Context()
+
+	alice := int64(createUser(t, s, "alice"))
+	expiresAt := time.Now().Add(time.Hour)
+
+	require.NoError(t, s.CreateRefreshToken(ctx, alice, "family", "first", expiresAt))
+
+	userID, err := s.RotateRefreshToken(ctx, "first", "second", expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, alice, userID)
+
+	_, err = s.RotateRefreshToken(ctx, "unknown", "third", expiresAt)
+	assert.ErrorIs(t, err, storage.ErrRefreshTokenNotFound)
+
+	_, err = s.RotateRefreshToken(ctx, "first", "third", expiresAt)
+	assert.ErrorIs(t, err, storage.ErrRefreshTokenReused)
+
+	// Reuse revoked the whole family, including the token it was rotated to.
+	_, err = s.RotateRefreshToken(ctx, "second", "third", expiresAt)
+	assert.ErrorIs(t, err, storage.ErrRefreshTokenNotFound)
+
+	require.NoError(t, s.RevokeAccessToken(ctx, "jti", expiresAt))
+	require.NoError(t, s.RevokeAccessToken(ctx, "jti", expiresAt))
+
+	revoked, err := s.AccessTokenRevoked(ctx, "jti")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	purged, err := s.PurgeExpiredTokens(ctx, expiresAt.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+}
+
+func testPersonalAccessTokens(t *testing.T, s app.Storage) {
+	ctx := t.Context()
+
+	alice := int64(createUser(t, s, "alice"))
+	bob := int64(createUser(t, s, "bob"))
+	expiresAt := time.Now().Add(time.Hour)
+
+	scopes := []string{models.ScopeNotesRead}
+	token, err := s.CreatePersonalAccessToken(ctx, alice, "ci", scopes, "hash", expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, scopes, token.Scopes)
+	assert.NotEmpty(t, token.ExpiresAt)
+
+	_, err = s.CreatePersonalAccessToken(ctx, alice, "ci", scopes, "other", expiresAt)
+	assert.ErrorIs(t, err, storage.ErrTokenAlreadyExists)
+
+	authenticated, err := s.AuthenticatePersonalAccessToken(ctx, "hash")
+	require.NoError(t, err)
+	assert.Equal(t, token.ID, authenticated.ID)
+	assert.Equal(t, alice, authenticated.UserID)
+	assert.NotNil(t, authenticated.LastUsedAt)
+
+	_, err = s.AuthenticatePersonalAccessToken(ctx, "unknown")
+	assert.ErrorIs(t, err, storage.ErrTokenNotFound)
+
+	tokens, err := s.PersonalAccessTokens(ctx, alice)
+	require.NoError(t, err)
+	assert.Len(t, tokens, 1)
+
+	assert.ErrorIs(t, s.DeletePersonalAccessToken(ctx, bob, token.ID), storage.ErrTokenNotFound)
+	require.NoError(t, s.DeletePersonalAccessToken(ctx, alice, token.ID))
+
+	_, err = s.AuthenticatePersonalAccessToken(ctx, "hash")
+	assert.ErrorIs(t, err, storage.ErrTokenNotFound)
+}
+
+func testConcurrentWrites(t *testing.T, s app.Storage) {
+	ctx := t.Context()
+
+	alice := createUser(t, s, "alice")
+
+	id, err := s.CreateNote(ctx, alice, "shared", "", nil)
+	require.NoError(t, err)
+
+	const writers = 8
+
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := s.CreateNote(ctx, alice, "note", "", []string{"tag"})
+			assert.NoError(t, err)
+
+			_, err = s.UpdateNote(ctx, int(id), alice, "shared", fmt.Sprint(i), nil, 0)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	page, err := s.Notes(ctx, alice, models.NotesQuery{Limit: models.MaxNotesLimit, SortBy: "created_at", Order: "asc"})
+	require.NoError(t, err)
+	assert.Equal(t, writers+1, page.Total)
+
+	note, err := s.Note(ctx, int(id), alice)
+	require.NoError(t, err)
+	assert.Equal(t, writers+1, note.Version)
+}
+
+func testContextErrors(t *testing.T, s app.Storage) {
+	alice := createUser(t, s, "alice")
+
+	id, err := s.CreateNote(t.Context(), alice, "note", "", []string{"tag"})
+	require.NoError(t, err)
+
+	canceled, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	expired, cancel := context.WithDeadline(t.Context(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	for _, tt := range []struct {
+		ctx  context.Context
+		want error
+	}{
+		{canceled, context.Canceled},
+		{expired, context.DeadlineExceeded},
+	} {
+		_, err := s.Note(tt.ctx, int(id), alice)
+		assert.ErrorIs(t, err, tt.want)
+
+		_, err = s.Notes(tt.ctx, alice, models.NotesQuery{Limit: 10, SortBy: "created_at", Order: "asc"})
+		assert.ErrorIs(t, err, tt.want)
+
+		_, err = s.CreateNote(tt.ctx, alice, "note", "", nil)
+		assert.ErrorIs(t, err, tt.want)
+
+		_, err = s.UpdateNote(tt.ctx, int(id), alice, "note", "changed", nil, 0)
+		assert.ErrorIs(t, err, tt.want)
+
+		assert.ErrorIs(t, s.RenameTag(tt.ctx, alice, "tag", "renamed"), tt.want)
+	}
+
+	note, err := s.Note(t.Context(), int(id), alice)
+	require.NoError(t, err)
+	assert.Equal(t, 1, note.Version)
+}
@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ReturnedRows records on the span of the storage operation running under
+// ctx how many rows it read.
+func ReturnedRows(ctx context.Context, n int) {
+	trace.SpanFromContext(ctx).SetAttributes(semconv.DBResponseReturnedRows(n))
+}
+
+// AffectedRows records on the span of the storage operation running under
+// ctx how many rows it changed.
+func AffectedRows(ctx context.Context, n int64) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("db.response.affected_rows", n))
+}
@@ -0,0 +1,125 @@
+// Package totp implements the time-based one-time passwords of RFC 6238
+// with the parameters every authenticator app supports: HMAC-SHA1, six
+// digits and a 30 second period.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Digits = 6
+	Period = 30 * time.Second
+
+	// Skew is how many periods a code is accepted before and after its
+	// own, to allow for clocks that are off and codes typed slowly.
+	Skew = 1
+
+	secretSize = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret returns a random 160-bit secret, base32 encoded without
+// padding as authenticator apps expect it.
+func NewSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(b), nil
+}
+
+// URI returns the otpauth URI of secret for the account of issuer, which
+// authenticator apps import, usually from a QR code.
+func URI(issuer, account, secret string) string {
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", strconv.Itoa(Digits))
+	q.Set("period", strconv.Itoa(int(Period/time.Second)))
+
+	// Some apps show a + in the issuer as is; literal ones are escaped by
+	// Encode, so the rest are spaces.
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: strings.ReplaceAll(q.Encode(), "+", "%20"),
+	}
+
+	return u.String()
+}
+
+// Step returns the time step t falls in.
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// Code returns the code of secret for the time step step.
+func Code(secret string, step int64) (string, error) {
+	key, err := decode(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return hotp(key, uint64(step), Digits), nil
+}
+
+// Validate reports whether code is the code of secret at now, give or take
+// Skew periods, and returns the time step it belongs to. Callers should
+// remember the step and refuse codes of it and earlier ones, so that an
+// observed code cannot be replayed.
+func Validate(secret, code string, now time.Time) (int64, bool) {
+	key, err := decode(secret)
+	if err != nil || len(key) == 0 || len(code) != Digits {
+		return 0, false
+	}
+
+	step := Step(now)
+	for i := int64(-Skew); i <= Skew; i++ {
+		if subtle.ConstantTimeCompare([]byte(hotp(key, uint64(step+i), Digits)), []byte(code)) == 1 {
+			return step + i, true
+		}
+	}
+
+	return 0, false
+}
+
+func decode(secret string) ([]byte, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret: %w", err)
+	}
+
+	return key, nil
+}
+
+// hotp is the HMAC-based one-time password of RFC 4226.
+func hotp(key []byte, counter uint64, digits int) string {
+	mac := hmac.New(sha1.New, key)
+	binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range digits {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
@@ -0,0 +1,91 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The SHA1 test vectors of RFC 6238, appendix B, which have eight digits.
+func TestRFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+
+	for _, tt := range []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	} {
+		step := Step(time.Unix(tt.unix, 0))
+		assert.Equal(t, tt.code, hotp(key, uint64(step), 8), tt.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1234567890, 0)
+
+	code, err := Code(secret, Step(now))
+	require.NoError(t, err)
+	assert.Equal(t, "005924", code)
+
+	step, ok := Validate(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	// The code of the previous period is still accepted, and reports it.
+	step, ok = Validate(secret, code, now.Add(Period))
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	_, ok = Validate(secret, code, now.Add(2*Period))
+	assert.False(t, ok)
+
+	for _, code := range []string{"", "00592", "0059240", "123456"} {
+		_, ok := Validate(secret, code, now)
+		assert.False(t, ok, code)
+	}
+
+	for _, secret := range []string{"not base32!", ""} {
+		_, ok = Validate(secret, code, now)
+		assert.False(t, ok, secret)
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	secret, err := NewSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	other, err := NewSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+
+	code, err := Code(secret, Step(time.Now()))
+	require.NoError(t, err)
+
+	_, ok := Validate(secret, code, time.Now())
+	assert.True(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("Notes API", "alice@example", "JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Notes API:alice@example", u.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", u.Query().Get("secret"))
+	assert.Equal(t, "Notes API", u.Query().Get("issuer"))
+	assert.Equal(t, "6", u.Query().Get("digits"))
+	assert.Equal(t, "30", u.Query().Get("period"))
+}
@@ -0,0 +1,156 @@
+//go:build sqlite_fts5
+
+package tracing_test
+
+import (
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	"notes-api/internal/config"
+	"notes-api/internal/models"
+	"notes-api/internal/storage/sqlite"
+	"notes-api/internal/tracing"
+)
+
+// collector stands in for an OpenTelemetry collector receiving OTLP over
+// HTTP.
+type collector struct {
+	mu    sync.Mutex
+	spans []*tracepb.Span
+	// service is the service.name of the last export.
+	service string
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req collectortrace.ExportTraceServiceRequest
+	if err := proto.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	for _, rs := range req.ResourceSpans {
+		for _, attr := range rs.Resource.Attributes {
+			if attr.Key == "service.name" {
+				c.service = attr.Value.GetStringValue()
+			}
+		}
+
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+	c.mu.Unlock()
+
+	resp, _ := proto.Marshal(&collectortrace.ExportTraceServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Write(resp)
+}
+
+func (c *collector) span(t *testing.T, name string) *tracepb.Span {
+	t.Helper()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, span := range c.spans {
+		if span.Name == name {
+			return span
+		}
+	}
+
+	t.Fatalf("no span named %q among %d exported", name, len(c.spans))
+	return nil
+}
+
+func attribute(span *tracepb.Span, key string) any {
+	for _, attr := range span.Attributes {
+		if attr.Key == key {
+			switch v := attr.Value.Value.(type) {
+			case *commonpb.AnyValue_StringValue:
+				return v.StringValue
+			case *commonpb.AnyValue_IntValue:
+				return v.IntValue
+			}
+		}
+	}
+
+	return nil
+}
+
+func TestOTLPExport(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	shutdown, err := tracing.Setup(t.Context(), config.Tracing{
+		Exporter:    config.ExporterOTLP,
+		Endpoint:    srv.URL,
+		ServiceName: "notes-api-test",
+		SampleRatio: 1,
+	})
+	require.NoError(t, err)
+
+	storage, err := sqlite.New(filepath.Join(t.TempDir(), "notes.db"), 0)
+	require.NoError(t, err)
+	defer storage.Close()
+
+	userID, err := storage.CreateUser(t.Context(), "alice", "hash", "")
+	require.NoError(t, err)
+
+	_, err = storage.CreateNote(t.Context(), int(userID), "title", "content", nil)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(tracing.Middleware)
+	r.Get("/notes", func(w http.ResponseWriter, r *http.Request) {
+		_, err := storage.Notes(r.Context(), int(userID), models.NotesQuery{Limit: 10, SortBy: "created_at", Order: "asc"})
+		assert.NoError(t, err)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/notes", nil)
+	req.Header.Set("traceparent", traceparent)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.NoError(t, shutdown(t.Context()))
+
+	assert.Equal(t, "notes-api-test", c.service)
+
+	server := c.span(t, "GET /notes")
+	assert.Equal(t, traceID, hex.EncodeToString(server.TraceId))
+	assert.Equal(t, parentSpanID, hex.EncodeToString(server.ParentSpanId))
+	assert.Equal(t, tracepb.Span_SPAN_KIND_SERVER, server.Kind)
+	assert.Equal(t, "/notes", attribute(server, "http.route"))
+	assert.Equal(t, int64(http.StatusOK), attribute(server, "http.response.status_code"))
+
+	query := c.span(t, "sqlite.Notes")
+	assert.Equal(t, traceID, hex.EncodeToString(query.TraceId))
+	assert.Equal(t, server.SpanId, query.ParentSpanId)
+	assert.Equal(t, tracepb.Span_SPAN_KIND_CLIENT, query.Kind)
+	assert.Equal(t, "sqlite", attribute(query, "db.system.name"))
+	assert.Equal(t, int64(1), attribute(query, "db.response.returned_rows"))
+}
@@ -3,3 +3,12 @@ package utils
 type ContextKey string
 
 const UserIDKey ContextKey = "userID"
+
+// TokenIDKey and TokenExpiresAtKey hold the jti and expiry of the access
+// token a request was authenticated with.
+const TokenIDKey ContextKey = "tokenID"
+const TokenExpiresAtKey ContextKey = "tokenExpiresAt"
+
+// ScopesKey holds the scopes of the personal access token a request was
+// authenticated with. It is unset for access tokens, which carry every scope.
+const ScopesKey ContextKey = "scopes"
@@ -0,0 +1,14 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashToken is what refresh and personal access tokens are stored as, so a
+// leaked database does not hand out live credentials. The tokens are random,
+// so a plain digest is enough.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
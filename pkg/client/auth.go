@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type tokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// signInResponse is either a token pair or, with MFARequired, a challenge
+// that expires in ExpiresIn.
+type signInResponse struct {
+	tokenResponse
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+type mfaVerifyRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// SignUp creates an account. It does not sign in.
+func (c *Client) SignUp(ctx context.Context, username, password string) (*User, error) {
+	var user User
+	_, err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/signup",
+		body:   credentials{Username: username, Password: password},
+	}, &user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// SignIn starts a session. The client authenticates every later call with
+// it and keeps it alive with its refresh token. For users with two-factor
+// sign-in, it returns an *MFARequiredError, which matches ErrMFARequired;
+// VerifySecondFactor then starts the session.
+func (c *Client) SignIn(ctx context.Context, username, password string) (Tokens, error) {
+	var resp signInResponse
+	_, err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/signin",
+		body:   credentials{Username: username, Password: password},
+	}, &resp)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if resp.MFARequired {
+		return Tokens{}, &MFARequiredError{
+			Token:  resp.MFAToken,
+			Expiry: time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second),
+		}
+	}
+
+	c.setTokens(resp.tokenResponse)
+
+	return c.Tokens(), nil
+}
+
+// VerifySecondFactor finishes a sign-in that SignIn answered with an
+// *MFARequiredError, given its token and a code from the authenticator app
+// or a recovery code.
+func (c *Client) VerifySecondFactor(ctx context.Context, mfaToken, code string) (Tokens, error) {
+	var resp tokenResponse
+	_, err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/2fa/verify",
+		body:   mfaVerifyRequest{MFAToken: mfaToken, Code: code},
+	}, &resp)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	c.setTokens(resp)
+
+	return c.Tokens(), nil
+}
@@ -0,0 +1,371 @@
+// Package client is a typed client for the notes API.
+//
+// A Client signs in once and then keeps its access token fresh on its own:
+// it renews it with the refresh token shortly before it expires, and once
+// more if the server rejects it anyway. Reads, replacements and deletions
+// are retried with exponential backoff when the server is unavailable or
+// rate limits them; creations are not, since they could land twice. Errors
+// returned by the API are *Error values, which match the sentinel errors of
+// this package with errors.Is.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultBaseDelay  = 200 * time.Millisecond
+	maxDelay          = 10 * time.Second
+
+	// refreshSkew is how long before its expiry an access token is renewed,
+	// so that it does not expire on its way to the server.
+	refreshSkew = 30 * time.Second
+)
+
+// ErrNotSignedIn is returned by calls that need a token when the client has
+// none.
+var ErrNotSignedIn = errors.New("client: not signed in")
+
+// Tokens are the credentials of a session. Expiry is when AccessToken stops
+// being accepted; it is zero for tokens that do not expire or whose expiry is
+// unknown.
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	Expiry       time.Time
+}
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	maxRetries int
+	baseDelay  time.Duration
+	onTokens   func(Tokens)
+
+	// mu guards tokens and serializes refreshes: a refresh token can only be
+	// used once, and using it twice revokes the session.
+	mu     sync.Mutex
+	tokens Tokens
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sends requests with hc instead of http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithTokens starts the client with the tokens of an existing session, such
+// as ones saved by an OnTokens callback.
+func WithTokens(tokens Tokens) Option {
+	return func(c *Client) { c.tokens = tokens }
+}
+
+// WithPersonalAccessToken authenticates with a personal access token, which
+// is never refreshed.
+func WithPersonalAccessToken(token string) Option {
+	return func(c *Client) { c.tokens = Tokens{AccessToken: token} }
+}
+
+// WithRetry sets how many times an idempotent call is retried and the delay
+// before the first retry, which doubles with every attempt. Zero retries
+// disables retrying.
+func WithRetry(maxRetries int, baseDelay time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.baseDelay = baseDelay
+	}
+}
+
+// OnTokens registers a function called with the new tokens whenever the
+// client signs in or refreshes its access token, to persist them.
+func OnTokens(fn func(Tokens)) Option {
+	return func(c *Client) { c.onTokens = fn }
+}
+
+// New creates a client for the API at baseURL, such as
+// "https://notes.example.com".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("client: base URL %q must be absolute", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		maxRetries: defaultMaxRetries,
+		baseDelay:  defaultBaseDelay,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// Tokens returns the current credentials of the client.
+func (c *Client) Tokens() Tokens {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.tokens
+}
+
+// request describes a call to the API.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   any
+	// auth sends the access token with the request.
+	auth bool
+}
+
+// do sends req and decodes a successful response body into out, if given.
+// It returns the response, whose body is already closed, for its headers.
+func (c *Client) do(ctx context.Context, req request, out any) (*http.Response, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return nil, fmt.Errorf("client: encode request body: %w", err)
+		}
+	}
+
+	var token string
+	if req.auth {
+		var err error
+		if token, err = c.accessToken(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, req, body, token)
+		if err != nil {
+			if ctx.Err() != nil || !c.retryable(req.method, attempt) {
+				return nil, err
+			}
+			if err := c.wait(ctx, attempt, ""); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		switch {
+		case retryableStatus(resp.StatusCode) && c.retryable(req.method, attempt):
+			drain(resp)
+			if err := c.wait(ctx, attempt, resp.Header.Get("Retry-After")); err != nil {
+				return nil, err
+			}
+			continue
+
+		// The server rejected the token before doing anything, so any call
+		// can be sent again with a fresh one.
+		case resp.StatusCode == http.StatusUnauthorized && req.auth && !refreshed && c.canRefresh():
+			drain(resp)
+			if err := c.refresh(ctx, token); err != nil {
+				return nil, err
+			}
+			refreshed = true
+			if token, err = c.accessToken(ctx); err != nil {
+				return nil, err
+			}
+			attempt--
+			continue
+		}
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			return resp, newError(resp)
+		}
+
+		if out != nil && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotModified {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				return resp, fmt.Errorf("client: decode response: %w", err)
+			}
+		}
+
+		return resp, nil
+	}
+}
+
+func (c *Client) send(ctx context.Context, req request, body []byte, token string) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path += req.path
+	u.RawQuery = req.query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), reader)
+	if err != nil {
+		return nil, fmt.Errorf("client: %w", err)
+	}
+
+	for key, values := range req.header {
+		httpReq.Header[key] = values
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return c.httpClient.Do(httpReq)
+}
+
+// retryable reports whether a call that failed on attempt may be sent
+// again. Only calls that have the same effect however often they land are.
+func (c *Client) retryable(method string, attempt int) bool {
+	if attempt >= c.maxRetries {
+		return false
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// wait sleeps before retry attempt+1: as long as retryAfter asks, if the
+// server said, and otherwise an exponentially growing, jittered delay.
+func (c *Client) wait(ctx context.Context, attempt int, retryAfter string) error {
+	delay := c.baseDelay << attempt
+	if delay > maxDelay || delay <= 0 {
+		delay = maxDelay
+	}
+	delay = delay/2 + rand.N(delay/2+1)
+
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		delay = min(time.Duration(seconds)*time.Second, maxDelay)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// drain reads what is left of a response that is discarded, so that its
+// connection can be reused.
+func drain(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
+
+// accessToken returns the token to authenticate with, refreshing it first
+// when it is about to expire.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	tokens := c.tokens
+	c.mu.Unlock()
+
+	if tokens.AccessToken == "" {
+		return "", ErrNotSignedIn
+	}
+
+	if tokens.RefreshToken != "" && !tokens.Expiry.IsZero() && time.Until(tokens.Expiry) < refreshSkew {
+		if err := c.refresh(ctx, tokens.AccessToken); err != nil {
+			return "", err
+		}
+
+		c.mu.Lock()
+		tokens = c.tokens
+		c.mu.Unlock()
+	}
+
+	return tokens.AccessToken, nil
+}
+
+func (c *Client) canRefresh() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.tokens.RefreshToken != ""
+}
+
+// refresh trades the refresh token for new tokens, unless another call
+// already replaced stale, the access token that prompted it.
+func (c *Client) refresh(ctx context.Context, stale string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tokens.AccessToken != stale {
+		return nil
+	}
+
+	var resp tokenResponse
+	_, err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/refresh",
+		body:   map[string]string{"refresh_token": c.tokens.RefreshToken},
+	}, &resp)
+	if err != nil {
+		return fmt.Errorf("client: refresh access token: %w", err)
+	}
+
+	c.setTokensLocked(resp)
+
+	return nil
+}
+
+func (c *Client) setTokens(resp tokenResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setTokensLocked(resp)
+}
+
+func (c *Client) setTokensLocked(resp tokenResponse) {
+	c.tokens = Tokens{
+		AccessToken:  resp.Token,
+		RefreshToken: resp.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second),
+	}
+
+	if c.onTokens != nil {
+		c.onTokens(c.tokens)
+	}
+}
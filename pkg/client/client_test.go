@@ -0,0 +1,301 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"notes-api/internal/app"
+	"notes-api/internal/config"
+	"notes-api/internal/jwtkeys"
+	"notes-api/internal/storage/memory"
+	"notes-api/internal/totp"
+	"notes-api/pkg/client"
+)
+
+// newServer serves the whole API from memory, wrapped in wrap if given.
+func newServer(t *testing.T, wrap func(http.Handler) http.Handler) *httptest.Server {
+	t.Helper()
+
+	cfg := &config.Config{
+		Env: config.EnvDev,
+		Auth: config.Auth{
+			AccessTokenTTL:  time.Hour,
+			RefreshTokenTTL: 24 * time.Hour,
+			MFAChallengeTTL: time.Minute,
+		},
+		JWT: config.JWT{Issuer: "notes-api", Audience: "notes-api"},
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	keys, err := jwtkeys.New(cfg.JWT, []byte("secret"))
+	require.NoError(t, err)
+
+	var handler http.Handler = app.NewApp(cfg, memory.New(), log, keys).AddRoutes()
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// signedIn returns a client of a new account on srv.
+func signedIn(t *testing.T, srv *httptest.Server, opts ...client.Option) *client.Client {
+	t.Helper()
+
+	c, err := client.New(srv.URL, opts...)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = c.SignUp(ctx, "alice", "correct horse")
+	require.NoError(t, err)
+	_, err = c.SignIn(ctx, "alice", "correct horse")
+	require.NoError(t, err)
+
+	return c
+}
+
+// post sends body to path of srv as the user of c and decodes the response
+// into out.
+func post(t *testing.T, srv *httptest.Server, c *client.Client, path, body string, out any) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.Tokens().AccessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+}
+
+func TestSecondFactor(t *testing.T) {
+	ctx := context.Background()
+	srv := newServer(t, nil)
+	c := signedIn(t, srv)
+
+	var setup struct {
+		Secret string `json:"secret"`
+	}
+	post(t, srv, c, "/auth/2fa/setup", "", &setup)
+
+	code, err := totp.Code(setup.Secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	var confirm struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	post(t, srv, c, "/auth/2fa/confirm", `{"code":"`+code+`"}`, &confirm)
+	require.NotEmpty(t, confirm.RecoveryCodes)
+
+	_, err = c.SignIn(ctx, "alice", "correct horse")
+	require.ErrorIs(t, err, client.ErrMFARequired)
+
+	var mfa *client.MFARequiredError
+	require.ErrorAs(t, err, &mfa)
+	assert.NotEmpty(t, mfa.Token)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), mfa.Expiry, 5*time.Second)
+
+	// The code was spent confirming the setup.
+	_, err = c.VerifySecondFactor(ctx, mfa.Token, code)
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+
+	tokens, err := c.VerifySecondFactor(ctx, mfa.Token, confirm.RecoveryCodes[0])
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+
+	_, err = c.ListNotes(ctx, client.ListNotesOptions{})
+	assert.NoError(t, err)
+
+	// The challenge was spent by the sign-in.
+	_, err = c.VerifySecondFactor(ctx, mfa.Token, confirm.RecoveryCodes[1])
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+}
+
+func TestNotesLifecycle(t *testing.T) {
+	ctx := context.Background()
+	c := signedIn(t, newServer(t, nil))
+
+	created, err := c.CreateNote(ctx, client.NoteInput{Title: "Groceries", Content: "milk", Tags: []string{"home"}})
+	require.NoError(t, err)
+	assert.NotZero(t, created.ID)
+	assert.Equal(t, 1, created.Version)
+
+	note, err := c.GetNote(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Groceries", note.Title)
+	assert.Equal(t, []string{"home"}, note.Tags)
+
+	version, err := c.UpdateNote(ctx, note.ID, client.NoteInput{Title: "Groceries", Content: "milk, eggs", Version: note.Version})
+	require.NoError(t, err)
+	assert.Equal(t, note.Version+1, version)
+
+	_, err = c.UpdateNote(ctx, note.ID, client.NoteInput{Title: "Stale", Version: note.Version})
+	assert.ErrorIs(t, err, client.ErrPreconditionFailed)
+
+	require.NoError(t, c.DeleteNote(ctx, note.ID, &client.DeleteNoteOptions{Permanent: true, Version: version}))
+
+	_, err = c.GetNote(ctx, note.ID)
+	assert.ErrorIs(t, err, client.ErrNotFound)
+
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "not_found", apiErr.Code)
+}
+
+func TestNotesIterator(t *testing.T) {
+	ctx := context.Background()
+	c := signedIn(t, newServer(t, nil))
+
+	for i := range 5 {
+		_, err := c.CreateNote(ctx, client.NoteInput{Title: fmt.Sprintf("Note %d", i)})
+		require.NoError(t, err)
+	}
+
+	var titles []string
+	for note, err := range c.Notes(ctx, client.ListNotesOptions{Limit: 2, Sort: "title", Order: "asc"}) {
+		require.NoError(t, err)
+		titles = append(titles, note.Title)
+	}
+	assert.Equal(t, []string{"Note 0", "Note 1", "Note 2", "Note 3", "Note 4"}, titles)
+
+	page, err := c.ListNotes(ctx, client.ListNotesOptions{Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, page.Notes, 2)
+	assert.Equal(t, 5, page.Total)
+	assert.NotEmpty(t, page.NextCursor)
+}
+
+func TestValidationError(t *testing.T) {
+	c := signedIn(t, newServer(t, nil))
+
+	_, err := c.ListNotes(context.Background(), client.ListNotesOptions{Limit: 500})
+	assert.ErrorIs(t, err, client.ErrInvalidRequest)
+
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Len(t, apiErr.Fields, 1)
+	assert.Equal(t, "limit", apiErr.Fields[0].Field)
+}
+
+func TestRefreshOnUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	srv := newServer(t, nil)
+	session := signedIn(t, srv).Tokens()
+
+	var saved []client.Tokens
+	c, err := client.New(srv.URL,
+		client.WithTokens(client.Tokens{AccessToken: "revoked", RefreshToken: session.RefreshToken}),
+		client.OnTokens(func(tokens client.Tokens) { saved = append(saved, tokens) }),
+	)
+	require.NoError(t, err)
+
+	_, err = c.ListNotes(ctx, client.ListNotesOptions{})
+	require.NoError(t, err)
+
+	require.Len(t, saved, 1)
+	assert.Equal(t, saved[0], c.Tokens())
+	assert.NotEqual(t, session.RefreshToken, saved[0].RefreshToken, "refresh tokens rotate")
+}
+
+func TestRefreshBeforeExpiry(t *testing.T) {
+	srv := newServer(t, nil)
+	session := signedIn(t, srv).Tokens()
+
+	session.Expiry = time.Now()
+	c, err := client.New(srv.URL, client.WithTokens(session))
+	require.NoError(t, err)
+
+	_, err = c.ListNotes(context.Background(), client.ListNotesOptions{})
+	require.NoError(t, err)
+	assert.NotEqual(t, session.AccessToken, c.Tokens().AccessToken)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), c.Tokens().Expiry, time.Minute)
+}
+
+func TestNotSignedIn(t *testing.T) {
+	c, err := client.New(newServer(t, nil).URL)
+	require.NoError(t, err)
+
+	_, err = c.GetNote(context.Background(), 1)
+	assert.ErrorIs(t, err, client.ErrNotSignedIn)
+}
+
+// flaky fails the first n requests for notes with 503.
+func flaky(n int32, calls *atomic.Int32) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/notes" && calls.Add(1) <= n {
+				http.Error(w, "try again", http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("idempotent calls are retried", func(t *testing.T) {
+		var calls atomic.Int32
+		c := signedIn(t, newServer(t, flaky(2, &calls)), client.WithRetry(3, time.Millisecond))
+
+		_, err := c.ListNotes(ctx, client.ListNotesOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("retries run out", func(t *testing.T) {
+		var calls atomic.Int32
+		c := signedIn(t, newServer(t, flaky(10, &calls)), client.WithRetry(2, time.Millisecond))
+
+		_, err := c.ListNotes(ctx, client.ListNotesOptions{})
+		assert.ErrorIs(t, err, client.ErrServer)
+		assert.Equal(t, int32(3), calls.Load())
+
+		var apiErr *client.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "try again", apiErr.Detail)
+	})
+
+	t.Run("creations are not retried", func(t *testing.T) {
+		var calls atomic.Int32
+		c := signedIn(t, newServer(t, flaky(1, &calls)), client.WithRetry(3, time.Millisecond))
+
+		_, err := c.CreateNote(ctx, client.NoteInput{Title: "Once"})
+		assert.ErrorIs(t, err, client.ErrServer)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("context cancellation stops retrying", func(t *testing.T) {
+		var calls atomic.Int32
+		c := signedIn(t, newServer(t, flaky(10, &calls)), client.WithRetry(5, time.Hour))
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		_, err := c.ListNotes(ctx, client.ListNotesOptions{})
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Sentinel errors an *Error matches with errors.Is, by status.
+var (
+	ErrInvalidRequest     = errors.New("invalid request")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("forbidden")
+	ErrNotFound           = errors.New("not found")
+	ErrConflict           = errors.New("conflict")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrRateLimited        = errors.New("rate limited")
+	ErrServer             = errors.New("server error")
+)
+
+// ErrMFARequired matches the *MFARequiredError of a sign-in that needs a
+// second factor.
+var ErrMFARequired = errors.New("second factor required")
+
+// MFARequiredError is a sign-in that got past the password and waits for a
+// second factor. Pass Token to VerifySecondFactor before Expiry.
+type MFARequiredError struct {
+	Token  string
+	Expiry time.Time
+}
+
+func (e *MFARequiredError) Error() string {
+	return "notes api: second factor required"
+}
+
+func (e *MFARequiredError) Is(target error) bool {
+	return target == ErrMFARequired
+}
+
+// FieldError is what the API found wrong with one field of a request.
+type FieldError struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+// Error is an error response of the API. Code identifies the kind of
+// problem and is stable, unlike Title and Detail, which are meant for
+// people.
+type Error struct {
+	StatusCode int          `json:"status"`
+	Type       string       `json:"type"`
+	Code       string       `json:"code"`
+	Title      string       `json:"title"`
+	Detail     string       `json:"detail"`
+	Instance   string       `json:"instance"`
+	Fields     []FieldError `json:"errors"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("notes api: %d", e.StatusCode)
+	if e.Code != "" {
+		msg += " " + e.Code
+	}
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+
+	for _, f := range e.Fields {
+		msg += fmt.Sprintf("; %s: %s", f.Field, f.Detail)
+	}
+
+	return msg
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrInvalidRequest:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrPreconditionFailed:
+		return e.StatusCode == http.StatusPreconditionFailed
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	default:
+		return false
+	}
+}
+
+// newError reads the problem details of an error response. Responses that
+// do not carry any, such as those of a proxy in front of the API, get the
+// status text as their title and the start of the body as their detail.
+func newError(resp *http.Response) error {
+	e := &Error{}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	media, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if media == "application/problem+json" || media == "application/json" {
+		json.Unmarshal(body, e)
+	}
+
+	e.StatusCode = resp.StatusCode
+	if e.Title == "" {
+		e.Title = http.StatusText(resp.StatusCode)
+	}
+	if e.Code == "" && e.Detail == "" {
+		e.Detail = strings.TrimSpace(string(body[:min(len(body), 512)]))
+	}
+
+	return e
+}
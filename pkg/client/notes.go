@@ -0,0 +1,226 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Note is a note as the API returns it. Timestamps are UTC, formatted as
+// "2006-01-02 15:04:05".
+type Note struct {
+	ID        int      `json:"id"`
+	UserID    int      `json:"user_id"`
+	Title     string   `json:"title"`
+	Content   string   `json:"content"`
+	Tags      []string `json:"tags"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+	DeletedAt string   `json:"deleted_at,omitempty"`
+	// Version grows with every change to the note. Writes given it fail
+	// with ErrPreconditionFailed if the note has changed since.
+	Version int `json:"version"`
+}
+
+// NoteInput is the content of a note to create or replace it with. A
+// non-zero Version makes an update conditional on the note still being at
+// that version.
+type NoteInput struct {
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags"`
+	Version int      `json:"-"`
+}
+
+type NotesPage struct {
+	Notes []Note `json:"notes"`
+	// NextCursor fetches the next page when passed as the Cursor of
+	// ListNotesOptions. It is empty on the last page.
+	NextCursor string `json:"next_cursor"`
+	Total      int    `json:"total"`
+}
+
+// ListNotesOptions filters and sorts notes. Zero fields leave the choice to
+// the server, which returns 20 notes at a time, newest first.
+type ListNotesOptions struct {
+	Limit  int
+	Cursor string
+	// Sort is one of created_at, updated_at and title.
+	Sort string
+	// Order is asc or desc.
+	Order string
+	// Tags only lists notes with all of them, or any of them if TagMode is
+	// "any".
+	Tags          []string
+	TagMode       string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+}
+
+func (o ListNotesOptions) values() url.Values {
+	v := url.Values{}
+
+	if o.Limit != 0 {
+		v.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Cursor != "" {
+		v.Set("cursor", o.Cursor)
+	}
+	if o.Sort != "" {
+		v.Set("sort", o.Sort)
+	}
+	if o.Order != "" {
+		v.Set("order", o.Order)
+	}
+	for _, tag := range o.Tags {
+		v.Add("tag", tag)
+	}
+	if o.TagMode != "" {
+		v.Set("tag_mode", o.TagMode)
+	}
+
+	for name, t := range map[string]time.Time{
+		"created_after":  o.CreatedAfter,
+		"created_before": o.CreatedBefore,
+		"updated_after":  o.UpdatedAfter,
+		"updated_before": o.UpdatedBefore,
+	} {
+		if !t.IsZero() {
+			v.Set(name, t.Format(time.RFC3339))
+		}
+	}
+
+	return v
+}
+
+// ListNotes returns one page of notes.
+func (c *Client) ListNotes(ctx context.Context, opts ListNotesOptions) (*NotesPage, error) {
+	var page NotesPage
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/notes", query: opts.values(), auth: true}, &page)
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// Notes iterates over every note matching opts, fetching pages as it goes.
+// It stops at the first error, which it yields with a zero Note.
+func (c *Client) Notes(ctx context.Context, opts ListNotesOptions) iter.Seq2[Note, error] {
+	return func(yield func(Note, error) bool) {
+		for {
+			page, err := c.ListNotes(ctx, opts)
+			if err != nil {
+				yield(Note{}, err)
+				return
+			}
+
+			for _, note := range page.Notes {
+				if !yield(note, nil) {
+					return
+				}
+			}
+
+			if page.NextCursor == "" {
+				return
+			}
+			opts.Cursor = page.NextCursor
+		}
+	}
+}
+
+// GetNote returns the note with id.
+func (c *Client) GetNote(ctx context.Context, id int) (*Note, error) {
+	var note Note
+	_, err := c.do(ctx, request{method: http.MethodGet, path: notePath(id), auth: true}, &note)
+	if err != nil {
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+// CreateNote creates a note. Of the returned note, only the fields given in
+// note, ID and Version are set.
+func (c *Client) CreateNote(ctx context.Context, note NoteInput) (*Note, error) {
+	var created Note
+	resp, err := c.do(ctx, request{method: http.MethodPost, path: "/notes", body: note, auth: true}, &created)
+	if err != nil {
+		return nil, err
+	}
+
+	created.Version = etagVersion(resp)
+
+	return &created, nil
+}
+
+// UpdateNote replaces the title, content and tags of the note with id and
+// returns its new version.
+func (c *Client) UpdateNote(ctx context.Context, id int, note NoteInput) (int, error) {
+	resp, err := c.do(ctx, request{
+		method: http.MethodPut,
+		path:   notePath(id),
+		header: ifMatch(note.Version),
+		body:   note,
+		auth:   true,
+	}, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return etagVersion(resp), nil
+}
+
+// DeleteNoteOptions makes a deletion permanent rather than a move to the
+// trash, or conditional on the note being at Version.
+type DeleteNoteOptions struct {
+	Permanent bool
+	Version   int
+}
+
+// DeleteNote moves the note with id to the trash. opts may be nil.
+func (c *Client) DeleteNote(ctx context.Context, id int, opts *DeleteNoteOptions) error {
+	if opts == nil {
+		opts = &DeleteNoteOptions{}
+	}
+
+	query := url.Values{}
+	if opts.Permanent {
+		query.Set("permanent", "true")
+	}
+
+	_, err := c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   notePath(id),
+		query:  query,
+		header: ifMatch(opts.Version),
+		auth:   true,
+	}, nil)
+
+	return err
+}
+
+func notePath(id int) string {
+	return "/notes/" + strconv.Itoa(id)
+}
+
+func ifMatch(version int) http.Header {
+	if version == 0 {
+		return nil
+	}
+
+	return http.Header{"If-Match": {`"` + strconv.Itoa(version) + `"`}}
+}
+
+// etagVersion reads the note version out of the ETag of resp, or returns 0
+// if it has none.
+func etagVersion(resp *http.Response) int {
+	version, _ := strconv.Atoi(strings.Trim(resp.Header.Get("ETag"), `"`))
+	return version
+}